@@ -346,6 +346,10 @@ func (a *API) RegisterCompactor(c *compactor.MultitenantCompactor) {
 		false, http.MethodPost)
 	a.RegisterRoute("/api/v1/upload/block/{block}/files", http.HandlerFunc(c.UploadBlockFile),
 		true, false, http.MethodPost)
+	a.RegisterRoute("/api/v1/upload/block/{block}/state", http.HandlerFunc(c.GetBlockUploadState),
+		true, false, http.MethodGet)
+	a.RegisterRoute("/api/v1/upload/enabled", http.HandlerFunc(c.GetBlockUploadEnabled),
+		true, false, http.MethodGet)
 }
 
 type Distributor interface {
@@ -251,6 +251,92 @@ func (c *MultitenantCompactor) UploadBlockFile(w http.ResponseWriter, r *http.Re
 	w.WriteHeader(http.StatusOK)
 }
 
+// Block upload states, as returned by GetBlockUploadState.
+const (
+	blockUploadStateUploading = "uploading"
+	blockUploadStateComplete  = "complete"
+)
+
+type blockUploadStateResponse struct {
+	State string `json:"state"`
+}
+
+// GetBlockUploadState handles requests for the state of a block upload, which is "uploading"
+// from the start of the upload until it's completed, and "complete" afterwards. A block whose
+// upload hasn't been started, and which isn't in block storage, results in a not found error.
+// Since blocks aren't validated once uploaded, there are no states for a pending validation
+// or a failed upload: a block is complete as soon as its meta file is uploaded.
+func (c *MultitenantCompactor) GetBlockUploadState(w http.ResponseWriter, r *http.Request) {
+	const op = "get block upload state"
+
+	vars := mux.Vars(r)
+	blockID := vars["block"]
+	bULID, err := ulid.Parse(blockID)
+	if err != nil {
+		http.Error(w, "invalid block ID", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	tenantID, err := tenant.TenantID(ctx)
+	if err != nil {
+		http.Error(w, "invalid tenant ID", http.StatusBadRequest)
+		return
+	}
+	if !c.cfgProvider.CompactorBlockUploadEnabled(tenantID) {
+		http.Error(w, "block upload is disabled", http.StatusBadRequest)
+		return
+	}
+
+	logger := log.With(util_log.WithContext(ctx, c.logger), "block", blockID)
+
+	userBkt := bucket.NewUserBucketClient(tenantID, c.bucketClient, c.cfgProvider)
+	state, err := getBlockUploadState(ctx, bULID, userBkt)
+	if err != nil {
+		writeBlockUploadError(err, op, "", logger, w)
+		return
+	}
+
+	util.WriteJSONResponse(w, blockUploadStateResponse{State: state})
+}
+
+type blockUploadEnabledResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+// GetBlockUploadEnabled handles requests for whether block upload is enabled for the tenant,
+// so that clients can check it before starting to upload blocks.
+func (c *MultitenantCompactor) GetBlockUploadEnabled(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, "invalid tenant ID", http.StatusBadRequest)
+		return
+	}
+
+	util.WriteJSONResponse(w, blockUploadEnabledResponse{Enabled: c.cfgProvider.CompactorBlockUploadEnabled(tenantID)})
+}
+
+func getBlockUploadState(ctx context.Context, blockID ulid.ULID, userBkt objstore.Bucket) (string, error) {
+	// A complete block may still have its in-flight meta file, if deleting it failed,
+	// so the meta file uploaded when completing the upload is checked first.
+	for _, f := range []struct{ name, state string }{
+		{block.MetaFilename, blockUploadStateComplete},
+		{uploadingMetaFilename, blockUploadStateUploading},
+	} {
+		exists, err := userBkt.Exists(ctx, path.Join(blockID.String(), f.name))
+		if err != nil {
+			return "", errors.Wrap(err, fmt.Sprintf("failed to check existence of %s in object storage", f.name))
+		}
+		if exists {
+			return f.state, nil
+		}
+	}
+
+	return "", httpError{
+		message:    fmt.Sprintf("upload of block %s not started yet", blockID),
+		statusCode: http.StatusNotFound,
+	}
+}
+
 func decodeMeta(r io.Reader, name string) (metadata.Meta, error) {
 	dec := json.NewDecoder(r)
 	var meta metadata.Meta
@@ -1234,3 +1234,192 @@ func uploadMeta(t *testing.T, bkt *objstore.InMemBucket, pth string, meta metada
 	ctx := context.Background()
 	require.NoError(t, bkt.Upload(ctx, pth, buf))
 }
+
+func TestMultitenantCompactor_GetBlockUploadState(t *testing.T) {
+	const tenantID = "test"
+	const blockID = "01G3FZ0JWJYJC0ZM6Y9778P6KD"
+	uploadingMetaPath := path.Join(tenantID, blockID, fmt.Sprintf("uploading-%s", block.MetaFilename))
+	metaPath := path.Join(tenantID, blockID, block.MetaFilename)
+
+	testCases := []struct {
+		name                   string
+		tenantID               string
+		blockID                string
+		disableBlockUpload     bool
+		expBadRequest          string
+		expNotFound            string
+		expInternalServerError bool
+		expBody                string
+		setUpBucketMock        func(bkt *bucket.ClientMock)
+	}{
+		{
+			name:          "without tenant ID",
+			blockID:       blockID,
+			expBadRequest: "invalid tenant ID",
+		},
+		{
+			name:          "invalid block ID",
+			tenantID:      tenantID,
+			blockID:       "1234",
+			expBadRequest: "invalid block ID",
+		},
+		{
+			name:               "block upload disabled",
+			tenantID:           tenantID,
+			blockID:            blockID,
+			disableBlockUpload: true,
+			expBadRequest:      "block upload is disabled",
+		},
+		{
+			name:     "complete block",
+			tenantID: tenantID,
+			blockID:  blockID,
+			setUpBucketMock: func(bkt *bucket.ClientMock) {
+				bkt.MockExists(metaPath, true, nil)
+			},
+			expBody: `{"state":"complete"}`,
+		},
+		{
+			name:     "upload in progress",
+			tenantID: tenantID,
+			blockID:  blockID,
+			setUpBucketMock: func(bkt *bucket.ClientMock) {
+				bkt.MockExists(metaPath, false, nil)
+				bkt.MockExists(uploadingMetaPath, true, nil)
+			},
+			expBody: `{"state":"uploading"}`,
+		},
+		{
+			name:     "upload not started",
+			tenantID: tenantID,
+			blockID:  blockID,
+			setUpBucketMock: func(bkt *bucket.ClientMock) {
+				bkt.MockExists(metaPath, false, nil)
+				bkt.MockExists(uploadingMetaPath, false, nil)
+			},
+			expNotFound: fmt.Sprintf("upload of block %s not started yet", blockID),
+		},
+		{
+			name:     "checking for complete block fails",
+			tenantID: tenantID,
+			blockID:  blockID,
+			setUpBucketMock: func(bkt *bucket.ClientMock) {
+				bkt.MockExists(metaPath, false, fmt.Errorf("test"))
+			},
+			expInternalServerError: true,
+		},
+		{
+			name:     "checking for in-flight meta file fails",
+			tenantID: tenantID,
+			blockID:  blockID,
+			setUpBucketMock: func(bkt *bucket.ClientMock) {
+				bkt.MockExists(metaPath, false, nil)
+				bkt.MockExists(uploadingMetaPath, false, fmt.Errorf("test"))
+			},
+			expInternalServerError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var bkt bucket.ClientMock
+			if tc.setUpBucketMock != nil {
+				tc.setUpBucketMock(&bkt)
+			}
+			cfgProvider := newMockConfigProvider()
+			cfgProvider.blockUploadEnabled[tc.tenantID] = !tc.disableBlockUpload
+			c := &MultitenantCompactor{
+				logger:       log.NewNopLogger(),
+				bucketClient: &bkt,
+				cfgProvider:  cfgProvider,
+			}
+			r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/upload/block/%s/state", tc.blockID), nil)
+			if tc.tenantID != "" {
+				r = r.WithContext(user.InjectOrgID(r.Context(), tc.tenantID))
+			}
+			if tc.blockID != "" {
+				r = mux.SetURLVars(r, map[string]string{"block": tc.blockID})
+			}
+			w := httptest.NewRecorder()
+			c.GetBlockUploadState(w, r)
+
+			resp := w.Result()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			switch {
+			case tc.expBadRequest != "":
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+				assert.Equal(t, fmt.Sprintf("%s\n", tc.expBadRequest), string(body))
+			case tc.expNotFound != "":
+				assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+				assert.Equal(t, fmt.Sprintf("%s\n", tc.expNotFound), string(body))
+			case tc.expInternalServerError:
+				assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+				assert.Equal(t, "internal server error\n", string(body))
+			default:
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+				assert.JSONEq(t, tc.expBody, string(body))
+			}
+
+			bkt.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMultitenantCompactor_GetBlockUploadEnabled(t *testing.T) {
+	const tenantID = "test"
+
+	testCases := []struct {
+		name               string
+		tenantID           string
+		disableBlockUpload bool
+		expBadRequest      string
+		expBody            string
+	}{
+		{
+			name:          "without tenant ID",
+			expBadRequest: "invalid tenant ID",
+		},
+		{
+			name:     "block upload enabled",
+			tenantID: tenantID,
+			expBody:  `{"enabled":true}`,
+		},
+		{
+			name:               "block upload disabled",
+			tenantID:           tenantID,
+			disableBlockUpload: true,
+			expBody:            `{"enabled":false}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfgProvider := newMockConfigProvider()
+			cfgProvider.blockUploadEnabled[tc.tenantID] = !tc.disableBlockUpload
+			c := &MultitenantCompactor{
+				logger:      log.NewNopLogger(),
+				cfgProvider: cfgProvider,
+			}
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/upload/enabled", nil)
+			if tc.tenantID != "" {
+				r = r.WithContext(user.InjectOrgID(r.Context(), tc.tenantID))
+			}
+			w := httptest.NewRecorder()
+			c.GetBlockUploadEnabled(w, r)
+
+			resp := w.Result()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			if tc.expBadRequest != "" {
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+				assert.Equal(t, fmt.Sprintf("%s\n", tc.expBadRequest), string(body))
+				return
+			}
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+			assert.JSONEq(t, tc.expBody, string(body))
+		})
+	}
+}
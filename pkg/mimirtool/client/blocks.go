@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+)
+
+// BlockUploadState is the server-side state of a block upload.
+type BlockUploadState string
+
+const (
+	// BlockUploadStateUploading is the state of a block whose upload has been started, but not completed yet.
+	BlockUploadStateUploading BlockUploadState = "uploading"
+
+	// BlockUploadStateComplete is the state of a block whose upload has been completed, which is in block storage.
+	BlockUploadStateComplete BlockUploadState = "complete"
+)
+
+// BlockUploadStatus returns the state of the upload of the block, for the blocks uploaded by another
+// process. It returns ErrResourceNotFound if the upload of the block hasn't been started.
+func (r *MimirClient) BlockUploadStatus(ctx context.Context, blockID ulid.ULID) (BlockUploadState, error) {
+	path := fmt.Sprintf("/api/v1/upload/block/%s/state", blockID)
+
+	res, err := r.doRequest(path, "GET", nil)
+	if err != nil {
+		return "", err
+	}
+
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		State BlockUploadState `json:"state"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", errors.Wrap(err, "unable to unmarshal response")
+	}
+
+	switch result.State {
+	case BlockUploadStateUploading, BlockUploadStateComplete:
+		return result.State, nil
+	default:
+		return "", fmt.Errorf("unknown upload state %q of block %s", result.State, blockID)
+	}
+}
+
+// ErrBlockUploadDisabled is returned by CheckBlockUploadEnabled if block upload isn't enabled for the tenant.
+var ErrBlockUploadDisabled = errors.New("block upload is not enabled for this tenant")
+
+// BlockUploadEnabled returns whether block upload is enabled for the tenant, as configured on the server.
+func (r *MimirClient) BlockUploadEnabled(ctx context.Context) (bool, error) {
+	res, err := r.doRequest("/api/v1/upload/enabled", "GET", nil)
+	if err != nil {
+		return false, err
+	}
+
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return false, err
+	}
+
+	var result struct {
+		Enabled bool `json:"enabled"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return false, errors.Wrap(err, "unable to unmarshal response")
+	}
+	return result.Enabled, nil
+}
+
+// CheckBlockUploadEnabled is a preflight check for the callers uploading blocks, which returns
+// ErrBlockUploadDisabled if block upload isn't enabled for the tenant, rather than letting the
+// uploads fail with an opaque error.
+func (r *MimirClient) CheckBlockUploadEnabled(ctx context.Context) error {
+	enabled, err := r.BlockUploadEnabled(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to check whether block upload is enabled")
+	}
+	if !enabled {
+		return ErrBlockUploadDisabled
+	}
+	return nil
+}
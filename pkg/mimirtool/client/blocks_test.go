@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oklog/ulid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMimirClient_BlockUploadStatus(t *testing.T) {
+	blockID := ulid.MustParse("01G3FZ0JWJYJC0ZM6Y9778P6KD")
+
+	for _, tc := range []struct {
+		test     string
+		status   int
+		body     string
+		expState BlockUploadState
+		expErr   string
+		notFound bool
+	}{
+		{
+			test:     "uploading",
+			status:   http.StatusOK,
+			body:     `{"state":"uploading"}`,
+			expState: BlockUploadStateUploading,
+		},
+		{
+			test:     "complete",
+			status:   http.StatusOK,
+			body:     `{"state":"complete"}`,
+			expState: BlockUploadStateComplete,
+		},
+		{
+			test:   "unknown state",
+			status: http.StatusOK,
+			body:   `{"state":"validating"}`,
+			expErr: `unknown upload state "validating" of block 01G3FZ0JWJYJC0ZM6Y9778P6KD`,
+		},
+		{
+			test:   "invalid response",
+			status: http.StatusOK,
+			body:   `{`,
+			expErr: "unable to unmarshal response: unexpected end of JSON input",
+		},
+		{
+			test:     "unknown block",
+			status:   http.StatusNotFound,
+			body:     "upload of block 01G3FZ0JWJYJC0ZM6Y9778P6KD not started yet",
+			notFound: true,
+		},
+		{
+			test:   "server error",
+			status: http.StatusInternalServerError,
+			body:   "internal server error",
+			expErr: "server returned HTTP status 500 Internal Server Error: internal server error",
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			requestCh := make(chan *http.Request, 1)
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requestCh <- r
+				w.WriteHeader(tc.status)
+				fmt.Fprint(w, tc.body)
+			}))
+			defer ts.Close()
+
+			client, err := New(Config{Address: ts.URL, ID: "my-id"})
+			require.NoError(t, err)
+
+			state, err := client.BlockUploadStatus(context.Background(), blockID)
+
+			req := <-requestCh
+			assert.Equal(t, http.MethodGet, req.Method)
+			assert.Equal(t, "/api/v1/upload/block/01G3FZ0JWJYJC0ZM6Y9778P6KD/state", req.URL.Path)
+			assert.Equal(t, "my-id", req.Header.Get("X-Scope-OrgID"))
+
+			switch {
+			case tc.notFound:
+				assert.ErrorIs(t, err, ErrResourceNotFound)
+			case tc.expErr != "":
+				assert.EqualError(t, err, tc.expErr)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tc.expState, state)
+			}
+		})
+	}
+}
+
+func TestMimirClient_CheckBlockUploadEnabled(t *testing.T) {
+	for _, tc := range []struct {
+		test        string
+		status      int
+		body        string
+		expEnabled  bool
+		expErr      string
+		expCheckErr string
+		disabled    bool
+	}{
+		{
+			test:       "enabled",
+			status:     http.StatusOK,
+			body:       `{"enabled":true}`,
+			expEnabled: true,
+		},
+		{
+			test:     "disabled",
+			status:   http.StatusOK,
+			body:     `{"enabled":false}`,
+			disabled: true,
+		},
+		{
+			test:        "invalid response",
+			status:      http.StatusOK,
+			body:        `{`,
+			expErr:      "unable to unmarshal response: unexpected end of JSON input",
+			expCheckErr: "unable to check whether block upload is enabled: unable to unmarshal response: unexpected end of JSON input",
+		},
+		{
+			test:        "server error",
+			status:      http.StatusInternalServerError,
+			body:        "internal server error",
+			expErr:      "server returned HTTP status 500 Internal Server Error: internal server error",
+			expCheckErr: "unable to check whether block upload is enabled: server returned HTTP status 500 Internal Server Error: internal server error",
+		},
+	} {
+		t.Run(tc.test, func(t *testing.T) {
+			requestCh := make(chan *http.Request, 2)
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requestCh <- r
+				w.WriteHeader(tc.status)
+				fmt.Fprint(w, tc.body)
+			}))
+			defer ts.Close()
+
+			client, err := New(Config{Address: ts.URL, ID: "my-id"})
+			require.NoError(t, err)
+
+			enabled, err := client.BlockUploadEnabled(context.Background())
+
+			req := <-requestCh
+			assert.Equal(t, http.MethodGet, req.Method)
+			assert.Equal(t, "/api/v1/upload/enabled", req.URL.Path)
+			assert.Equal(t, "my-id", req.Header.Get("X-Scope-OrgID"))
+
+			if tc.expErr != "" {
+				assert.EqualError(t, err, tc.expErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expEnabled, enabled)
+			}
+
+			err = client.CheckBlockUploadEnabled(context.Background())
+			<-requestCh
+
+			switch {
+			case tc.disabled:
+				assert.ErrorIs(t, err, ErrBlockUploadDisabled)
+			case tc.expCheckErr != "":
+				assert.EqualError(t, err, tc.expCheckErr)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
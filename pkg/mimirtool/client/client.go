@@ -40,6 +40,13 @@ type Config struct {
 	TLS             tls.ClientConfig
 	UseLegacyRoutes bool   `yaml:"use_legacy_routes"`
 	AuthToken       string `yaml:"auth_token"`
+
+	// UserAgent is sent with every request, if not empty.
+	UserAgent string `yaml:"user_agent"`
+
+	// ExtraHeaders are added to every request. They can't override the
+	// tenant and authentication headers set by the client.
+	ExtraHeaders map[string]string `yaml:"extra_headers"`
 }
 
 // MimirClient is used to get and load rules into a Mimir ruler.
@@ -51,6 +58,9 @@ type MimirClient struct {
 	Client    http.Client
 	apiPath   string
 	authToken string
+
+	userAgent    string
+	extraHeaders map[string]string
 }
 
 // New returns a new MimirClient.
@@ -99,6 +109,9 @@ func New(cfg Config) (*MimirClient, error) {
 		Client:    client,
 		apiPath:   path,
 		authToken: cfg.AuthToken,
+
+		userAgent:    cfg.UserAgent,
+		extraHeaders: cfg.ExtraHeaders,
 	}, nil
 }
 
@@ -122,6 +135,14 @@ func (r *MimirClient) doRequest(path, method string, payload []byte) (*http.Resp
 		return nil, err
 	}
 
+	// Extra headers are set first, so that the headers set by the client take precedence.
+	for name, value := range r.extraHeaders {
+		req.Header.Set(name, value)
+	}
+	if r.userAgent != "" {
+		req.Header.Set("User-Agent", r.userAgent)
+	}
+
 	switch {
 	case (r.user != "" || r.key != "") && r.authToken != "":
 		err := errors.New("at most one of basic auth or auth token should be configured")
@@ -139,10 +160,10 @@ func (r *MimirClient) doRequest(path, method string, payload []byte) (*http.Resp
 		req.SetBasicAuth(r.id, r.key)
 
 	case r.authToken != "":
-		req.Header.Add("Authorization", "Bearer "+r.authToken)
+		req.Header.Set("Authorization", "Bearer "+r.authToken)
 	}
 
-	req.Header.Add("X-Scope-OrgID", r.id)
+	req.Header.Set("X-Scope-OrgID", r.id)
 
 	log.WithFields(log.Fields{
 		"url":    req.URL.String(),
@@ -7,6 +7,7 @@ package client
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 
@@ -98,3 +99,64 @@ func TestBuildURL(t *testing.T) {
 	}
 
 }
+
+func TestDoRequest_Headers(t *testing.T) {
+	tc := []struct {
+		name     string
+		cfg      Config
+		expected map[string]string
+	}{
+		{
+			name:     "default headers",
+			cfg:      Config{ID: "my-id"},
+			expected: map[string]string{"X-Scope-Orgid": "my-id", "User-Agent": "Go-http-client/1.1"},
+		},
+		{
+			name:     "user agent",
+			cfg:      Config{ID: "my-id", UserAgent: "backfill/1.0"},
+			expected: map[string]string{"X-Scope-Orgid": "my-id", "User-Agent": "backfill/1.0"},
+		},
+		{
+			name: "extra headers",
+			cfg: Config{ID: "my-id", UserAgent: "backfill/1.0", ExtraHeaders: map[string]string{
+				"X-Trace-Id":      "1234",
+				"X-Proxy-Token":   "secret",
+				"X-Scope-OrgID":   "other-id",
+				"User-Agent":      "ignored",
+				"Authorization":   "Bearer ignored",
+				"x-custom-header": "lowercase",
+			}, AuthToken: "my-token"},
+			expected: map[string]string{
+				"X-Scope-Orgid":   "my-id",
+				"User-Agent":      "backfill/1.0",
+				"Authorization":   "Bearer my-token",
+				"X-Trace-Id":      "1234",
+				"X-Proxy-Token":   "secret",
+				"X-Custom-Header": "lowercase",
+			},
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			requestCh := make(chan *http.Request, 1)
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requestCh <- r
+			}))
+			defer ts.Close()
+
+			tt.cfg.Address = ts.URL
+			client, err := New(tt.cfg)
+			require.NoError(t, err)
+
+			resp, err := client.doRequest("/api/v1/upload", http.MethodPost, []byte("payload"))
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+
+			req := <-requestCh
+			for name, value := range tt.expected {
+				require.Equal(t, []string{value}, req.Header.Values(name), name)
+			}
+		})
+	}
+}
@@ -260,7 +260,7 @@ func (i *InspectedEntry) zeroValuePtr() Value {
 	case "list of string":
 		typ = reflect.TypeOf(stringSlice{})
 	default:
-		typ = parse.ReflectType(i.FieldType)
+		typ = parse.ReflectType(parse.ParseTypeSpec(i.FieldType))
 	}
 
 	return InterfaceValue(reflect.New(typ).Interface()) // create a new typed pointer
@@ -480,7 +480,7 @@ func InspectConfig(cfg flagext.RegistererWithLogger) (*InspectedEntry, error) {
 
 // InspectConfigWithFlags does the same as InspectConfig while allowing to provide custom CLI flags. This is
 // useful when the configuration struct does not implement flagext.RegistererWithLogger.
-func InspectConfigWithFlags(cfg interface{}, flags map[uintptr]*flag.Flag) (*InspectedEntry, error) {
+func InspectConfigWithFlags(cfg interface{}, flags map[uintptr][]*flag.Flag) (*InspectedEntry, error) {
 	blocks, err := parse.Config(cfg, flags, parse.RootBlocks)
 	if err != nil {
 		return nil, errors.Wrap(err, "couldn't generate parsed config")
@@ -522,7 +522,7 @@ func convertEntryToEntry(entry *parse.ConfigEntry) *InspectedEntry {
 		e.FieldFlag = entry.FieldFlag
 		e.FieldType = entry.FieldType
 		e.FieldCategory = entry.FieldCategory
-		e.FieldDefaultValue = parseDefaultValue(e, entry.FieldDefault)
+		e.FieldDefaultValue = parseDefaultValue(e, entry.RawDefault())
 	default:
 		panic(fmt.Sprintf("cannot handle parse kind %q, entry name: %s", entry.Kind, entry.Name))
 	}
@@ -531,9 +531,10 @@ func convertEntryToEntry(entry *parse.ConfigEntry) *InspectedEntry {
 
 func parseDefaultValue(e *InspectedEntry, def string) Value {
 	yamlNodeKind := yaml.ScalarNode
-	if strings.HasPrefix(e.FieldType, "map") {
+	switch parse.ParseTypeSpec(e.FieldType).Kind {
+	case parse.TypeMap:
 		yamlNodeKind = yaml.MappingNode
-	} else if strings.HasPrefix(e.FieldType, "list") {
+	case parse.TypeList:
 		yamlNodeKind = yaml.SequenceNode
 	}
 	value, _ := e.decodeValue(&yaml.Node{Kind: yamlNodeKind, Value: def})
@@ -28,6 +28,11 @@ func (c Category) String() string {
 	}
 }
 
+// All returns all the field categories, from the most basic to the least stable.
+func All() []Category {
+	return []Category{Basic, Advanced, Experimental}
+}
+
 // Fields are primarily categorized via struct tags, but this can be impossible when third party libraries are involved
 // Only categorize fields here when you can't otherwise, since struct tags are less likely to become stale
 var overrides = map[string]Category{
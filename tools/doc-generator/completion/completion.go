@@ -0,0 +1,252 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package completion generates shell completion scripts for the CLI flags of the configuration.
+package completion
+
+import (
+	"flag"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/grafana/regexp"
+
+	"github.com/grafana/mimir/tools/doc-generator/parse"
+)
+
+// flagSpec describes how a CLI flag is completed.
+type flagSpec struct {
+	Name string
+	Desc string
+
+	// The type of the config field set by the flag, as documented, empty if unknown.
+	Type string
+
+	// The values the flag is completed with, if any.
+	Values []string
+}
+
+// valueGroup is a set of flags completed with the same values.
+type valueGroup struct {
+	Flags  []string
+	Values []string
+}
+
+type script struct {
+	Program  string
+	Function string
+	Flags    []flagSpec
+	Groups   []valueGroup
+}
+
+var nonIdentifierRegexp = regexp.MustCompile("[^a-zA-Z0-9_]")
+
+// WriteBash writes the bash completion script of the program, completing the input flags, as
+// returned by parse.Flags, with the types of the fields of the blocks, as returned by parse.Config.
+// Flag names in the blocks must not have been replaced by the "<prefix>" placeholder yet.
+func WriteBash(w io.Writer, program string, flags map[uintptr][]*flag.Flag, blocks []*parse.ConfigBlock) error {
+	return bashTemplate.Execute(w, newScript(program, flags, blocks))
+}
+
+// WriteZsh writes the zsh completion script of the program, the same way WriteBash does.
+func WriteZsh(w io.Writer, program string, flags map[uintptr][]*flag.Flag, blocks []*parse.ConfigBlock) error {
+	return zshTemplate.Execute(w, newScript(program, flags, blocks))
+}
+
+func newScript(program string, flags map[uintptr][]*flag.Flag, blocks []*parse.ConfigBlock) *script {
+	s := &script{
+		Program:  program,
+		Function: "_" + nonIdentifierRegexp.ReplaceAllString(program, "_"),
+		Flags:    flagSpecs(flags, blocks),
+	}
+
+	groups := map[string]*valueGroup{}
+	for _, spec := range s.Flags {
+		if len(spec.Values) == 0 {
+			continue
+		}
+
+		key := strings.Join(spec.Values, " ")
+		if groups[key] == nil {
+			groups[key] = &valueGroup{Values: spec.Values}
+		}
+		groups[key].Flags = append(groups[key].Flags, spec.Name)
+	}
+	for _, group := range groups {
+		s.Groups = append(s.Groups, *group)
+	}
+	sort.Slice(s.Groups, func(i, j int) bool {
+		return s.Groups[i].Flags[0] < s.Groups[j].Flags[0]
+	})
+
+	return s
+}
+
+// flagSpecs returns the completion spec of each flag, sorted by name. Deprecated flags are skipped.
+// Flags not documented by the blocks, like the ones without config field, are completed according
+// to the type of their value.
+func flagSpecs(flags map[uintptr][]*flag.Flag, blocks []*parse.ConfigBlock) []flagSpec {
+	types := map[string]string{}
+	for _, block := range blocks {
+		collectFlagTypes(block, types)
+	}
+
+	valueTypes := map[string]string{}
+	for _, f := range parse.FlagTypes(flags) {
+		valueTypes[f.Name] = f.Type
+	}
+
+	var specs []flagSpec
+	for _, fieldFlags := range flags {
+		for _, f := range fieldFlags {
+			if isDeprecated(f) {
+				continue
+			}
+
+			spec := flagSpec{Name: f.Name, Desc: summary(f.Usage), Type: types[f.Name]}
+			if spec.Type == "" {
+				spec.Type = valueTypes[f.Name]
+			}
+			if spec.Type == "boolean" {
+				spec.Values = []string{"true", "false"}
+			}
+			specs = append(specs, spec)
+		}
+	}
+
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Name < specs[j].Name
+	})
+	return specs
+}
+
+// collectFlagTypes maps the flags of the fields of the block to their type, recursing
+// into nested blocks. Root blocks are collected on their own.
+func collectFlagTypes(block *parse.ConfigBlock, types map[string]string) {
+	for _, entry := range block.Entries {
+		if entry.Kind == parse.KindBlock {
+			if !entry.Root {
+				collectFlagTypes(entry.Block, types)
+			}
+			continue
+		}
+
+		if entry.FieldFlag == "" {
+			continue
+		}
+		for _, name := range append([]string{entry.FieldFlag}, entry.FieldFlagAlternates...) {
+			types[name] = entry.FieldType
+		}
+	}
+}
+
+// isDeprecated returns whether the flag is deprecated, either replaced by a placeholder
+// whose value is "deprecated" or documented as deprecated in its usage.
+func isDeprecated(f *flag.Flag) bool {
+	if f.Value.String() == "deprecated" {
+		return true
+	}
+
+	usage := strings.ToLower(f.Usage)
+	return strings.HasPrefix(usage, "deprecated") || strings.HasPrefix(usage, "[deprecated]")
+}
+
+// summary returns the first sentence of the usage.
+func summary(usage string) string {
+	usage = strings.Join(strings.Fields(usage), " ")
+	if i := strings.Index(usage, ". "); i >= 0 {
+		return usage[:i+1]
+	}
+	return usage
+}
+
+// zshQuote returns the description escaped for an _arguments spec within single quotes.
+func zshQuote(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`[`, `\[`,
+		`]`, `\]`,
+		`:`, `\:`,
+		`'`, `'\''`,
+	).Replace(s)
+}
+
+// zshAction returns the message and the action completing the value of the flag.
+func zshAction(spec flagSpec) string {
+	message := spec.Type
+	if message == "" {
+		message = "value"
+	}
+
+	switch {
+	case spec.Type == "boolean":
+		// Boolean flags only accept a value in the same word, and it's optional.
+		return ":" + message + ":(" + strings.Join(spec.Values, " ") + ")"
+	case len(spec.Values) > 0:
+		return message + ":(" + strings.Join(spec.Values, " ") + ")"
+	case spec.Type == "string":
+		return message + ":_default"
+	default:
+		return message + ": "
+	}
+}
+
+var funcs = template.FuncMap{
+	"join":      strings.Join,
+	"zshQuote":  zshQuote,
+	"zshAction": zshAction,
+}
+
+var bashTemplate = template.Must(template.New("bash").Funcs(funcs).Parse(`# bash completion for {{.Program}}.
+# DO NOT EDIT - This file has been automatically generated by the doc-generator.
+
+{{.Function}}_flags="
+{{- range .Flags}}
+-{{.Name}}
+{{- end}}
+"
+
+{{.Function}}() {
+	local cur="${COMP_WORDS[COMP_CWORD]}" flag="" prefix=""
+
+	# The value of a flag may be split into its own word, depending on COMP_WORDBREAKS.
+	if [[ ${cur} == "=" ]]; then
+		flag="${COMP_WORDS[COMP_CWORD-1]}"
+		cur=""
+	elif [[ ${COMP_CWORD} -ge 2 && ${COMP_WORDS[COMP_CWORD-1]} == "=" ]]; then
+		flag="${COMP_WORDS[COMP_CWORD-2]}"
+	elif [[ ${cur} == -*=* ]]; then
+		flag="${cur%%=*}"
+		prefix="${flag}="
+		cur="${cur#*=}"
+	fi
+
+	if [[ -n ${flag} ]]; then
+		case "${flag#-}" in
+{{- range .Groups}}
+		{{join .Flags "|"}})
+			COMPREPLY=($(compgen -P "${prefix}" -W "{{join .Values " "}}" -- "${cur}"))
+			;;
+{{- end}}
+		esac
+		return
+	fi
+
+	if [[ ${cur} == -* ]]; then
+		COMPREPLY=($(compgen -W "{{printf "${%s_flags}" .Function}}" -- "${cur}"))
+	fi
+}
+
+complete -F {{.Function}} {{.Program}}
+`))
+
+var zshTemplate = template.Must(template.New("zsh").Funcs(funcs).Parse(`#compdef {{.Program}}
+# zsh completion for {{.Program}}.
+# DO NOT EDIT - This file has been automatically generated by the doc-generator.
+
+_arguments
+{{- range .Flags}} \
+	'-{{.Name}}={{if eq .Type "boolean"}}-{{end}}[{{zshQuote .Desc}}]:{{zshAction .}}'
+{{- end}}
+`))
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package completion
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/tools/doc-generator/parse"
+)
+
+type fixtureConfig struct {
+	Target  string        `yaml:"target"`
+	Enabled bool          `yaml:"enabled"`
+	Timeout time.Duration `yaml:"timeout"`
+	Limit   int           `yaml:"limit"`
+	Old     int           `yaml:"old"`
+	Client  struct {
+		Compress bool `yaml:"compress"`
+	} `yaml:"client"`
+}
+
+func fixture(t *testing.T) (map[uintptr][]*flag.Flag, []*parse.ConfigBlock) {
+	cfg := &fixtureConfig{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.StringVar(&cfg.Target, "target", "all", "Comma-separated list of [components] to run: it's 'all' by default. Other sentences are dropped.")
+	fs.BoolVar(&cfg.Enabled, "enabled", false, "Whether it's enabled.")
+	fs.DurationVar(&cfg.Timeout, "timeout", time.Minute, "The timeout.")
+	fs.IntVar(&cfg.Limit, "limit", 10, "The limit.")
+	fs.IntVar(&cfg.Old, "old", 0, "Deprecated: use -limit instead.")
+	fs.BoolVar(&cfg.Client.Compress, "client.compress", false, "Whether to compress.")
+	fs.BoolVar(&cfg.Client.Compress, "legacy.client.compress", false, "Whether to compress.")
+
+	// A flag without any config field.
+	fs.Bool("print.version", false, "Print the version and exit.")
+
+	flags := map[uintptr][]*flag.Flag{}
+	fs.VisitAll(func(f *flag.Flag) {
+		ptr := reflect.ValueOf(f.Value).Pointer()
+		flags[ptr] = append(flags[ptr], f)
+	})
+
+	blocks, err := parse.Config(cfg, flags, nil)
+	require.NoError(t, err)
+	return flags, blocks
+}
+
+func TestWrite(t *testing.T) {
+	tests := map[string]struct {
+		write   func(io.Writer, string, map[uintptr][]*flag.Flag, []*parse.ConfigBlock) error
+		fixture string
+	}{
+		"bash": {write: WriteBash, fixture: "testdata/fixture.bash"},
+		"zsh":  {write: WriteZsh, fixture: "testdata/fixture.zsh"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			flags, blocks := fixture(t)
+
+			out := &bytes.Buffer{}
+			require.NoError(t, test.write(out, "mimir", flags, blocks))
+
+			expected, err := os.ReadFile(test.fixture)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), out.String())
+		})
+	}
+}
@@ -0,0 +1,43 @@
+# bash completion for mimir.
+# DO NOT EDIT - This file has been automatically generated by the doc-generator.
+
+_mimir_flags="
+-client.compress
+-enabled
+-legacy.client.compress
+-limit
+-print.version
+-target
+-timeout
+"
+
+_mimir() {
+	local cur="${COMP_WORDS[COMP_CWORD]}" flag="" prefix=""
+
+	# The value of a flag may be split into its own word, depending on COMP_WORDBREAKS.
+	if [[ ${cur} == "=" ]]; then
+		flag="${COMP_WORDS[COMP_CWORD-1]}"
+		cur=""
+	elif [[ ${COMP_CWORD} -ge 2 && ${COMP_WORDS[COMP_CWORD-1]} == "=" ]]; then
+		flag="${COMP_WORDS[COMP_CWORD-2]}"
+	elif [[ ${cur} == -*=* ]]; then
+		flag="${cur%%=*}"
+		prefix="${flag}="
+		cur="${cur#*=}"
+	fi
+
+	if [[ -n ${flag} ]]; then
+		case "${flag#-}" in
+		client.compress|enabled|legacy.client.compress|print.version)
+			COMPREPLY=($(compgen -P "${prefix}" -W "true false" -- "${cur}"))
+			;;
+		esac
+		return
+	fi
+
+	if [[ ${cur} == -* ]]; then
+		COMPREPLY=($(compgen -W "${_mimir_flags}" -- "${cur}"))
+	fi
+}
+
+complete -F _mimir mimir
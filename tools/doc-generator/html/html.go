@@ -0,0 +1,295 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package html renders the configuration reference as a standalone HTML page.
+package html
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/grafana/mimir/tools/doc-generator/parse"
+)
+
+// page is the data model of the HTML template.
+type page struct {
+	Blocks []*blockView
+
+	// JSON index of all fields, embedded in the page to be searched by external tools.
+	Index string
+}
+
+type blockView struct {
+	ID      string
+	Name    string
+	Desc    string
+	Entries []*entryView
+}
+
+type entryView struct {
+	ID       string
+	Path     string
+	Name     string
+	Kind     parse.EntryKind
+	Required bool
+
+	// In case of a block, either nested or a reference to a root block.
+	// In case of a slice, the entries are the ones of its element.
+	BlockDesc string
+	RefID     string
+	RefName   string
+	Entries   []*entryView
+
+	// In case of a field.
+	Desc      string
+	Type      string
+	Unit      string
+	Default   string
+	Flag      string
+	Category  string
+	Example   string
+	Warnings  []string
+	Aliases   string
+	Conflicts string
+
+	DeprecatedInFavorOf string
+	Reloadable          bool
+	StartupOnly         bool
+	DefaultChanges      []parse.DefaultChange
+}
+
+type indexEntry struct {
+	ID       string `json:"id"`
+	Path     string `json:"path"`
+	Flag     string `json:"flag,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Category string `json:"category,omitempty"`
+	Desc     string `json:"desc,omitempty"`
+}
+
+// Write renders the blocks, as returned by parse.Config, as a standalone HTML page.
+// Each block is rendered once, even if multiple blocks share the same name.
+func Write(w io.Writer, blocks []*parse.ConfigBlock) error {
+	p := &page{}
+	var index []indexEntry
+
+	seen := map[string]bool{}
+	for _, block := range blocks {
+		if seen[block.Name] {
+			continue
+		}
+		seen[block.Name] = true
+
+		b := &blockView{ID: blockID(block.Name), Name: block.Name, Desc: block.Desc}
+		entries, err := entryViews(block.Entries, block.Name, "", &index)
+		if err != nil {
+			return err
+		}
+		b.Entries = entries
+		p.Blocks = append(p.Blocks, b)
+	}
+
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	p.Index = string(data)
+
+	return pageTemplate.Execute(w, p)
+}
+
+func entryViews(entries []*parse.ConfigEntry, blockName, parentPath string, index *[]indexEntry) ([]*entryView, error) {
+	views := make([]*entryView, 0, len(entries))
+	for _, e := range entries {
+		path := e.Name
+		if parentPath != "" {
+			path = parentPath + "." + e.Name
+		}
+
+		v := &entryView{
+			ID:       fieldID(blockName, path),
+			Path:     path,
+			Name:     e.Name,
+			Kind:     e.Kind,
+			Required: e.Required,
+		}
+
+		if e.Kind == parse.KindBlock {
+			v.BlockDesc = e.BlockDesc
+			if !e.Root && !e.CategoryInherited && e.Block.Category != "" && e.Block.Category != "basic" {
+				v.Category = e.Block.Category
+			}
+			if e.Root {
+				v.RefID = blockID(e.Block.Name)
+				v.RefName = e.Block.Name
+			} else {
+				nested, err := entryViews(e.Block.Entries, blockName, path, index)
+				if err != nil {
+					return nil, err
+				}
+				v.Entries = nested
+			}
+
+			views = append(views, v)
+			continue
+		}
+
+		v.Desc = e.Description()
+		v.Type = e.FieldType
+		v.Unit = e.FieldUnit
+		v.Default = e.DisplayDefault()
+		v.Flag = e.FieldFlag
+		v.Warnings = e.FieldWarnings
+		v.DeprecatedInFavorOf = e.DeprecatedInFavorOf
+		v.Reloadable = e.FieldReloadable
+		v.StartupOnly = e.StartupOnly
+		v.DefaultChanges = e.FieldDefaultChanges
+		v.Aliases = strings.Join(e.AliasesOf, ", ")
+		v.Conflicts = strings.Join(e.FieldConflictsWith, ", ")
+		v.Category = e.FieldCategory
+		if v.Category == "" {
+			v.Category = "basic"
+		}
+
+		if e.FieldExample != nil {
+			data, err := e.FieldExample.YAML()
+			if err != nil {
+				return nil, fmt.Errorf("can't render example of %s: %w", path, err)
+			}
+
+			example := string(data)
+			if e.FieldExample.Comment != "" {
+				example = "# " + strings.ReplaceAll(strings.TrimSpace(e.FieldExample.Comment), "\n", "\n# ") + "\n" + example
+			}
+			v.Example = example
+		}
+
+		*index = append(*index, indexEntry{
+			ID:       v.ID,
+			Path:     path,
+			Flag:     v.Flag,
+			Type:     v.Type,
+			Category: v.Category,
+			Desc:     v.Desc,
+		})
+
+		// The element block is also documented on its own, so its fields are nested under a distinct path.
+		if e.Element != nil {
+			elements, err := entryViews(e.Element.Entries, blockName, path+"[]", index)
+			if err != nil {
+				return nil, err
+			}
+			v.Entries = elements
+		}
+
+		views = append(views, v)
+	}
+
+	return views, nil
+}
+
+func blockID(name string) string {
+	if name == "" {
+		return "block-top"
+	}
+	return "block-" + name
+}
+
+func fieldID(blockName, path string) string {
+	if blockName == "" {
+		return path
+	}
+	return blockName + "." + path
+}
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Grafana Mimir configuration reference</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+details { margin: 0.5em 0 0.5em 1em; }
+summary { cursor: pointer; font-weight: bold; }
+.field { margin: 0.5em 0 0.5em 1em; padding: 0.3em; border-left: 2px solid #ddd; }
+.name { font-family: monospace; font-weight: bold; }
+.badge { font-size: 0.8em; padding: 0 0.4em; border-radius: 0.3em; background: #eee; }
+.badge-advanced { background: #fde9c8; }
+.badge-experimental { background: #f9d0d0; }
+.meta { font-family: monospace; font-size: 0.9em; color: #555; }
+.warning { margin: 0.5em 0; padding: 0.3em 0.5em; border-left: 4px solid #e0a000; background: #fff8e1; }
+.deprecated { margin: 0.5em 0; padding: 0.3em 0.5em; border-left: 4px solid #c00000; background: #fdecea; }
+pre { background: #f6f6f6; padding: 0.5em; }
+</style>
+</head>
+<body>
+<h1>Grafana Mimir configuration reference</h1>
+{{- range .Blocks}}
+<details id="{{.ID}}" open>
+<summary>{{if .Name}}{{.Name}}{{else}}Top-level configuration{{end}}</summary>
+{{- if .Desc}}
+<p>{{.Desc}}</p>
+{{- end}}
+{{- template "entries" .Entries}}
+</details>
+{{- end}}
+<pre id="search-index" hidden>{{.Index}}</pre>
+</body>
+</html>
+{{define "entries"}}
+{{- range .}}
+{{- if eq .Kind "block"}}
+{{- if .RefID}}
+<div class="field" id="{{.ID}}">
+<span class="name">{{.Name}}</span>: <a href="#{{.RefID}}">{{.RefName}}</a>
+{{- if .BlockDesc}}
+<p>{{.BlockDesc}}</p>
+{{- end}}
+</div>
+{{- else}}
+<details id="{{.ID}}">
+<summary>{{.Name}}{{if .Category}} <span class="badge badge-{{.Category}}">{{.Category}}</span>{{end}}</summary>
+{{- if .BlockDesc}}
+<p>{{.BlockDesc}}</p>
+{{- end}}
+{{- template "entries" .Entries}}
+</details>
+{{- end}}
+{{- else}}
+<div class="field" id="{{.ID}}" data-path="{{.Path}}"{{if .Flag}} data-flag="{{.Flag}}"{{end}}>
+<a class="name" href="#{{.ID}}">{{.Name}}</a> <span class="badge badge-{{.Category}}">{{.Category}}</span>{{if .Required}} <span class="badge">required</span>{{end}}{{if .Reloadable}} <span class="badge">reloadable</span>{{end}}{{if .StartupOnly}} <span class="badge">startup-only</span>{{end}}
+<div class="meta">type: {{.Type}}{{with .Unit}} ({{.}}){{end}} | default: {{.Default}}{{if .Flag}} | flag: -{{.Flag}}{{end}}</div>
+{{- if .Desc}}
+<p>{{.Desc}}</p>
+{{- end}}
+{{- if .DeprecatedInFavorOf}}
+<div class="deprecated">Deprecated: use {{.DeprecatedInFavorOf}} instead.</div>
+{{- end}}
+{{- range .DefaultChanges}}
+<p>Default changed from {{.Old}} in {{.Version}}.</p>
+{{- end}}
+{{- range .Warnings}}
+<div class="warning">Warning: {{.}}</div>
+{{- end}}
+{{- if .Conflicts}}
+<p>Cannot be used together with {{.Conflicts}}.</p>
+{{- end}}
+{{- if .Aliases}}
+<p>Alias of {{.Aliases}}.</p>
+{{- end}}
+{{- if .Example}}
+<pre class="example">{{.Example}}</pre>
+{{- end}}
+{{- if .Entries}}
+<details>
+<summary>Each element</summary>
+{{- template "entries" .Entries}}
+</details>
+{{- end}}
+</div>
+{{- end}}
+{{- end}}
+{{- end}}
+`))
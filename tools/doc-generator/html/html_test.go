@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package html
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/tools/doc-generator/parse"
+)
+
+type fixtureTargets []string
+
+func (fixtureTargets) ExampleDoc() (comment string, yaml interface{}) {
+	return "Scrape <two> targets.", []string{"a", "b"}
+}
+
+type fixtureServerConfig struct {
+	Port int `yaml:"port"`
+}
+
+type fixtureRuleConfig struct {
+	Name string `yaml:"name"`
+}
+
+type fixtureConfig struct {
+	Target  string              `yaml:"target" doc:"required"`
+	Limit   int                 `yaml:"limit" category:"advanced" doc:"warning=Raising it may exhaust the <memory>.|min=1|max=99|unit=series"`
+	Targets fixtureTargets      `yaml:"targets"`
+	Server  fixtureServerConfig `yaml:"server"`
+	Timeout time.Duration       `yaml:"timeout"`
+	Prefix  *string             `yaml:"prefix"`
+	Rules   []fixtureRuleConfig `yaml:"rules"`
+	Nested  struct {
+		Enabled bool `yaml:"enabled" category:"experimental"`
+	} `yaml:"nested" doc:"description=Options of the <nested> block."`
+}
+
+func TestWrite(t *testing.T) {
+	cfg := &fixtureConfig{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.StringVar(&cfg.Target, "target", "all", "Components to run, like a & b.")
+	fs.IntVar(&cfg.Limit, "limit", 10, "Limit, must be < 100.")
+	fs.IntVar(&cfg.Server.Port, "server.port", 8080, "The port.")
+	fs.DurationVar(&cfg.Timeout, "timeout", time.Minute, "The timeout.")
+	fs.BoolVar(&cfg.Nested.Enabled, "nested.enabled", false, "Whether it's enabled.")
+
+	flags := map[uintptr][]*flag.Flag{}
+	fs.VisitAll(func(f *flag.Flag) {
+		ptr := reflect.ValueOf(f.Value).Pointer()
+		flags[ptr] = append(flags[ptr], f)
+	})
+
+	rootBlocks := []parse.RootBlock{{
+		Name:       "server",
+		Desc:       "The server block configures the server.",
+		StructType: reflect.TypeOf(fixtureServerConfig{}),
+	}}
+	blocks, err := parse.Config(cfg, flags, rootBlocks)
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, Write(out, blocks))
+
+	expected, err := os.ReadFile("testdata/fixture.html")
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), out.String())
+}
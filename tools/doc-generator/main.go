@@ -8,20 +8,69 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"text/template"
 
 	"github.com/grafana/mimir/pkg/mimir"
+	"github.com/grafana/mimir/pkg/util"
 	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/tools/doc-generator/completion"
+	"github.com/grafana/mimir/tools/doc-generator/html"
 	"github.com/grafana/mimir/tools/doc-generator/parse"
 )
 
-const (
-	maxLineWidth = 80
-	tabWidth     = 2
-)
+// cliFlagsAllowlist contains the paths of the advanced and experimental fields (or blocks)
+// which are allowed to have no CLI flag without being tagged as nocli.
+var cliFlagsAllowlist = []string{
+	// Deprecated in favour of the per-tenant limit with the same name.
+	"ingester.active_series_custom_trackers",
+}
+
+// snakeCaseAllowlist contains the paths of the entries, or the names of the root blocks, which
+// are allowed not to be snake_case because they can't be renamed without breaking the config.
+var snakeCaseAllowlist []string
+
+// hiddenFieldsAllowlist contains the locations of the hidden fields, like
+// "github.com/grafana/mimir/pkg/mimir.Config.Ingester", which are allowed
+// to have a category, a description or examples.
+var hiddenFieldsAllowlist = []string{
+	// Deprecated fields, hidden until they're removed.
+	"github.com/grafana/mimir/pkg/distributor.Config.ExtendWrites",
+	"github.com/grafana/mimir/pkg/ingester.RingConfig.DeprecatedJoinAfter",
+	// Deprecated in favour of the field with the same type and the new name.
+	"github.com/grafana/mimir/pkg/util/validation.Limits.ActiveSeriesCustomTrackersConfigOld",
+}
+
+// flagDefaultsAllowlist contains the CLI flags whose default can't be checked by -check-flag-defaults,
+// because their value holds a function or an interface, which are allowed not to be reported as skipped.
+var flagDefaultsAllowlist = []string{
+	// The value holds the parsed matchers, which are interfaces.
+	"ingester.active-series-custom-trackers",
+	// The values hold the logrus formatter and the go-kit level option.
+	"log.format",
+	"log.level",
+}
+
+// descriptionReferencesAllowlist contains the CLI flags, like "-config.file", or the YAML fields,
+// like "tenant_federation.enabled", which are allowed to be referenced by the field descriptions
+// without being documented, like the flags of other tools.
+var descriptionReferencesAllowlist []string
+
+// flagNameRules map the YAML paths of the blocks to the prefix of their CLI flags,
+// where they don't follow the naming convention checked by -lint-flag-names.
+var flagNameRules = []parse.FlagNameRule{
+	// The KV store of the rings is registered with the prefix of the ring.
+	{PathPrefix: "alertmanager.sharding_ring.kvstore", FlagPrefix: "alertmanager.sharding-ring"},
+	{PathPrefix: "compactor.sharding_ring.kvstore", FlagPrefix: "compactor.ring"},
+	{PathPrefix: "distributor.ha_tracker.kvstore", FlagPrefix: "distributor.ha-tracker"},
+	{PathPrefix: "distributor.ring.kvstore", FlagPrefix: "distributor.ring"},
+	{PathPrefix: "ingester.ring.kvstore", FlagPrefix: "ingester.ring"},
+	{PathPrefix: "ruler.ring.kvstore", FlagPrefix: "ruler.ring"},
+	{PathPrefix: "store_gateway.sharding_ring.kvstore", FlagPrefix: "store-gateway.sharding-ring"},
+}
 
 func removeFlagPrefix(block *parse.ConfigBlock, prefix string) {
 	for _, entry := range block.Entries {
@@ -90,24 +139,25 @@ func annotateFlagPrefix(blocks []*parse.ConfigBlock) {
 	}
 }
 
-func generateBlocksMarkdown(blocks []*parse.ConfigBlock) string {
-	md := &markdownWriter{}
-	md.writeConfigDoc(blocks)
-	return md.string()
+func generateBlocksMarkdown(blocks []*parse.ConfigBlock, goTypes bool) (string, error) {
+	out := &strings.Builder{}
+	if err := parse.RenderWithOptions(blocks, parse.DefaultTemplate(), out, parse.RenderOptions{GoTypes: goTypes}); err != nil {
+		return "", err
+	}
+	return out.String(), nil
 }
 
-func generateBlockMarkdown(blocks []*parse.ConfigBlock, blockName, fieldName string) string {
+func generateBlockMarkdown(blocks []*parse.ConfigBlock, blockName, fieldName string, goTypes bool) (string, error) {
 	// Look for the requested block.
 	for _, block := range blocks {
 		if block.Name != blockName {
 			continue
 		}
 
-		md := &markdownWriter{}
-
 		// Wrap the root block with another block, so that we can show the name of the
 		// root field containing the block specs.
-		md.writeConfigBlock(&parse.ConfigBlock{
+		out := &strings.Builder{}
+		err := parse.RenderBlock(&parse.ConfigBlock{
 			Name: blockName,
 			Desc: block.Desc,
 			Entries: []*parse.ConfigEntry{
@@ -120,43 +170,289 @@ func generateBlockMarkdown(blocks []*parse.ConfigBlock, blockName, fieldName str
 					Root:      false,
 				},
 			},
-		})
-
-		return md.string()
+		}, parse.DefaultTemplate(), out, parse.RenderOptions{GoTypes: goTypes})
+		if err != nil {
+			return "", err
+		}
+		return out.String(), nil
 	}
 
 	// If the block has not been found, we return an empty string.
-	return ""
+	return "", nil
 }
 
 func main() {
 	// Parse the generator flags.
+	jsonOutput := flag.Bool("json", false, "Output the reference configuration as JSON instead of executing a template.")
+	htmlOutput := flag.Bool("html", false, "Output the reference configuration as a standalone HTML page instead of executing a template.")
+	flagMappingOutput := flag.Bool("flag-mapping", false, "Output the mapping between CLI flags and YAML paths as JSON instead of executing a template.")
+	completionOutput := flag.String("completion", "", "Output the completion script of the CLI flags for the given shell, either bash or zsh, instead of executing a template.")
+	snippetOutput := flag.String("snippet", "", "Output a YAML snippet setting the field at the given dot-separated YAML path, like blocks_storage.tsdb.dir, instead of executing a template.")
+	goTypes := flag.Bool("go-types", false, "Include the Go type of the fields in the reference configuration generated from the template.")
+	validateCLIFlags := flag.Bool("validate-cli-flags", true, "Fail if an advanced or experimental field has no CLI flag and isn't tagged as nocli.")
+	lintZeroDurations := flag.Bool("lint-zero-durations", false, "Warn about the duration fields defaulting to 0 which don't document what 0 means, through the zero doc tag.")
+	lintFlagNames := flag.Bool("lint-flag-names", false, "Warn about the fields whose CLI flag doesn't follow their YAML path, like -blocks-storage.tsdb.dir for blocks_storage.tsdb.dir.")
+	flagNamesExceptions := flag.String("flag-names-exceptions", "", "File listing the paths or CLI flags of the fields, one per line, which are allowed not to follow the naming convention checked by -lint-flag-names.")
+	lintFlagUsages := flag.Bool("lint-flag-usages", false, "Warn about the fields whose CLI flag usage is longer than -flag-usage-max-length or contains a markdown link or an HTML tag, which look wrong in the -help output.")
+	flagUsageMaxLength := flag.Int("flag-usage-max-length", 400, "Maximum length of the CLI flag usages checked by -lint-flag-usages.")
+	checkFlagDefaults := flag.Bool("check-flag-defaults", true, "Fail if the default of a CLI flag, as shown by -help, can't be set back through the flag.")
+	categories := flag.String("categories", "", "Only document the fields of the given comma-separated categories, like basic or basic,advanced. Fields without a category are basic. All the fields are documented by default.")
 	flag.Parse()
-	if flag.NArg() != 1 {
-		fmt.Fprintf(os.Stderr, "Usage: doc-generator template-file")
+
+	outputs := 0
+	for _, output := range []bool{*jsonOutput, *htmlOutput, *flagMappingOutput, *completionOutput != "", *snippetOutput != ""} {
+		if output {
+			outputs++
+		}
+	}
+	if outputs > 1 || (outputs == 1 && flag.NArg() != 0) || (outputs == 0 && flag.NArg() != 1) {
+		fmt.Fprintf(os.Stderr, "Usage: doc-generator [-json | -html | -flag-mapping | -completion=bash|zsh | -snippet=path | template-file]")
 		os.Exit(1)
 	}
 
-	templatePath := flag.Arg(0)
-
-	// In order to match YAML config fields with CLI flags, we map
-	// the memory address of the CLI flag variables and match them with
-	// the config struct fields' addresses.
-	cfg := &mimir.Config{}
-	flags := parse.Flags(cfg, util_log.Logger)
-
 	// Parse the config, mapping each config field with the related CLI flag.
-	blocks, err := parse.Config(cfg, flags, parse.RootBlocks)
+	// Doc tags are parsed strictly, to catch typos in their keys.
+	opts := parse.Options{StrictDocTags: true}
+	res, err := parse.Generate(&mimir.Config{}, util_log.Logger, opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "An error occurred while generating the doc: %s\n", err.Error())
 		os.Exit(1)
 	}
+	cfg, flags, blocks := res.Config, res.Flags, res.Blocks
+
+	// Registered block descriptions must match a block, otherwise they're stale.
+	if unmatched := parse.UnmatchedBlockDescriptions(); len(unmatched) > 0 {
+		fmt.Fprintf(os.Stderr, "Block descriptions registered for unknown blocks: %s\n", strings.Join(unmatched, ", "))
+		os.Exit(1)
+	}
+
+	// Fields registered as reloadable must match a field, otherwise they're stale.
+	if unmatched := parse.UnmatchedReloadablePaths(); len(unmatched) > 0 {
+		fmt.Fprintf(os.Stderr, "Reloadable fields registered for unknown paths: %s\n", strings.Join(unmatched, ", "))
+		os.Exit(1)
+	}
+
+	// Fields can't be both reloadable and only applied at startup.
+	if errs := parse.ValidateStartupOnly(blocks); len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		}
+		os.Exit(1)
+	}
+
+	// Hidden fields aren't documented, so they shouldn't carry any documentation.
+	if errs := parse.ValidateHiddenFields(cfg, hiddenFieldsAllowlist); len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		}
+		os.Exit(1)
+	}
+
+	// Required blocks must have a required field or a field with a default, otherwise they're mis-tagged.
+	if errs := parse.ValidateRequiredBlocks(blocks); len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		}
+		os.Exit(1)
+	}
+
+	// CLI flags and YAML fields referenced by the field descriptions must exist, otherwise they're stale.
+	if errs := parse.ValidateDescriptionReferences(blocks, flags, descriptionReferencesAllowlist); len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		}
+		os.Exit(1)
+	}
+
+	// Conflicting fields must exist.
+	if errs := parse.ValidateConflicts(blocks); len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		}
+		os.Exit(1)
+	}
+
+	// Advanced and experimental fields must be settable through a CLI flag.
+	if *validateCLIFlags {
+		if errs := parse.ValidateCLIFlags(blocks, cliFlagsAllowlist); len(errs) > 0 {
+			for _, err := range errs {
+				fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+			}
+			os.Exit(1)
+		}
+	}
+
+	// Defaults shown by -help must be valid values of their own flag.
+	if *checkFlagDefaults {
+		errs, skipped := parse.CheckFlagDefaults(flags)
+		for _, name := range skipped {
+			if util.StringsContain(flagDefaultsAllowlist, name) {
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Warning: the default of the CLI flag -%s can't be checked\n", name)
+		}
+		if len(errs) > 0 {
+			for _, err := range errs {
+				fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+			}
+			os.Exit(1)
+		}
+	}
+
+	// YAML keys must be snake_case.
+	if errs := parse.ValidateSnakeCaseNames(blocks, snakeCaseAllowlist); len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		}
+		os.Exit(1)
+	}
+
+	// Issues which don't prevent documenting the config are reported, like the fields set by
+	// the same CLI flag without being tagged as intended aliases, or malformed field warnings.
+	for _, err := range res.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", err.Error())
+	}
+
+	// Duration fields defaulting to 0 should document what 0 means.
+	if *lintZeroDurations {
+		for _, err := range parse.ValidateZeroMeanings(blocks) {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", err.Error())
+		}
+	}
+
+	// CLI flags should follow the YAML paths. They're checked before annotating
+	// the flags prefix, because they need the actual flag names.
+	if *lintFlagNames {
+		var exceptions []string
+		if *flagNamesExceptions != "" {
+			f, err := os.Open(*flagNamesExceptions)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "An error occurred while opening the flag name exceptions: %s\n", err.Error())
+				os.Exit(1)
+			}
+			defer f.Close()
+
+			exceptions, err = parse.LoadFlagNameExceptions(f)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+				os.Exit(1)
+			}
+		}
+
+		mismatches, err := parse.CheckFlagNames(blocks, flagNameRules, exceptions)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+			os.Exit(1)
+		}
+		for _, m := range mismatches {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", m)
+		}
+	}
+
+	// CLI flag usages are shown in the terminal, so they should be short plain text.
+	// Long descriptions belong to the description doc tag.
+	if *lintFlagUsages {
+		for _, err := range parse.ValidateFlagUsages(blocks, flags, *flagUsageMaxLength) {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", err.Error())
+		}
+	}
+
+	// The completion scripts are generated before annotating the flags prefix,
+	// because they need the actual flag names.
+	if *completionOutput != "" {
+		write := map[string]func(io.Writer, string, map[uintptr][]*flag.Flag, []*parse.ConfigBlock) error{
+			"bash": completion.WriteBash,
+			"zsh":  completion.WriteZsh,
+		}[*completionOutput]
+		if write == nil {
+			fmt.Fprintf(os.Stderr, "Unsupported shell for the completion script: %s\n", *completionOutput)
+			os.Exit(1)
+		}
+
+		if err := write(os.Stdout, "mimir", flags, blocks); err != nil {
+			fmt.Fprintf(os.Stderr, "An error occurred while generating the completion script: %s\n", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
 
 	// Annotate the flags prefix for each root block, and remove the
 	// prefix wherever encountered in the config blocks.
 	annotateFlagPrefix(blocks)
 
+	// Keep only the fields of the requested categories, like for the basic guide.
+	if *categories != "" {
+		requested := strings.Split(*categories, ",")
+		if err := validateCategories(requested, parse.Categories(opts.ExtraCategories)); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid -categories flag: %s\n", err.Error())
+			os.Exit(1)
+		}
+		blocks = parse.SplitByCategory(blocks, requested...)
+	}
+
+	if *jsonOutput {
+		data, err := parse.MarshalJSON(blocks)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "An error occurred while generating the JSON: %s\n", err.Error())
+			os.Exit(1)
+		}
+
+		if _, err := os.Stdout.Write(data); err != nil {
+			fmt.Fprintf(os.Stderr, "An error occurred while writing the JSON: %s\n", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *flagMappingOutput {
+		mapping, err := parse.FlagMapping(blocks)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "An error occurred while generating the flag mapping: %s\n", err.Error())
+			os.Exit(1)
+		}
+
+		data, err := parse.MarshalFlagMappingJSON(mapping)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "An error occurred while generating the flag mapping: %s\n", err.Error())
+			os.Exit(1)
+		}
+
+		if _, err := os.Stdout.Write(data); err != nil {
+			fmt.Fprintf(os.Stderr, "An error occurred while writing the flag mapping: %s\n", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *snippetOutput != "" {
+		snippet, err := parse.SnippetFor(blocks, *snippetOutput)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "An error occurred while generating the snippet: %s\n", err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Fprint(os.Stdout, snippet)
+		return
+	}
+
+	if *htmlOutput {
+		if err := html.Write(os.Stdout, blocks); err != nil {
+			fmt.Fprintf(os.Stderr, "An error occurred while generating the HTML: %s\n", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	templatePath := flag.Arg(0)
+
 	// Generate documentation markdown.
+	markdown := func(md string, err error) string {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "An error occurred while generating the doc: %s\n", err.Error())
+			os.Exit(1)
+		}
+		return md
+	}
 	data := struct {
 		ConfigFile               string
 		BlocksStorageConfigBlock string
@@ -166,12 +462,12 @@ func main() {
 		S3SSEConfigBlock         string
 		GeneratedFileWarning     string
 	}{
-		ConfigFile:               generateBlocksMarkdown(blocks),
-		BlocksStorageConfigBlock: generateBlockMarkdown(blocks, "blocks_storage_config", "blocks_storage"),
-		StoreGatewayConfigBlock:  generateBlockMarkdown(blocks, "store_gateway_config", "store_gateway"),
-		CompactorConfigBlock:     generateBlockMarkdown(blocks, "compactor_config", "compactor"),
-		QuerierConfigBlock:       generateBlockMarkdown(blocks, "querier_config", "querier"),
-		S3SSEConfigBlock:         generateBlockMarkdown(blocks, "s3_sse_config", "sse"),
+		ConfigFile:               markdown(generateBlocksMarkdown(blocks, *goTypes)),
+		BlocksStorageConfigBlock: markdown(generateBlockMarkdown(blocks, "blocks_storage_config", "blocks_storage", *goTypes)),
+		StoreGatewayConfigBlock:  markdown(generateBlockMarkdown(blocks, "store_gateway_config", "store_gateway", *goTypes)),
+		CompactorConfigBlock:     markdown(generateBlockMarkdown(blocks, "compactor_config", "compactor", *goTypes)),
+		QuerierConfigBlock:       markdown(generateBlockMarkdown(blocks, "querier_config", "querier", *goTypes)),
+		S3SSEConfigBlock:         markdown(generateBlockMarkdown(blocks, "s3_sse_config", "sse", *goTypes)),
 		GeneratedFileWarning:     "<!-- DO NOT EDIT THIS FILE - This file has been automatically generated from its .template -->",
 	}
 
@@ -189,3 +485,14 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// validateCategories returns an error if any of the requested categories isn't valid,
+// since a typo would otherwise silently document no field of that category.
+func validateCategories(requested, valid []string) error {
+	for _, c := range requested {
+		if !util.StringsContain(valid, c) {
+			return fmt.Errorf("unknown category %q, valid categories are: %s", c, strings.Join(valid, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package main
+
+import (
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimir"
+	"github.com/grafana/mimir/tools/doc-generator/parse"
+)
+
+func TestGenerateBlocksMarkdown_JSONRoundTrip(t *testing.T) {
+	cfg := &mimir.Config{}
+	blocks, err := parse.Config(cfg, parse.Flags(cfg, log.NewNopLogger()), parse.RootBlocks)
+	require.NoError(t, err)
+	annotateFlagPrefix(blocks)
+
+	data, err := parse.MarshalJSON(blocks)
+	require.NoError(t, err)
+	decoded, err := parse.UnmarshalJSON(data)
+	require.NoError(t, err)
+
+	expected, err := generateBlocksMarkdown(blocks, false)
+	require.NoError(t, err)
+	actual, err := generateBlocksMarkdown(decoded, false)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestGenerateBlockMarkdown(t *testing.T) {
+	blocks := []*parse.ConfigBlock{{}, {
+		Name: "sse_config",
+		Desc: "The sse_config configures the S3 server-side encryption.",
+		Entries: []*parse.ConfigEntry{
+			{Kind: parse.KindField, Name: "type", FieldType: "string", FieldFlag: "s3.sse.type", GoType: "string"},
+		},
+	}}
+
+	md, err := generateBlockMarkdown(blocks, "sse_config", "sse", true)
+	require.NoError(t, err)
+	assert.Equal(t, "### sse_config\n"+
+		"\n"+
+		"The `sse_config` configures the S3 server-side encryption.\n"+
+		"\n"+
+		"```yaml\n"+
+		"sse:\n"+
+		"  # CLI flag: -s3.sse.type\n"+
+		"  # Go type: string\n"+
+		"  [type: <string> | default = \"\"]\n"+
+		"```", md)
+
+	md, err = generateBlockMarkdown(blocks, "unknown", "unknown", false)
+	require.NoError(t, err)
+	assert.Empty(t, md)
+}
+
+func TestValidateCategories(t *testing.T) {
+	valid := parse.Categories([]string{"enterprise"})
+
+	assert.NoError(t, validateCategories([]string{"basic"}, valid))
+	assert.NoError(t, validateCategories([]string{"basic", "advanced", "enterprise"}, valid))
+	assert.EqualError(t, validateCategories([]string{"basic", "advnced"}, valid), `unknown category "advnced", valid categories are: basic, advanced, experimental, enterprise`)
+}
@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+// Severity of a change between two versions of the config.
+type Severity string
+
+const (
+	// SeverityBreaking changes make a valid config fail to parse, or silently change its meaning.
+	SeverityBreaking Severity = "breaking"
+	// SeveritySoft changes keep working for now, but require a migration before the next major release.
+	SeveritySoft Severity = "soft"
+	// SeverityNonBreaking changes don't require any action.
+	SeverityNonBreaking Severity = "non-breaking"
+)
+
+// ChangeKind is the kind of change of a config entry.
+type ChangeKind string
+
+const (
+	ChangeTypeChanged     ChangeKind = "type changed"
+	ChangeRemoved         ChangeKind = "removed"
+	ChangeDefaultChanged  ChangeKind = "default changed"
+	ChangeCategoryChanged ChangeKind = "category changed"
+	ChangeFlagRenamed     ChangeKind = "flag renamed"
+	ChangeFlagAliased     ChangeKind = "flag renamed with alias"
+)
+
+// changeSeverities classifies each kind of change.
+var changeSeverities = map[ChangeKind]Severity{
+	ChangeTypeChanged:     SeverityBreaking,
+	ChangeRemoved:         SeverityBreaking,
+	ChangeFlagRenamed:     SeverityBreaking,
+	ChangeFlagAliased:     SeveritySoft,
+	ChangeDefaultChanged:  SeverityNonBreaking,
+	ChangeCategoryChanged: SeverityNonBreaking,
+}
+
+// Old CLI flag names still accepted as an alias of the new ones, registered through RegisterFlagAlias.
+var flagAliases = map[string]string{}
+
+// RegisterFlagAlias registers the old name of a renamed CLI flag, still accepted as an
+// alias of the new name, so that BreakingChanges classifies the rename as soft.
+func RegisterFlagAlias(oldName, newName string) {
+	flagAliases[oldName] = newName
+}
+
+// Breaking is a change of a config entry between two versions of the config.
+type Breaking struct {
+	// Dot-separated YAML path of the entry, from the top-level block.
+	Path     string
+	Kind     ChangeKind
+	Severity Severity
+
+	// The old and new value of the changed property, empty if not applicable.
+	Old string
+	New string
+}
+
+// BreakingChanges returns the changes of the fields between the old and the new blocks, as returned
+// by Config, sorted by path. Fields added in the new blocks aren't reported.
+func BreakingChanges(oldBlocks, newBlocks []*ConfigBlock) []Breaking {
+	oldEntries := indexEntriesByPath(oldBlocks)
+	newEntries := indexEntriesByPath(newBlocks)
+
+	var changes []Breaking
+	add := func(path string, kind ChangeKind, oldValue, newValue string) {
+		changes = append(changes, Breaking{Path: path, Kind: kind, Severity: changeSeverities[kind], Old: oldValue, New: newValue})
+	}
+
+	for _, path := range sortedEntryPaths(oldEntries) {
+		oldEntry := oldEntries[path]
+		newEntry, ok := newEntries[path]
+		if !ok {
+			// Removed blocks are reported through their fields.
+			if oldEntry.Kind != KindBlock {
+				add(path, ChangeRemoved, "", "")
+			}
+			continue
+		}
+
+		if oldType, newType := entryType(oldEntry), entryType(newEntry); oldType != newType {
+			add(path, ChangeTypeChanged, oldType, newType)
+			continue
+		}
+		if oldEntry.Kind == KindBlock {
+			continue
+		}
+
+		if oldEntry.FieldFlag != newEntry.FieldFlag && oldEntry.FieldFlag != "" {
+			if flagAliases[oldEntry.FieldFlag] == newEntry.FieldFlag {
+				add(path, ChangeFlagAliased, oldEntry.FieldFlag, newEntry.FieldFlag)
+			} else {
+				add(path, ChangeFlagRenamed, oldEntry.FieldFlag, newEntry.FieldFlag)
+			}
+		}
+		if oldDefault, newDefault := oldEntry.RawDefault(), newEntry.RawDefault(); oldDefault != newDefault {
+			add(path, ChangeDefaultChanged, oldDefault, newDefault)
+		}
+		if oldCategory, newCategory := entryCategory(oldEntry), entryCategory(newEntry); oldCategory != newCategory {
+			add(path, ChangeCategoryChanged, oldCategory, newCategory)
+		}
+	}
+
+	return changes
+}
+
+// indexEntriesByPath returns the entries reachable from the top-level block, which is
+// the first one, by YAML path. Root blocks are walked at each path they're referenced at.
+func indexEntriesByPath(blocks []*ConfigBlock) map[string]*ConfigEntry {
+	entries := map[string]*ConfigEntry{}
+	if len(blocks) > 0 {
+		indexBlockEntries(blocks[0], "", entries)
+	}
+	return entries
+}
+
+func indexBlockEntries(block *ConfigBlock, path string, entries map[string]*ConfigEntry) {
+	for _, entry := range block.Entries {
+		entryPath := joinPath(path, entry.Name)
+		entries[entryPath] = entry
+
+		if entry.Kind == KindBlock {
+			indexBlockEntries(entry.Block, entryPath, entries)
+		}
+		if entry.Element != nil {
+			indexBlockEntries(entry.Element, entryPath+"[]", entries)
+		}
+	}
+}
+
+func entryType(e *ConfigEntry) string {
+	if e.Kind == KindBlock {
+		return "block"
+	}
+	return e.FieldType
+}
+
+func entryCategory(e *ConfigEntry) string {
+	if e.FieldCategory == "" {
+		return "basic"
+	}
+	return e.FieldCategory
+}
@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func breakingTestBlocks(fields ...*ConfigEntry) []*ConfigBlock {
+	return []*ConfigBlock{{
+		Entries: []*ConfigEntry{{
+			Kind:  KindBlock,
+			Name:  "limits",
+			Block: &ConfigBlock{Name: "limits", Entries: fields},
+		}},
+	}}
+}
+
+func TestBreakingChanges(t *testing.T) {
+	RegisterFlagAlias("limits.old-name", "limits.new-name")
+	t.Cleanup(func() { delete(flagAliases, "limits.old-name") })
+
+	field := func(name, fieldType, fieldDefault, flagName, category string) *ConfigEntry {
+		return &ConfigEntry{Kind: KindField, Name: name, FieldType: fieldType, FieldDefault: fieldDefault, FieldFlag: flagName, FieldCategory: category}
+	}
+
+	tests := map[string]struct {
+		old, new []*ConfigBlock
+		expected []Breaking
+	}{
+		"no changes": {
+			old: breakingTestBlocks(field("max", "int", "10", "limits.max", "")),
+			new: breakingTestBlocks(field("max", "int", "10", "limits.max", "basic")),
+		},
+		"go type changed with the same documented type": {
+			old: breakingTestBlocks(&ConfigEntry{Kind: KindField, Name: "max", FieldType: "int", GoType: "int"}),
+			new: breakingTestBlocks(&ConfigEntry{Kind: KindField, Name: "max", FieldType: "int", GoType: "int64"}),
+		},
+		"added field": {
+			old: breakingTestBlocks(),
+			new: breakingTestBlocks(field("max", "int", "10", "limits.max", "")),
+		},
+		"type changed": {
+			old:      breakingTestBlocks(field("labels", "string", "", "limits.labels", "")),
+			new:      breakingTestBlocks(field("labels", "list of string", "", "limits.labels", "")),
+			expected: []Breaking{{Path: "limits.labels", Kind: ChangeTypeChanged, Severity: SeverityBreaking, Old: "string", New: "list of string"}},
+		},
+		"field changed to block": {
+			old: breakingTestBlocks(field("ring", "string", "", "", "")),
+			new: breakingTestBlocks(&ConfigEntry{Kind: KindBlock, Name: "ring", Block: &ConfigBlock{}}),
+			expected: []Breaking{
+				{Path: "limits.ring", Kind: ChangeTypeChanged, Severity: SeverityBreaking, Old: "string", New: "block"},
+			},
+		},
+		"removed field": {
+			old:      breakingTestBlocks(field("max", "int", "10", "limits.max", "")),
+			new:      breakingTestBlocks(),
+			expected: []Breaking{{Path: "limits.max", Kind: ChangeRemoved, Severity: SeverityBreaking}},
+		},
+		"removed block": {
+			old: breakingTestBlocks(field("max", "int", "10", "limits.max", "")),
+			new: []*ConfigBlock{{}},
+			expected: []Breaking{
+				{Path: "limits.max", Kind: ChangeRemoved, Severity: SeverityBreaking},
+			},
+		},
+		"default changed": {
+			old:      breakingTestBlocks(field("timeout", "duration", "1m", "limits.timeout", "")),
+			new:      breakingTestBlocks(field("timeout", "duration", "2m", "limits.timeout", "")),
+			expected: []Breaking{{Path: "limits.timeout", Kind: ChangeDefaultChanged, Severity: SeverityNonBreaking, Old: "1m", New: "2m"}},
+		},
+		"raw default changed": {
+			old:      breakingTestBlocks(&ConfigEntry{Kind: KindField, Name: "max_bytes", FieldType: "int", FieldDefault: "1GiB", FieldRawDefault: "1073741824"}),
+			new:      breakingTestBlocks(&ConfigEntry{Kind: KindField, Name: "max_bytes", FieldType: "int", FieldDefault: "2GiB", FieldRawDefault: "2147483648"}),
+			expected: []Breaking{{Path: "limits.max_bytes", Kind: ChangeDefaultChanged, Severity: SeverityNonBreaking, Old: "1073741824", New: "2147483648"}},
+		},
+		"default formatted differently": {
+			old: breakingTestBlocks(&ConfigEntry{Kind: KindField, Name: "max_bytes", FieldType: "int", FieldDefault: "1073741824"}),
+			new: breakingTestBlocks(&ConfigEntry{Kind: KindField, Name: "max_bytes", FieldType: "int", FieldDefault: "1GiB", FieldRawDefault: "1073741824"}),
+		},
+		"category changed": {
+			old:      breakingTestBlocks(field("max", "int", "10", "limits.max", "experimental")),
+			new:      breakingTestBlocks(field("max", "int", "10", "limits.max", "")),
+			expected: []Breaking{{Path: "limits.max", Kind: ChangeCategoryChanged, Severity: SeverityNonBreaking, Old: "experimental", New: "basic"}},
+		},
+		"flag renamed with alias": {
+			old:      breakingTestBlocks(field("name", "string", "", "limits.old-name", "")),
+			new:      breakingTestBlocks(field("name", "string", "", "limits.new-name", "")),
+			expected: []Breaking{{Path: "limits.name", Kind: ChangeFlagAliased, Severity: SeveritySoft, Old: "limits.old-name", New: "limits.new-name"}},
+		},
+		"flag renamed without alias": {
+			old:      breakingTestBlocks(field("max", "int", "10", "limits.max", "")),
+			new:      breakingTestBlocks(field("max", "int", "10", "limits.maximum", "")),
+			expected: []Breaking{{Path: "limits.max", Kind: ChangeFlagRenamed, Severity: SeverityBreaking, Old: "limits.max", New: "limits.maximum"}},
+		},
+		"multiple changes sorted by path": {
+			old: breakingTestBlocks(
+				field("b", "int", "1", "limits.b", ""),
+				field("a", "int", "1", "limits.a", ""),
+			),
+			new: breakingTestBlocks(
+				field("a", "int", "2", "limits.a", "advanced"),
+			),
+			expected: []Breaking{
+				{Path: "limits.a", Kind: ChangeDefaultChanged, Severity: SeverityNonBreaking, Old: "1", New: "2"},
+				{Path: "limits.a", Kind: ChangeCategoryChanged, Severity: SeverityNonBreaking, Old: "basic", New: "advanced"},
+				{Path: "limits.b", Kind: ChangeRemoved, Severity: SeverityBreaking},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, BreakingChanges(test.old, test.new))
+		})
+	}
+}
+
+func TestChangeSeverities(t *testing.T) {
+	// Every kind of change must be classified.
+	for _, kind := range []ChangeKind{ChangeTypeChanged, ChangeRemoved, ChangeDefaultChanged, ChangeCategoryChanged, ChangeFlagRenamed, ChangeFlagAliased} {
+		_, ok := changeSeverities[kind]
+		assert.True(t, ok, kind)
+	}
+}
@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// conflictIndex resolves the references of the conflicts_with doc tag to the fields.
+type conflictIndex struct {
+	// Fields by reference: dotted YAML path from their root block (or from the top-level
+	// block), dotted YAML path from the top-level block and CLI flag prefixed by "-".
+	byRef map[string][]*ConfigEntry
+
+	// Dotted YAML path of each field from its root block (or from the top-level block).
+	paths map[*ConfigEntry]string
+
+	// Root block (or top-level block) of each field.
+	roots map[*ConfigEntry]*ConfigBlock
+
+	// Fields in the order they're walked.
+	entries []*ConfigEntry
+}
+
+func newConflictIndex(blocks []*ConfigBlock) *conflictIndex {
+	idx := &conflictIndex{
+		byRef: map[string][]*ConfigEntry{},
+		paths: map[*ConfigEntry]string{},
+		roots: map[*ConfigEntry]*ConfigBlock{},
+	}
+	if len(blocks) == 0 {
+		return idx
+	}
+
+	// Root blocks are indexed both on their own and through the top-level block, so that
+	// references can be either relative to the root block or from the top-level block.
+	for _, block := range blocks {
+		idx.addBlock(block, block, block.Name, false)
+	}
+	idx.addBlock(blocks[0], blocks[0], "", true)
+	return idx
+}
+
+func (idx *conflictIndex) addBlock(root, block *ConfigBlock, path string, throughRoots bool) {
+	for _, entry := range block.Entries {
+		entryPath := joinPath(path, entry.Name)
+
+		if entry.Kind == KindBlock {
+			if !entry.Root || throughRoots {
+				idx.addBlock(root, entry.Block, entryPath, throughRoots)
+			}
+			continue
+		}
+
+		idx.add(entryPath, entry)
+		if throughRoots {
+			continue
+		}
+
+		idx.paths[entry] = entryPath
+		idx.roots[entry] = root
+		idx.entries = append(idx.entries, entry)
+		for _, name := range append([]string{entry.FieldFlag}, entry.FieldFlagAlternates...) {
+			if name != "" {
+				idx.add("-"+name, entry)
+			}
+		}
+	}
+}
+
+func (idx *conflictIndex) add(ref string, entry *ConfigEntry) {
+	for _, e := range idx.byRef[ref] {
+		if e == entry {
+			return
+		}
+	}
+	idx.byRef[ref] = append(idx.byRef[ref], entry)
+}
+
+// resolve returns the fields referenced by a conflict of entry. Since root blocks may be referenced
+// multiple times, a path from the root block of entry only resolves within the same block.
+func (idx *conflictIndex) resolve(entry *ConfigEntry, ref string) []*ConfigEntry {
+	root := idx.roots[entry]
+	if root == nil || root.Name == "" || !strings.HasPrefix(ref, root.Name+".") {
+		return idx.byRef[ref]
+	}
+
+	var resolved []*ConfigEntry
+	for _, e := range idx.byRef[ref] {
+		if idx.roots[e] == root {
+			resolved = append(resolved, e)
+		}
+	}
+	return resolved
+}
+
+// conflictsWith returns whether any of the conflicts of entry resolves to other.
+func (idx *conflictIndex) conflictsWith(entry, other *ConfigEntry) bool {
+	for _, ref := range entry.FieldConflictsWith {
+		for _, e := range idx.resolve(entry, ref) {
+			if e == other {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// setEntryConflicts makes the conflicts symmetric: each field tagged as conflicting with another
+// one is added to the conflicts of the other one, referenced the same way, either by CLI flag or
+// by YAML path. References which don't resolve are left to ValidateConflicts.
+func setEntryConflicts(blocks []*ConfigBlock) {
+	idx := newConflictIndex(blocks)
+
+	type conflict struct {
+		entry, other *ConfigEntry
+		ref          string
+	}
+
+	// Conflicts are collected before being added, so that only the tagged ones are walked.
+	var conflicts []conflict
+	for _, entry := range idx.entries {
+		for _, ref := range entry.FieldConflictsWith {
+			for _, other := range idx.resolve(entry, ref) {
+				if other != entry {
+					conflicts = append(conflicts, conflict{entry: entry, other: other, ref: ref})
+				}
+			}
+		}
+	}
+
+	for _, c := range conflicts {
+		if idx.conflictsWith(c.other, c.entry) {
+			continue
+		}
+
+		ref := idx.paths[c.entry]
+		if strings.HasPrefix(c.ref, "-") && c.entry.FieldFlag != "" {
+			ref = "-" + c.entry.FieldFlag
+		}
+		c.other.FieldConflictsWith = append(c.other.FieldConflictsWith, ref)
+	}
+}
+
+// ValidateConflicts returns an error for each field tagged as conflicting with a field which
+// doesn't exist, either by YAML path or by CLI flag, or with itself.
+func ValidateConflicts(blocks []*ConfigBlock) []error {
+	idx := newConflictIndex(blocks)
+
+	var errs []error
+	for _, entry := range idx.entries {
+		for _, ref := range entry.FieldConflictsWith {
+			others := idx.resolve(entry, ref)
+			if len(others) == 0 {
+				errs = append(errs, fmt.Errorf("field %s conflicts with the nonexistent field %s", idx.paths[entry], ref))
+			} else if len(others) == 1 && others[0] == entry {
+				errs = append(errs, fmt.Errorf("field %s conflicts with itself", idx.paths[entry]))
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type conflictsClientConfig struct {
+	Address string `yaml:"address"`
+	Local   bool   `yaml:"local" doc:"conflicts_with=client_config.address"`
+}
+
+func TestConfig_ConflictsWith(t *testing.T) {
+	type config struct {
+		Store struct {
+			Dir    string `yaml:"dir" doc:"conflicts_with=store.bucket|conflicts_with=-ingester.client.address"`
+			Bucket string `yaml:"bucket"`
+		} `yaml:"store"`
+		IngesterClient conflictsClientConfig `yaml:"ingester_client"`
+		QuerierClient  conflictsClientConfig `yaml:"querier_client"`
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.StringVar(&cfg.Store.Dir, "store.dir", "", "")
+	fs.StringVar(&cfg.Store.Bucket, "store.bucket", "", "")
+	fs.StringVar(&cfg.IngesterClient.Address, "ingester.client.address", "", "")
+	fs.BoolVar(&cfg.IngesterClient.Local, "ingester.client.local", false, "")
+	fs.StringVar(&cfg.QuerierClient.Address, "querier.client.address", "", "")
+	fs.BoolVar(&cfg.QuerierClient.Local, "querier.client.local", false, "")
+
+	rootBlocks := []RootBlock{
+		{Name: "client_config", Desc: "The client block.", StructType: reflect.TypeOf(conflictsClientConfig{})},
+	}
+
+	blocks, err := Config(cfg, testFlags(fs), rootBlocks)
+	require.NoError(t, err)
+	assert.Empty(t, ValidateConflicts(blocks))
+
+	entries := indexEntriesByPath(blocks)
+
+	// The conflicts are surfaced on both sides, referenced the same way they're tagged.
+	assert.Equal(t, []string{"store.bucket", "-ingester.client.address"}, entries["store.dir"].FieldConflictsWith)
+	assert.Equal(t, []string{"store.dir"}, entries["store.bucket"].FieldConflictsWith)
+	assert.Equal(t, []string{"-store.dir", "client_config.local"}, entries["ingester_client.address"].FieldConflictsWith)
+
+	// References within a root block resolve in each block referencing it, without duplicating the tag.
+	assert.Equal(t, []string{"client_config.address"}, entries["ingester_client.local"].FieldConflictsWith)
+	assert.Equal(t, []string{"client_config.address"}, entries["querier_client.local"].FieldConflictsWith)
+	assert.Equal(t, []string{"client_config.local"}, entries["querier_client.address"].FieldConflictsWith)
+}
+
+func TestConfig_ConflictsWithTaggedOnBothSides(t *testing.T) {
+	cfg := &struct {
+		Local  bool   `yaml:"local" doc:"conflicts_with=remote"`
+		Remote string `yaml:"remote" doc:"conflicts_with=local"`
+	}{}
+
+	blocks, err := Config(cfg, nil, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"remote"}, blocks[0].Entries[0].FieldConflictsWith)
+	assert.Equal(t, []string{"local"}, blocks[0].Entries[1].FieldConflictsWith)
+}
+
+func TestValidateConflicts(t *testing.T) {
+	cfg := &struct {
+		Local  bool   `yaml:"local" doc:"conflicts_with=remote.url|conflicts_with=-remote.enabled"`
+		Remote string `yaml:"remote" doc:"conflicts_with=remote"`
+	}{}
+
+	blocks, err := Config(cfg, nil, nil)
+	require.NoError(t, err)
+
+	errs := ValidateConflicts(blocks)
+	require.Len(t, errs, 3)
+	assert.EqualError(t, errs[0], "field local conflicts with the nonexistent field remote.url")
+	assert.EqualError(t, errs[1], "field local conflicts with the nonexistent field -remote.enabled")
+	assert.EqualError(t, errs[2], "field remote conflicts with itself")
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"sort"
+)
+
+var (
+	// Descriptions of non-root blocks, by YAML path, used when the block has no description in its doc tag.
+	blockDescriptions = map[string]string{}
+
+	// YAML paths of the registered block descriptions which have been matched by Config.
+	matchedBlockDescriptions = map[string]struct{}{}
+)
+
+// RegisterBlockDescription registers the description of the non-root block at yamlPath, which
+// is the dot-separated path of the block from its root block (like "blocks_storage.tsdb"), or
+// from the top-level block if it isn't nested in any root block. The registered description is
+// used only if the block has no description in the doc tag of its field.
+func RegisterBlockDescription(yamlPath, desc string) {
+	blockDescriptions[yamlPath] = desc
+}
+
+// UnmatchedBlockDescriptions returns the sorted YAML paths of the registered block
+// descriptions which haven't matched any block in the configs parsed so far. A
+// description matches a block even if the block has its own description.
+func UnmatchedBlockDescriptions() []string {
+	var unmatched []string
+	for path := range blockDescriptions {
+		if _, ok := matchedBlockDescriptions[path]; !ok {
+			unmatched = append(unmatched, path)
+		}
+	}
+	sort.Strings(unmatched)
+	return unmatched
+}
+
+// setRegisteredBlockDescriptions sets the registered descriptions of the non-root blocks
+// without a description, walking the blocks from the top-level block and the root blocks.
+func setRegisteredBlockDescriptions(blocks []*ConfigBlock, rootBlocks []RootBlock) {
+	roots := make(map[string]struct{}, len(rootBlocks))
+	for _, rootBlock := range rootBlocks {
+		roots[rootBlock.Name] = struct{}{}
+	}
+
+	for _, block := range blocks {
+		if _, ok := roots[block.Name]; ok || block.Name == "" {
+			setRegisteredEntriesDescriptions(block, block.Name, roots)
+		}
+	}
+}
+
+func setRegisteredEntriesDescriptions(block *ConfigBlock, path string, roots map[string]struct{}) {
+	for _, entry := range block.Entries {
+		entryPath := joinPath(path, entry.Name)
+
+		switch {
+		case entry.Kind == KindBlock && !entry.Root:
+			if desc := registeredBlockDescription(entryPath); entry.BlockDesc == "" {
+				entry.BlockDesc = desc
+				entry.Block.Desc = desc
+			}
+			setRegisteredEntriesDescriptions(entry.Block, entryPath, roots)
+
+		case entry.Element != nil:
+			// Elements which are root blocks are walked on their own.
+			if _, ok := roots[entry.Element.Name]; ok {
+				continue
+			}
+			if desc := registeredBlockDescription(entryPath); entry.Element.Desc == "" {
+				entry.Element.Desc = desc
+			}
+			setRegisteredEntriesDescriptions(entry.Element, entryPath, roots)
+		}
+	}
+}
+
+func registeredBlockDescription(path string) string {
+	desc, ok := blockDescriptions[path]
+	if ok {
+		matchedBlockDescriptions[path] = struct{}{}
+	}
+	return desc
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func registerTestBlockDescription(t *testing.T, yamlPath, desc string) {
+	RegisterBlockDescription(yamlPath, desc)
+	t.Cleanup(func() {
+		delete(blockDescriptions, yamlPath)
+		delete(matchedBlockDescriptions, yamlPath)
+	})
+}
+
+func TestRegisterBlockDescription(t *testing.T) {
+	type RootConfig struct {
+		Tagged struct {
+			Enabled bool `yaml:"enabled"`
+		} `yaml:"tagged" doc:"description=The tag description."`
+		Untagged struct {
+			Enabled bool `yaml:"enabled"`
+		} `yaml:"untagged"`
+		Subs []struct {
+			Name string `yaml:"name"`
+		} `yaml:"subs"`
+	}
+
+	cfg := &struct {
+		Root   RootConfig `yaml:"root"`
+		Nested struct {
+			Inner struct {
+				Enabled bool `yaml:"enabled"`
+			} `yaml:"inner"`
+		} `yaml:"nested"`
+	}{}
+	rootBlocks := []RootBlock{{Name: "root_config", Desc: "The root_config block.", StructType: reflect.TypeOf(RootConfig{})}}
+
+	registerTestBlockDescription(t, "root_config.tagged", "The registered description.")
+	registerTestBlockDescription(t, "root_config.untagged", "The registered untagged description.")
+	registerTestBlockDescription(t, "root_config.subs", "The registered subs description.")
+	registerTestBlockDescription(t, "nested.inner", "The registered inner description.")
+	registerTestBlockDescription(t, "root_config.removed", "The registered description of a removed block.")
+
+	blocks, err := Config(cfg, nil, rootBlocks)
+	require.NoError(t, err)
+
+	root := blocks[0].Entries[0].Block
+	require.Equal(t, "root_config", root.Name)
+
+	// The doc tag wins over the registry.
+	assert.Equal(t, "The tag description.", root.Entries[0].BlockDesc)
+	assert.Equal(t, "The tag description.", root.Entries[0].Block.Desc)
+
+	// The registry wins over an empty description.
+	assert.Equal(t, "The registered untagged description.", root.Entries[1].BlockDesc)
+	assert.Equal(t, "The registered untagged description.", root.Entries[1].Block.Desc)
+	assert.Equal(t, "The registered subs description.", root.Entries[2].Element.Desc)
+
+	inner := blocks[0].Entries[1].Block.Entries[0]
+	assert.Equal(t, "The registered inner description.", inner.BlockDesc)
+
+	// The registered description of the tagged block has been matched, even if not used.
+	assert.Equal(t, []string{"root_config.removed"}, UnmatchedBlockDescriptions())
+}
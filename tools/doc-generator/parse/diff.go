@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"sort"
+)
+
+// ConfigDiff is the difference between two versions of the config. Paths are
+// dot-separated YAML paths from the top-level block, and every list is sorted by path.
+// Added and removed blocks are reported through their fields.
+type ConfigDiff struct {
+	Added             []string
+	Removed           []string
+	DefaultsChanged   []FieldChange
+	TypesChanged      []FieldChange
+	CategoriesChanged []FieldChange
+}
+
+// FieldChange is the change of a property of a config entry.
+type FieldChange struct {
+	Path string
+	Old  string
+	New  string
+}
+
+// DefaultChangedTag returns the doc tag recording the change of the default value of the
+// field in the input version, like "default_changed=2.5:5m", to be added to the field.
+func (c FieldChange) DefaultChangedTag(version string) string {
+	return "default_changed=" + version + ":" + c.Old
+}
+
+// Empty returns whether the two versions of the config are equal.
+func (d ConfigDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.DefaultsChanged) == 0 &&
+		len(d.TypesChanged) == 0 && len(d.CategoriesChanged) == 0
+}
+
+// DiffConfig returns the difference between the old and the new blocks, as returned by Config.
+// Unlike BreakingChanges, added fields are reported, and changes aren't classified.
+func DiffConfig(oldBlocks, newBlocks []*ConfigBlock) ConfigDiff {
+	oldEntries := indexEntriesByPath(oldBlocks)
+	newEntries := indexEntriesByPath(newBlocks)
+
+	diff := ConfigDiff{}
+	for _, path := range sortedEntryPaths(newEntries) {
+		if entry := newEntries[path]; oldEntries[path] == nil && entry.Kind != KindBlock {
+			diff.Added = append(diff.Added, path)
+		}
+	}
+
+	for _, path := range sortedEntryPaths(oldEntries) {
+		oldEntry := oldEntries[path]
+		newEntry, ok := newEntries[path]
+		if !ok {
+			if oldEntry.Kind != KindBlock {
+				diff.Removed = append(diff.Removed, path)
+			}
+			continue
+		}
+
+		// Other properties of entries whose type changed aren't comparable.
+		if oldType, newType := entryType(oldEntry), entryType(newEntry); oldType != newType {
+			diff.TypesChanged = append(diff.TypesChanged, FieldChange{Path: path, Old: oldType, New: newType})
+			continue
+		}
+		if oldEntry.Kind == KindBlock {
+			continue
+		}
+
+		// Raw defaults are compared, so that they can be set back, like by the default_changed doc tag.
+		if oldDefault, newDefault := oldEntry.RawDefault(), newEntry.RawDefault(); oldDefault != newDefault {
+			diff.DefaultsChanged = append(diff.DefaultsChanged, FieldChange{Path: path, Old: oldDefault, New: newDefault})
+		}
+		if oldCategory, newCategory := entryCategory(oldEntry), entryCategory(newEntry); oldCategory != newCategory {
+			diff.CategoriesChanged = append(diff.CategoriesChanged, FieldChange{Path: path, Old: oldCategory, New: newCategory})
+		}
+	}
+
+	return diff
+}
+
+func sortedEntryPaths(entries map[string]*ConfigEntry) []string {
+	paths := make([]string, 0, len(entries))
+	for path := range entries {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
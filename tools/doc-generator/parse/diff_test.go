@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffConfig(t *testing.T) {
+	field := func(name, fieldType, fieldDefault, category string) *ConfigEntry {
+		return &ConfigEntry{Kind: KindField, Name: name, FieldType: fieldType, FieldDefault: fieldDefault, FieldCategory: category}
+	}
+	ring := func(fields ...*ConfigEntry) *ConfigEntry {
+		return &ConfigEntry{Kind: KindBlock, Name: "ring", Block: &ConfigBlock{Entries: fields}}
+	}
+
+	tests := map[string]struct {
+		old, new []*ConfigBlock
+		expected ConfigDiff
+	}{
+		"no changes": {
+			old: breakingTestBlocks(field("max", "int", "10", "")),
+			new: breakingTestBlocks(field("max", "int", "10", "basic")),
+		},
+		"added field": {
+			old:      breakingTestBlocks(),
+			new:      breakingTestBlocks(field("max", "int", "10", "")),
+			expected: ConfigDiff{Added: []string{"limits.max"}},
+		},
+		"added block": {
+			old:      breakingTestBlocks(),
+			new:      breakingTestBlocks(ring(field("address", "string", "", ""), field("port", "int", "80", ""))),
+			expected: ConfigDiff{Added: []string{"limits.ring.address", "limits.ring.port"}},
+		},
+		"removed field": {
+			old:      breakingTestBlocks(field("max", "int", "10", "")),
+			new:      breakingTestBlocks(),
+			expected: ConfigDiff{Removed: []string{"limits.max"}},
+		},
+		"removed block": {
+			old:      breakingTestBlocks(ring(field("address", "string", "", ""))),
+			new:      breakingTestBlocks(),
+			expected: ConfigDiff{Removed: []string{"limits.ring.address"}},
+		},
+		"default changed": {
+			old:      breakingTestBlocks(field("max", "int", "10", "")),
+			new:      breakingTestBlocks(field("max", "int", "20", "")),
+			expected: ConfigDiff{DefaultsChanged: []FieldChange{{Path: "limits.max", Old: "10", New: "20"}}},
+		},
+		"type changed": {
+			old:      breakingTestBlocks(field("labels", "string", "", "")),
+			new:      breakingTestBlocks(field("labels", "list of string", "[]", "")),
+			expected: ConfigDiff{TypesChanged: []FieldChange{{Path: "limits.labels", Old: "string", New: "list of string"}}},
+		},
+		"field changed to block": {
+			old: breakingTestBlocks(field("ring", "string", "", "")),
+			new: breakingTestBlocks(ring(field("address", "string", "", ""))),
+			expected: ConfigDiff{
+				Added:        []string{"limits.ring.address"},
+				TypesChanged: []FieldChange{{Path: "limits.ring", Old: "string", New: "block"}},
+			},
+		},
+		"category changed": {
+			old:      breakingTestBlocks(field("max", "int", "10", "experimental")),
+			new:      breakingTestBlocks(field("max", "int", "10", "")),
+			expected: ConfigDiff{CategoriesChanged: []FieldChange{{Path: "limits.max", Old: "experimental", New: "basic"}}},
+		},
+		"multiple changes are sorted by path": {
+			old: breakingTestBlocks(field("b", "int", "1", ""), field("a", "int", "1", ""), field("c", "int", "1", "")),
+			new: breakingTestBlocks(field("c", "int", "2", "advanced"), field("a", "int", "2", ""), field("d", "int", "1", "")),
+			expected: ConfigDiff{
+				Added:             []string{"limits.d"},
+				Removed:           []string{"limits.b"},
+				DefaultsChanged:   []FieldChange{{Path: "limits.a", Old: "1", New: "2"}, {Path: "limits.c", Old: "1", New: "2"}},
+				CategoriesChanged: []FieldChange{{Path: "limits.c", Old: "basic", New: "advanced"}},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual := DiffConfig(test.old, test.new)
+			assert.Equal(t, test.expected, actual)
+			assert.Equal(t, test.expected.Empty(), actual.Empty())
+		})
+	}
+}
+
+func TestFieldChange_DefaultChangedTag(t *testing.T) {
+	diff := DiffConfig(
+		breakingTestBlocks(&ConfigEntry{Kind: KindField, Name: "timeout", FieldType: "duration", FieldDefault: "5m"}),
+		breakingTestBlocks(&ConfigEntry{Kind: KindField, Name: "timeout", FieldType: "duration", FieldDefault: "10m"}),
+	)
+	require.Len(t, diff.DefaultsChanged, 1)
+	assert.Equal(t, "default_changed=2.5:5m", diff.DefaultsChanged[0].DefaultChangedTag("2.5"))
+
+	// The raw defaults are compared and recorded, so that the tag is valid for the field.
+	diff = DiffConfig(
+		breakingTestBlocks(&ConfigEntry{Kind: KindField, Name: "max_bytes", FieldType: "int", FieldDefault: "1GiB", FieldRawDefault: "1073741824"}),
+		breakingTestBlocks(&ConfigEntry{Kind: KindField, Name: "max_bytes", FieldType: "int", FieldDefault: "2GiB", FieldRawDefault: "2147483648"}),
+	)
+	require.Len(t, diff.DefaultsChanged, 1)
+	assert.Equal(t, FieldChange{Path: "limits.max_bytes", Old: "1073741824", New: "2147483648"}, diff.DefaultsChanged[0])
+
+	tag := diff.DefaultsChanged[0].DefaultChangedTag("3.0")
+	assert.Equal(t, "default_changed=3.0:1073741824", tag)
+	field := reflect.StructField{Name: "MaxBytes", Type: reflect.TypeOf(0), Tag: reflect.StructTag(`doc:"unit=bytes|` + tag + `"`)}
+	_, err := getFieldDefaultChanges(field)
+	assert.NoError(t, err)
+}
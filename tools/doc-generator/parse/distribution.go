@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DistributionDiff is the difference between the config of a distribution of Mimir, like
+// a fork, and the upstream one. Paths are dot-separated YAML paths from the top-level block,
+// and every list is sorted by path.
+type DistributionDiff struct {
+	// Fields which only exist in one of the two distributions.
+	ForkOnly     []string `json:"forkOnly,omitempty"`
+	UpstreamOnly []string `json:"upstreamOnly,omitempty"`
+
+	// Fields which exist in both distributions, with different properties. The defaults
+	// and categories of the fields whose type differs aren't compared.
+	TypesDiffer      []DistributionChange `json:"typesDiffer,omitempty"`
+	DefaultsDiffer   []DistributionChange `json:"defaultsDiffer,omitempty"`
+	CategoriesDiffer []DistributionChange `json:"categoriesDiffer,omitempty"`
+}
+
+// DistributionChange is a property of a field which differs between the two distributions.
+type DistributionChange struct {
+	Path     string `json:"path"`
+	Ours     string `json:"ours"`
+	Upstream string `json:"upstream"`
+}
+
+// CompareDistributions returns the difference between our config and the upstream one, both
+// serialized by MarshalJSON, possibly by different versions of the JSON format. Fields are
+// matched by path, so the root blocks can be in a different order in the two documents.
+func CompareDistributions(ours, upstream []byte) (DistributionDiff, error) {
+	ourBlocks, err := unmarshalJSON(ours, true)
+	if err != nil {
+		return DistributionDiff{}, errors.Wrap(err, "can't parse our config")
+	}
+	upstreamBlocks, err := unmarshalJSON(upstream, true)
+	if err != nil {
+		return DistributionDiff{}, errors.Wrap(err, "can't parse the upstream config")
+	}
+
+	diff := DiffConfig(upstreamBlocks, ourBlocks)
+	return DistributionDiff{
+		ForkOnly:         diff.Added,
+		UpstreamOnly:     diff.Removed,
+		TypesDiffer:      distributionChanges(diff.TypesChanged),
+		DefaultsDiffer:   distributionChanges(diff.DefaultsChanged),
+		CategoriesDiffer: distributionChanges(diff.CategoriesChanged),
+	}, nil
+}
+
+// distributionChanges returns the changes from the upstream config to ours as distribution changes.
+func distributionChanges(changes []FieldChange) []DistributionChange {
+	var result []DistributionChange
+	for _, c := range changes {
+		result = append(result, DistributionChange{Path: c.Path, Ours: c.New, Upstream: c.Old})
+	}
+	return result
+}
+
+// Empty returns whether both distributions have the same config.
+func (d DistributionDiff) Empty() bool {
+	return len(d.ForkOnly) == 0 && len(d.UpstreamOnly) == 0 && len(d.TypesDiffer) == 0 &&
+		len(d.DefaultsDiffer) == 0 && len(d.CategoriesDiffer) == 0
+}
+
+// Markdown returns the difference in markdown, while the JSON one is returned by json.Marshal.
+func (d DistributionDiff) Markdown() string {
+	if d.Empty() {
+		return "The config is the same as the upstream one.\n"
+	}
+
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "The config has %d fork-only %s, %d upstream-only, and %d with a different type, default or category.\n",
+		len(d.ForkOnly), pluralize(len(d.ForkOnly), "field", "fields"), len(d.UpstreamOnly),
+		len(d.TypesDiffer)+len(d.DefaultsDiffer)+len(d.CategoriesDiffer))
+
+	writePaths := func(title string, paths []string) {
+		if len(paths) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "\n**%s**\n\n", title)
+		for _, path := range paths {
+			fmt.Fprintf(&sb, "- `%s`\n", path)
+		}
+	}
+	writeChanges := func(title string, changes []DistributionChange) {
+		if len(changes) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "\n**%s**\n\n| Field | Ours | Upstream |\n| --- | --- | --- |\n", title)
+		for _, c := range changes {
+			fmt.Fprintf(&sb, "| `%s` | %s | %s |\n", c.Path, markdownCode(c.Ours), markdownCode(c.Upstream))
+		}
+	}
+	writePaths("Fork only", d.ForkOnly)
+	writePaths("Upstream only", d.UpstreamOnly)
+	writeChanges("Different types", d.TypesDiffer)
+	writeChanges("Different defaults", d.DefaultsDiffer)
+	writeChanges("Different categories", d.CategoriesDiffer)
+
+	return sb.String()
+}
+
+// markdownCode returns s as inline code in a markdown table cell, where pipes must be escaped.
+func markdownCode(s string) string {
+	if s == "" {
+		return "_empty_"
+	}
+	return "`" + strings.ReplaceAll(s, "|", `\|`) + "`"
+}
@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareDistributions(t *testing.T) {
+	// The root blocks of the fixtures are in a different order.
+	ours, err := os.ReadFile("testdata/distribution/ours.json")
+	require.NoError(t, err)
+	upstream, err := os.ReadFile("testdata/distribution/upstream.json")
+	require.NoError(t, err)
+
+	diff, err := CompareDistributions(ours, upstream)
+	require.NoError(t, err)
+	assert.Equal(t, DistributionDiff{
+		ForkOnly:         []string{"store.cache", "tenant"},
+		UpstreamOnly:     []string{"legacy"},
+		TypesDiffer:      []DistributionChange{{Path: "timeout", Ours: "string", Upstream: "int"}},
+		DefaultsDiffer:   []DistributionChange{{Path: "server.port", Ours: "9090", Upstream: "8080"}},
+		CategoriesDiffer: []DistributionChange{{Path: "store.backend", Ours: "experimental", Upstream: "basic"}},
+	}, diff)
+
+	data, err := json.Marshal(diff)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"forkOnly": ["store.cache", "tenant"],
+		"upstreamOnly": ["legacy"],
+		"typesDiffer": [{"path": "timeout", "ours": "string", "upstream": "int"}],
+		"defaultsDiffer": [{"path": "server.port", "ours": "9090", "upstream": "8080"}],
+		"categoriesDiffer": [{"path": "store.backend", "ours": "experimental", "upstream": "basic"}]
+	}`, string(data))
+
+	assert.Equal(t, "The config has 2 fork-only fields, 1 upstream-only, and 3 with a different type, default or category.\n"+
+		"\n**Fork only**\n\n- `store.cache`\n- `tenant`\n"+
+		"\n**Upstream only**\n\n- `legacy`\n"+
+		"\n**Different types**\n\n| Field | Ours | Upstream |\n| --- | --- | --- |\n| `timeout` | `string` | `int` |\n"+
+		"\n**Different defaults**\n\n| Field | Ours | Upstream |\n| --- | --- | --- |\n| `server.port` | `9090` | `8080` |\n"+
+		"\n**Different categories**\n\n| Field | Ours | Upstream |\n| --- | --- | --- |\n| `store.backend` | `experimental` | `basic` |\n",
+		diff.Markdown())
+}
+
+func TestCompareDistributions_Same(t *testing.T) {
+	upstream, err := os.ReadFile("testdata/distribution/upstream.json")
+	require.NoError(t, err)
+
+	diff, err := CompareDistributions(upstream, upstream)
+	require.NoError(t, err)
+	assert.True(t, diff.Empty())
+	assert.Equal(t, "The config is the same as the upstream one.\n", diff.Markdown())
+
+	data, err := json.Marshal(diff)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(data))
+}
+
+func TestCompareDistributions_InvalidJSON(t *testing.T) {
+	upstream, err := os.ReadFile("testdata/distribution/upstream.json")
+	require.NoError(t, err)
+
+	_, err = CompareDistributions([]byte("{"), upstream)
+	assert.ErrorContains(t, err, "can't parse our config")
+
+	_, err = CompareDistributions(upstream, []byte("{"))
+	assert.ErrorContains(t, err, "can't parse the upstream config")
+}
+
+func TestDistributionDiff_MarkdownEscaping(t *testing.T) {
+	diff := DistributionDiff{DefaultsDiffer: []DistributionChange{{Path: "pattern", Ours: "a|b", Upstream: ""}}}
+	assert.Contains(t, diff.Markdown(), "| `pattern` | `a\\|b` | _empty_ |\n")
+}
@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// CheckFlagDefaults returns an error for each flag whose default value, as shown by -help,
+// can't be set back through its own Set(), like when the String() of a custom value is
+// formatted differently than what Set() expects. Defaults are set on a fresh instance of
+// the flag value type, leaving the config untouched. Like -help, which doesn't show them,
+// defaults matching the zero value of the type aren't checked. The names of the flags whose
+// value type can't be safely instantiated, like the ones holding functions, are returned
+// as skipped rather than checked.
+func CheckFlagDefaults(flags map[uintptr][]*flag.Flag) (errs []error, skipped []string) {
+	seen := map[string]bool{}
+	var all []*flag.Flag
+	for _, fieldFlags := range flags {
+		for _, f := range fieldFlags {
+			if !seen[f.Name] {
+				seen[f.Name] = true
+				all = append(all, f)
+			}
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name < all[j].Name
+	})
+
+	for _, f := range all {
+		value, ok := newFlagValue(f.Value)
+		if !ok {
+			skipped = append(skipped, f.Name)
+			continue
+		}
+
+		if isZeroFlagDefault(value, f.DefValue) {
+			continue
+		}
+
+		if err := setFlagValue(value, f.DefValue); err != nil {
+			errs = append(errs, fmt.Errorf("flag -%s: can't set its default %q: %w", f.Name, f.DefValue, err))
+		}
+	}
+	return errs, skipped
+}
+
+// newFlagValue returns a zero instance of the type of v, if v is a pointer to a type
+// which can be safely instantiated: its zero value doesn't hold any function, channel,
+// interface or unsafe pointer, which usually reference some state of the original value.
+func newFlagValue(v flag.Value) (flag.Value, bool) {
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Ptr || !isSafelyInstantiable(t.Elem(), map[reflect.Type]bool{}) {
+		return nil, false
+	}
+
+	value, ok := reflect.New(t.Elem()).Interface().(flag.Value)
+	return value, ok
+}
+
+func isSafelyInstantiable(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if visited[t] {
+		return true
+	}
+	visited[t] = true
+
+	switch t.Kind() {
+	case reflect.Func, reflect.Chan, reflect.Interface, reflect.UnsafePointer:
+		return false
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return isSafelyInstantiable(t.Elem(), visited)
+	case reflect.Map:
+		return isSafelyInstantiable(t.Key(), visited) && isSafelyInstantiable(t.Elem(), visited)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !isSafelyInstantiable(t.Field(i).Type, visited) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isZeroFlagDefault returns whether def is the string of the zero value, like flag.PrintDefaults does.
+func isZeroFlagDefault(zero flag.Value, def string) (isZero bool) {
+	defer func() {
+		if recover() != nil {
+			isZero = false
+		}
+	}()
+
+	return def == zero.String()
+}
+
+// setFlagValue sets s to the value, reporting a panic, like the one of a value
+// whose zero value isn't usable, as an error.
+func setFlagValue(value flag.Value, s string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return value.Set(s)
+}
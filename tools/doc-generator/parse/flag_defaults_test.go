@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"errors"
+	"flag"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// percentValue is formatted with a "%" suffix which its Set doesn't accept.
+type percentValue struct {
+	value int
+}
+
+func (v *percentValue) String() string {
+	return strconv.Itoa(v.value) + "%"
+}
+
+func (v *percentValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	v.value = n
+	return err
+}
+
+// callbackValue calls a function on Set, so it can't be instantiated on its own.
+type callbackValue struct {
+	callback func(string) error
+}
+
+func (v *callbackValue) String() string {
+	return "callback"
+}
+
+func (v *callbackValue) Set(s string) error {
+	return v.callback(s)
+}
+
+func TestCheckFlagDefaults(t *testing.T) {
+	var (
+		count    int
+		timeout  time.Duration
+		name     string
+		percent  = percentValue{value: 50}
+		zero     percentValue
+		callback = callbackValue{callback: func(string) error { return errors.New("unexpected") }}
+	)
+
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.IntVar(&count, "count", 10, "")
+	fs.DurationVar(&timeout, "timeout", time.Minute, "")
+	fs.StringVar(&name, "name", "mimir", "")
+	fs.Var(&percent, "percent", "")
+	fs.Var(&zero, "zero-percent", "")
+	fs.Var(&callback, "callback", "")
+
+	errs, skipped := CheckFlagDefaults(testFlags(fs))
+
+	// The zero-percent default isn't checked, since it isn't shown by -help either.
+	require.Len(t, errs, 1)
+	assert.True(t, strings.HasPrefix(errs[0].Error(), `flag -percent: can't set its default "50%"`), errs[0].Error())
+	assert.Equal(t, []string{"callback"}, skipped)
+
+	// The config is left untouched.
+	assert.Equal(t, 50, percent.value)
+	assert.Equal(t, 10, count)
+}
+
+func TestCheckFlagDefaults_Panic(t *testing.T) {
+	var values mapValue
+	values.m = map[string]string{"a": "b"}
+
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.Var(&values, "values", "")
+
+	errs, skipped := CheckFlagDefaults(testFlags(fs))
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "panic")
+	assert.Empty(t, skipped)
+}
+
+// mapValue panics when set on its zero value, since the map isn't allocated.
+type mapValue struct {
+	m map[string]string
+}
+
+func (v *mapValue) String() string {
+	var pairs []string
+	for k, val := range v.m {
+		pairs = append(pairs, k+"="+val)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (v *mapValue) Set(s string) error {
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		v.m[parts[0]] = parts[1]
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// flagPrefixPlaceholder replaces the CLI flags prefix of the blocks referenced with multiple prefixes.
+const flagPrefixPlaceholder = "<prefix>"
+
+// FlagMapping returns the dot-separated YAML path of each CLI flag of the blocks, as returned by
+// Config, walking the config from the top-level block, which is the first one. The flags of the
+// blocks whose CLI flags prefix has been replaced by the "<prefix>" placeholder are resolved with
+// the prefix of the block referenced at each path. Fields without a CLI flag are not included.
+// It returns an error if a flag maps to multiple paths, or if its prefix can't be resolved.
+func FlagMapping(blocks []*ConfigBlock) (map[string]string, error) {
+	if len(blocks) == 0 {
+		return map[string]string{}, nil
+	}
+
+	paths := map[string][]string{}
+	if err := collectFlagPaths(blocks[0], "", blocks[0].FlagsPrefix, paths); err != nil {
+		return nil, err
+	}
+
+	mapping := make(map[string]string, len(paths))
+	for name, flagPaths := range paths {
+		if len(flagPaths) > 1 {
+			sort.Strings(flagPaths)
+			return nil, fmt.Errorf("flag %s maps to multiple paths: %s", name, strings.Join(flagPaths, ", "))
+		}
+		mapping[name] = flagPaths[0]
+	}
+	return mapping, nil
+}
+
+func collectFlagPaths(block *ConfigBlock, path, prefix string, paths map[string][]string) error {
+	for _, entry := range block.Entries {
+		entryPath := joinPath(path, entry.Name)
+
+		if entry.Kind == KindBlock {
+			blockPrefix := prefix
+			if entry.Root {
+				blockPrefix = entry.Block.FlagsPrefix
+			}
+			if err := collectFlagPaths(entry.Block, entryPath, blockPrefix, paths); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if entry.FieldFlag == "" {
+			continue
+		}
+
+		for _, name := range append([]string{entry.FieldFlag}, entry.FieldFlagAlternates...) {
+			if strings.HasPrefix(name, flagPrefixPlaceholder) {
+				if prefix == "" {
+					return fmt.Errorf("can't resolve the prefix of the flag %s of %s", name, entryPath)
+				}
+				name = prefix + strings.TrimPrefix(name, flagPrefixPlaceholder)
+			}
+			paths[name] = append(paths[name], entryPath)
+		}
+	}
+	return nil
+}
+
+// InvertFlagMapping returns the sorted CLI flags of each YAML path of the mapping returned by FlagMapping.
+func InvertFlagMapping(mapping map[string]string) map[string][]string {
+	inverse := map[string][]string{}
+	for name, path := range mapping {
+		inverse[path] = append(inverse[path], name)
+	}
+	for _, names := range inverse {
+		sort.Strings(names)
+	}
+	return inverse
+}
+
+// MarshalFlagMappingJSON returns the JSON document of the mapping returned by FlagMapping,
+// along with its inverse mapping.
+func MarshalFlagMappingJSON(mapping map[string]string) ([]byte, error) {
+	return json.MarshalIndent(struct {
+		Flags map[string]string   `json:"flags"`
+		Paths map[string][]string `json:"paths"`
+	}{
+		Flags: mapping,
+		Paths: InvertFlagMapping(mapping),
+	}, "", "  ")
+}
@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"encoding/json"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type FlagMappingInlineConfig struct {
+	Inlined string `yaml:"inlined"`
+}
+
+func TestFlagMapping(t *testing.T) {
+	type config struct {
+		FlagMappingInlineConfig `yaml:",inline"`
+		Server                  struct {
+			Port    int    `yaml:"port"`
+			Secret  string `yaml:"secret" doc:"nocli"`
+			Unbound string `yaml:"unbound"`
+		} `yaml:"server"`
+		Frontend prefixedClientConfig `yaml:"frontend_client"`
+		Querier  prefixedClientConfig `yaml:"querier_client"`
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.StringVar(&cfg.Inlined, "inlined", "", "")
+	fs.IntVar(&cfg.Server.Port, "server.port", 0, "")
+	fs.StringVar(&cfg.Server.Secret, "server.secret", "", "")
+	cfg.Frontend.RegisterFlagsWithPrefix("frontend.", fs)
+	cfg.Querier.RegisterFlagsWithPrefix("querier.", fs)
+
+	rootBlocks := []RootBlock{{Name: "client", StructType: reflect.TypeOf(prefixedClientConfig{})}}
+	blocks, err := Config(cfg, testFlags(fs), rootBlocks)
+	require.NoError(t, err)
+
+	// Replace the prefix of the root blocks with the placeholder, like the doc generator does.
+	prefixes := []string{"frontend", "querier"}
+	require.Len(t, blocks, 3)
+	for i, block := range blocks[1:] {
+		block.FlagsPrefix = prefixes[i]
+		block.FlagsPrefixes = prefixes
+		for _, entry := range block.Entries {
+			entry.FieldFlag = "<prefix>" + strings.TrimPrefix(entry.FieldFlag, prefixes[i])
+		}
+	}
+	require.Equal(t, "<prefix>.client.endpoint", blocks[1].Entries[0].FieldFlag)
+
+	mapping, err := FlagMapping(blocks)
+	require.NoError(t, err)
+
+	// Fields without a flag, either nocli or not registered, are not included.
+	assert.Equal(t, map[string]string{
+		"inlined":                  "inlined",
+		"server.port":              "server.port",
+		"frontend.client.endpoint": "frontend_client.endpoint",
+		"frontend.client.timeout":  "frontend_client.timeout",
+		"querier.client.endpoint":  "querier_client.endpoint",
+		"querier.client.timeout":   "querier_client.timeout",
+	}, mapping)
+
+	assert.Equal(t, map[string][]string{
+		"inlined":                  {"inlined"},
+		"server.port":              {"server.port"},
+		"frontend_client.endpoint": {"frontend.client.endpoint"},
+		"frontend_client.timeout":  {"frontend.client.timeout"},
+		"querier_client.endpoint":  {"querier.client.endpoint"},
+		"querier_client.timeout":   {"querier.client.timeout"},
+	}, InvertFlagMapping(mapping))
+
+	data, err := MarshalFlagMappingJSON(mapping)
+	require.NoError(t, err)
+	doc := struct {
+		Flags map[string]string   `json:"flags"`
+		Paths map[string][]string `json:"paths"`
+	}{}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, mapping, doc.Flags)
+	assert.Equal(t, InvertFlagMapping(mapping), doc.Paths)
+}
+
+func TestFlagMapping_AlternateFlags(t *testing.T) {
+	cfg := &struct {
+		Client prefixedClientConfig `yaml:"client"`
+	}{}
+
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	cfg.Client.RegisterFlagsWithPrefix("", fs)
+	cfg.Client.RegisterFlagsWithPrefix("legacy.", fs)
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+
+	mapping, err := FlagMapping(blocks)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"client.endpoint":        "client.endpoint",
+		"client.timeout":         "client.timeout",
+		"legacy.client.endpoint": "client.endpoint",
+		"legacy.client.timeout":  "client.timeout",
+	}, mapping)
+	assert.Equal(t, []string{"client.endpoint", "legacy.client.endpoint"}, InvertFlagMapping(mapping)["client.endpoint"])
+}
+
+func TestFlagMapping_Errors(t *testing.T) {
+	tests := map[string]struct {
+		blocks   []*ConfigBlock
+		expected string
+	}{
+		"flag mapping to multiple paths": {
+			blocks: []*ConfigBlock{{
+				Entries: []*ConfigEntry{
+					{Kind: KindField, Name: "a", FieldFlag: "shared"},
+					{Kind: KindBlock, Name: "b", Block: &ConfigBlock{
+						Entries: []*ConfigEntry{{Kind: KindField, Name: "c", FieldFlag: "shared"}},
+					}},
+				},
+			}},
+			expected: "flag shared maps to multiple paths: a, b.c",
+		},
+		"unresolved prefix": {
+			blocks: []*ConfigBlock{{
+				Entries: []*ConfigEntry{{Kind: KindField, Name: "a", FieldFlag: "<prefix>.a"}},
+			}},
+			expected: "can't resolve the prefix of the flag <prefix>.a of a",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := FlagMapping(test.blocks)
+			require.Error(t, err)
+			assert.EqualError(t, err, test.expected)
+		})
+	}
+}
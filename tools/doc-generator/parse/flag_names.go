@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// FlagNameRule maps the fields under a YAML path, like the ones of a root block registered
+// with a custom prefix, to the prefix of their CLI flags.
+type FlagNameRule struct {
+	// Dot-separated YAML path from the top-level block, like "ruler.ruler_client".
+	PathPrefix string
+
+	// Prefix of the CLI flags of the fields under PathPrefix, like "ruler.client", or
+	// an empty string if their CLI flags aren't prefixed.
+	FlagPrefix string
+}
+
+// FlagNameMismatch is a field whose CLI flags don't follow the naming convention.
+type FlagNameMismatch struct {
+	Path     string
+	Flag     string
+	Expected string
+}
+
+func (m FlagNameMismatch) String() string {
+	return fmt.Sprintf("field %s has the CLI flag -%s, while -%s is expected", m.Path, m.Flag, m.Expected)
+}
+
+// ExpectedFlagName returns the CLI flag name of the field at the dot-separated YAML path
+// according to the naming convention: the YAML path with underscores replaced by dashes,
+// like "blocks-storage.tsdb.dir" for "blocks_storage.tsdb.dir". The longest rule whose
+// PathPrefix matches the path replaces the matching part of the path with its FlagPrefix.
+func ExpectedFlagName(yamlPath string, rules []FlagNameRule) string {
+	var best *FlagNameRule
+	for i, rule := range rules {
+		if yamlPath != rule.PathPrefix && !strings.HasPrefix(yamlPath, rule.PathPrefix+".") {
+			continue
+		}
+		if best == nil || len(rule.PathPrefix) > len(best.PathPrefix) {
+			best = &rules[i]
+		}
+	}
+
+	if best == nil {
+		return strings.ReplaceAll(yamlPath, "_", "-")
+	}
+
+	rest := strings.TrimPrefix(strings.TrimPrefix(yamlPath, best.PathPrefix), ".")
+	return joinPath(best.FlagPrefix, strings.ReplaceAll(rest, "_", "-"))
+}
+
+// CheckFlagNames returns the fields of the blocks, as returned by Config, none of whose CLI
+// flags is the one expected by ExpectedFlagName, sorted by path. Fields without a CLI flag are
+// skipped, as well as the fields whose path or CLI flag matches any of the exceptions, as matched
+// by path.Match. The expected flag only depends on the YAML path, so the fields of an inline
+// struct, which doesn't add a level to the YAML path, are expected not to add it to the flag either.
+func CheckFlagNames(blocks []*ConfigBlock, rules []FlagNameRule, exceptions []string) ([]FlagNameMismatch, error) {
+	for _, pattern := range exceptions {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid exception %q: %w", pattern, err)
+		}
+	}
+
+	var mismatches []FlagNameMismatch
+	if len(blocks) > 0 {
+		mismatches = appendFlagNameMismatches(mismatches, blocks[0], "", rules, exceptions)
+	}
+
+	sort.Slice(mismatches, func(i, j int) bool {
+		return mismatches[i].Path < mismatches[j].Path
+	})
+	return mismatches, nil
+}
+
+func appendFlagNameMismatches(mismatches []FlagNameMismatch, block *ConfigBlock, blockPath string, rules []FlagNameRule, exceptions []string) []FlagNameMismatch {
+	for _, entry := range block.Entries {
+		entryPath := joinPath(blockPath, entry.Name)
+		if entry.Kind == KindBlock {
+			mismatches = appendFlagNameMismatches(mismatches, entry.Block, entryPath, rules, exceptions)
+			continue
+		}
+
+		if entry.FieldFlag == "" {
+			continue
+		}
+
+		// A root block registered with multiple prefixes is walked at each path it's referenced
+		// at, and its fields have a CLI flag for each prefix, one of which is expected to match.
+		flags := entryFlags(entry)
+		expected := ExpectedFlagName(entryPath, rules)
+		if hasFlag(flags, expected) || isExcluded(entryPath, flags, exceptions) {
+			continue
+		}
+		mismatches = append(mismatches, FlagNameMismatch{Path: entryPath, Flag: entry.FieldFlag, Expected: expected})
+	}
+	return mismatches
+}
+
+func hasFlag(flags []string, name string) bool {
+	for _, f := range flags {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadFlagNameExceptions reads the exceptions of CheckFlagNames, one per line. Empty lines
+// and lines starting with "#" are skipped.
+func LoadFlagNameExceptions(r io.Reader) ([]string, error) {
+	var exceptions []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		exceptions = append(exceptions, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("can't read the flag name exceptions: %w", err)
+	}
+	return exceptions, nil
+}
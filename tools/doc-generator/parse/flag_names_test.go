@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpectedFlagName(t *testing.T) {
+	rules := []FlagNameRule{
+		{PathPrefix: "ingester.ring.kvstore", FlagPrefix: "ingester.ring"},
+		{PathPrefix: "ingester.ring.kvstore.consul", FlagPrefix: "consul"},
+		{PathPrefix: "server", FlagPrefix: ""},
+	}
+
+	tests := map[string]string{
+		"blocks_storage.tsdb.dir":                "blocks-storage.tsdb.dir",
+		"ingester.ring.kvstore.store":            "ingester.ring.store",
+		"ingester.ring.kvstore_backup.store":     "ingester.ring.kvstore-backup.store",
+		"ingester.ring.kvstore.consul.acl_token": "consul.acl-token",
+		"server.http_listen_port":                "http-listen-port",
+	}
+
+	for yamlPath, expected := range tests {
+		assert.Equal(t, expected, ExpectedFlagName(yamlPath, rules), yamlPath)
+	}
+}
+
+type FlagNamesLogConfig struct {
+	LogLevel  string `yaml:"log_level"`
+	LogFormat string `yaml:"log_format"`
+}
+
+func TestCheckFlagNames(t *testing.T) {
+	type config struct {
+		FlagNamesLogConfig `yaml:",inline"`
+
+		Storage struct {
+			Dir           string `yaml:"dir"`
+			RetentionDays int    `yaml:"retention_days"`
+			NoCLI         string `yaml:"no_cli"`
+		} `yaml:"storage"`
+
+		Ruler        prefixedClientConfig `yaml:"ruler"`
+		Alertmanager prefixedClientConfig `yaml:"alertmanager"`
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.StringVar(&cfg.LogLevel, "log-level", "info", "The log level.")
+	fs.StringVar(&cfg.LogFormat, "log.format", "logfmt", "The log format.")
+	fs.StringVar(&cfg.Storage.Dir, "storage.dir", "", "The directory.")
+	fs.IntVar(&cfg.Storage.RetentionDays, "storage.retention", 0, "The retention.")
+	cfg.Ruler.RegisterFlagsWithPrefix("ruler.", fs)
+	cfg.Alertmanager.RegisterFlagsWithPrefix("alertmanager.", fs)
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+
+	rules := []FlagNameRule{{PathPrefix: "ruler", FlagPrefix: "ruler.client"}}
+	mismatches, err := CheckFlagNames(blocks, rules, nil)
+	require.NoError(t, err)
+
+	// The fields of an inline struct are expected to have a flag following their YAML path,
+	// which doesn't include the inline struct, so "log.format" isn't expected for "log_format".
+	// The client config isn't covered by a rule for the alertmanager, so its flags don't match.
+	assert.Equal(t, []string{
+		"field alertmanager.endpoint has the CLI flag -alertmanager.client.endpoint, while -alertmanager.endpoint is expected",
+		"field alertmanager.timeout has the CLI flag -alertmanager.client.timeout, while -alertmanager.timeout is expected",
+		"field log_format has the CLI flag -log.format, while -log-format is expected",
+		"field storage.retention_days has the CLI flag -storage.retention, while -storage.retention-days is expected",
+	}, flagNameMismatchStrings(mismatches))
+
+	// Grandfathered fields can be excepted by path or by CLI flag.
+	mismatches, err = CheckFlagNames(blocks, rules, []string{"alertmanager.*", "log.format"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"field storage.retention_days has the CLI flag -storage.retention, while -storage.retention-days is expected",
+	}, flagNameMismatchStrings(mismatches))
+
+	_, err = CheckFlagNames(blocks, rules, []string{"["})
+	assert.EqualError(t, err, `invalid exception "[": syntax error in pattern`)
+}
+
+func TestLoadFlagNameExceptions(t *testing.T) {
+	exceptions, err := LoadFlagNameExceptions(strings.NewReader(`
+# Renamed before the convention.
+alertmanager.data_dir
+
+  log.format  
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alertmanager.data_dir", "log.format"}, exceptions)
+}
+
+func flagNameMismatchStrings(mismatches []FlagNameMismatch) []string {
+	var actual []string
+	for _, m := range mismatches {
+		actual = append(actual, m.String())
+	}
+	return actual
+}
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"flag"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/common/model"
+	"github.com/weaveworks/common/logging"
+)
+
+// FlagType is a CLI flag along with the documented type of its value, as needed to complete it.
+type FlagType struct {
+	Name  string
+	Usage string
+
+	// The type of the flag value, like "int" or "list of string", empty if unknown.
+	Type string
+}
+
+// flagValueTypes are the documented types of the flag values whose type isn't the one of
+// the config field, like the ones of the flag package, or which are documented as a custom
+// field entry.
+var flagValueTypes = func() map[reflect.Type]string {
+	types := map[reflect.Type]string{
+		reflect.TypeOf(&logging.Level{}):    "string",
+		reflect.TypeOf(&logging.Format{}):   "string",
+		reflect.TypeOf(&flagext.URLValue{}): "url",
+		reflect.TypeOf(&flagext.Secret{}):   "string",
+		reflect.TypeOf(new(model.Duration)): "duration",
+		reflect.TypeOf(&flagext.Time{}):     "time",
+		reflect.TypeOf(&flagext.DayValue{}): "date",
+	}
+
+	// The values of the flag package are unexported, so they're taken from
+	// flags whose usage is the documented type.
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.Bool("bool", false, "boolean")
+	fs.Int("int", 0, "int")
+	fs.Int64("int64", 0, "int")
+	fs.Uint("uint", 0, "int")
+	fs.Uint64("uint64", 0, "int")
+	fs.Float64("float64", 0, "float")
+	fs.String("string", "", "string")
+	fs.Duration("duration", time.Duration(0), "duration")
+	fs.VisitAll(func(f *flag.Flag) {
+		types[reflect.TypeOf(f.Value)] = f.Usage
+	})
+
+	return types
+}()
+
+// FlagTypes returns the input flags, as returned by Flags, along with the documented type of
+// their value, sorted by name. Types are documented like the config fields, through the same
+// mapping, so that completion scripts can be generated from the flags alone.
+func FlagTypes(flags map[uintptr][]*flag.Flag) []FlagType {
+	var types []FlagType
+	for _, fieldFlags := range flags {
+		for _, f := range fieldFlags {
+			types = append(types, FlagType{Name: f.Name, Usage: f.Usage, Type: getFlagType(f)})
+		}
+	}
+
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].Name < types[j].Name
+	})
+	return types
+}
+
+// getFlagType returns the documented type of the value of the flag, or an empty string if unknown.
+func getFlagType(f *flag.Flag) string {
+	t := reflect.TypeOf(f.Value)
+	if typ, ok := flagValueTypes[t]; ok {
+		return typ
+	}
+
+	// Other values are the config fields themselves.
+	if t.Kind() != reflect.Ptr {
+		return ""
+	}
+
+	// Like in the config, structs without a custom type are documented as a string.
+	if _, custom := getFieldCustomType(t.Elem()); !custom && t.Elem().Kind() == reflect.Struct {
+		return "string"
+	}
+
+	typ, err := getFieldType(t.Elem())
+	if err != nil {
+		return ""
+	}
+	return typ.String()
+}
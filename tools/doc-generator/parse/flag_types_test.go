@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/stretchr/testify/assert"
+	"github.com/weaveworks/common/logging"
+
+	"github.com/grafana/mimir/pkg/ingester/activeseries"
+	"github.com/grafana/mimir/pkg/storage/tsdb"
+)
+
+func TestFlagTypes(t *testing.T) {
+	var (
+		enabled   bool
+		limit     int
+		maxBytes  uint64
+		ratio     float64
+		name      string
+		timeout   time.Duration
+		targets   flagext.StringSliceCSV
+		ranges    tsdb.DurationList
+		level     logging.Level
+		url       flagext.URLValue
+		trackers  activeseries.CustomTrackersConfig
+		address   hostPortValue
+		protected flagext.Secret
+	)
+
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.BoolVar(&enabled, "enabled", false, "Whether it's enabled.")
+	fs.IntVar(&limit, "limit", 10, "The limit.")
+	fs.Uint64Var(&maxBytes, "max-bytes", 0, "The max bytes.")
+	fs.Float64Var(&ratio, "ratio", 0.5, "The ratio.")
+	fs.StringVar(&name, "name", "", "The name.")
+	fs.DurationVar(&timeout, "timeout", time.Minute, "The timeout.")
+	fs.Var(&targets, "targets", "The targets.")
+	fs.Var(&ranges, "ranges", "The ranges.")
+	fs.Var(&level, "log.level", "The log level.")
+	fs.Var(&url, "url", "The URL.")
+	fs.Var(&trackers, "trackers", "The trackers.")
+	fs.Var(&address, "address", "The address.")
+	fs.Var(&protected, "password", "The password.")
+
+	// A flag registered with multiple names.
+	fs.IntVar(&limit, "legacy.limit", 10, "The limit.")
+
+	assert.Equal(t, []FlagType{
+		{Name: "address", Usage: "The address.", Type: "string"},
+		{Name: "enabled", Usage: "Whether it's enabled.", Type: "boolean"},
+		{Name: "legacy.limit", Usage: "The limit.", Type: "int"},
+		{Name: "limit", Usage: "The limit.", Type: "int"},
+		{Name: "log.level", Usage: "The log level.", Type: "string"},
+		{Name: "max-bytes", Usage: "The max bytes.", Type: "int"},
+		{Name: "name", Usage: "The name.", Type: "string"},
+		{Name: "password", Usage: "The password.", Type: "string"},
+		{Name: "ranges", Usage: "The ranges.", Type: "list of duration"},
+		{Name: "ratio", Usage: "The ratio.", Type: "float"},
+		{Name: "targets", Usage: "The targets.", Type: "string"},
+		{Name: "timeout", Usage: "The timeout.", Type: "duration"},
+		{Name: "trackers", Usage: "The trackers.", Type: "map of tracker name (string) to matcher (string)"},
+		{Name: "url", Usage: "The URL.", Type: "url"},
+	}, FlagTypes(testFlags(fs)))
+}
+
+func TestFlagTypes_Empty(t *testing.T) {
+	assert.Empty(t, FlagTypes(nil))
+}
@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/grafana/regexp"
+)
+
+var (
+	// usageMarkdownLinkRegexp matches markdown links, like "[the docs](https://grafana.com/docs/)".
+	usageMarkdownLinkRegexp = regexp.MustCompile(`\[[^\[\]]+\]\([^()\s]+\)`)
+
+	// usageHTMLTagRegexp matches opening, closing and self-closing HTML tags, like "<br>" or "</a>".
+	// Placeholders in angle brackets, like "<tenant>" or "<prefix>", are told apart by only
+	// matching the tags commonly found in descriptions.
+	usageHTMLTagRegexp = regexp.MustCompile(`(?i)</?(?:a|b|br|code|em|i|li|ol|p|pre|strong|ul)(?:\s[^<>]*)?/?>`)
+)
+
+// usageExcerptLength is the maximum length of the excerpts of the CLI flag usages reported by ValidateFlagUsages.
+const usageExcerptLength = 40
+
+// ValidateFlagUsages returns an error for each field whose CLI flag usage, as shown by -help,
+// is longer than maxLength characters or contains a markdown link or an HTML tag, which
+// are only rendered by the website. Only the usage is checked, so the remedy is to move
+// the long description to the description doc tag, which overrides the usage in the
+// reference configuration, and to keep the usage short.
+func ValidateFlagUsages(blocks []*ConfigBlock, flags map[uintptr][]*flag.Flag, maxLength int) []error {
+	usages := map[string]string{}
+	for _, fieldFlags := range flags {
+		for _, f := range fieldFlags {
+			usages[f.Name] = f.Usage
+		}
+	}
+
+	var errs []error
+	for _, block := range blocks {
+		errs = append(errs, validateFlagUsages(block, block.Name, usages, maxLength)...)
+	}
+	return errs
+}
+
+func validateFlagUsages(block *ConfigBlock, path string, usages map[string]string, maxLength int) []error {
+	var errs []error
+	for _, entry := range block.Entries {
+		entryPath := joinPath(path, entry.Name)
+
+		if entry.Kind == KindBlock {
+			// Root blocks are validated on their own.
+			if !entry.Root {
+				errs = append(errs, validateFlagUsages(entry.Block, entryPath, usages, maxLength)...)
+			}
+			continue
+		}
+
+		usage, ok := usages[entry.FieldFlag]
+		if !ok {
+			continue
+		}
+
+		if len(usage) > maxLength {
+			// The excerpt is the text past the budget.
+			errs = append(errs, fmt.Errorf("field %s has a CLI flag usage of %d characters, exceeding %d at %q", entryPath, len(usage), maxLength, usageExcerpt(usage[maxLength:])))
+		}
+		if match := usageMarkdownLinkRegexp.FindString(usage); match != "" {
+			errs = append(errs, fmt.Errorf("field %s has a CLI flag usage containing a markdown link: %q", entryPath, usageExcerpt(match)))
+		}
+		if match := usageHTMLTagRegexp.FindString(usage); match != "" {
+			errs = append(errs, fmt.Errorf("field %s has a CLI flag usage containing an HTML tag: %q", entryPath, usageExcerpt(match)))
+		}
+	}
+	return errs
+}
+
+// usageExcerpt returns s truncated to usageExcerptLength characters, followed by "..." if truncated.
+func usageExcerpt(s string) string {
+	runes := []rune(s)
+	if len(runes) <= usageExcerptLength {
+		return s
+	}
+	return string(runes[:usageExcerptLength]) + "..."
+}
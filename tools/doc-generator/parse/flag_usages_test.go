@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFlagUsages(t *testing.T) {
+	type storageConfig struct {
+		Short    string `yaml:"short"`
+		Long     string `yaml:"long"`
+		Link     string `yaml:"link"`
+		HTML     string `yaml:"html"`
+		Remedied string `yaml:"remedied" doc:"description=The service account, see [the docs](https://cloud.google.com/iam/docs/) for how to create one.<br>It must have write access."`
+	}
+	type config struct {
+		Storage storageConfig `yaml:"storage"`
+		NoFlag  string        `yaml:"no_flag"`
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.StringVar(&cfg.Storage.Short, "storage.short", "", "Short plain text, with a <placeholder>.")
+	fs.StringVar(&cfg.Storage.Long, "storage.long", "", strings.Repeat("a", 80)+strings.Repeat("b", 50))
+	fs.StringVar(&cfg.Storage.Link, "storage.link", "", "Refer to [the docs](https://grafana.com/docs/mimir/) for details.")
+	fs.StringVar(&cfg.Storage.HTML, "storage.html", "", "First paragraph.<br/>Second paragraph.")
+	fs.StringVar(&cfg.Storage.Remedied, "storage.remedied", "", "The service account.")
+
+	blocks, err := Config(cfg, FlagsFromSet(fs), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`field storage.long has a CLI flag usage of 130 characters, exceeding 80 at "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb..."`,
+		`field storage.link has a CLI flag usage containing a markdown link: "[the docs](https://grafana.com/docs/mimi..."`,
+		`field storage.html has a CLI flag usage containing an HTML tag: "<br/>"`,
+	}, errorStrings(ValidateFlagUsages(blocks, FlagsFromSet(fs), 80)))
+
+	// The long description is in the doc tag, so only the short usage is shown by -help.
+	assert.Contains(t, blocks[0].Entries[0].Block.Entries[4].FieldDesc, "[the docs]")
+}
+
+func TestUsageExcerpt(t *testing.T) {
+	assert.Equal(t, "short", usageExcerpt("short"))
+	assert.Equal(t, strings.Repeat("é", usageExcerptLength)+"...", usageExcerpt(strings.Repeat("é", usageExcerptLength+1)))
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package parse maps a config struct, along with the CLI flags it registers, to the blocks
+// documented by the reference configuration. Generate is the entrypoint: it runs the parsing
+// and the post-processing in the right order, while the individual functions are exported
+// for the callers needing a single step, like the linters.
+package parse
+
+import (
+	"flag"
+	"sort"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+)
+
+// Result is the result of Generate.
+type Result struct {
+	// The defaulted config and the CLI flags it registers, as returned by DefaultedConfig.
+	Config interface{}
+	Flags  map[uintptr][]*flag.Flag
+
+	// The blocks, as returned by ConfigWithOptions: the top-level block first, followed by the other blocks.
+	Blocks []*ConfigBlock
+
+	// The field entries of the blocks by dot-separated YAML path from the top-level block, sorted
+	// by path. Fields of list elements have a "[]" path segment, like "targets[].url", and the
+	// fields of a root block are listed once for each entry referencing it.
+	Entries []FlatEntry
+
+	// The markdown anchors of the blocks other than the top-level one, like "#blocks_storage", by block name.
+	Anchors map[string]string
+
+	// Issues worth reporting which don't prevent documenting the config, like unintended aliases.
+	Warnings []error
+
+	Stats Stats
+}
+
+// Stats are counts of the documented config.
+type Stats struct {
+	Blocks     int
+	Fields     int
+	Deprecated int
+
+	// The number of fields of each category. Fields without a category are "basic".
+	Categories map[string]int
+}
+
+// Generate parses the config registered by cfg, along with its CLI flags, like ConfigWithOptions
+// with the root blocks of RootBlocks, and computes the data derived from the blocks, each once.
+// cfg is never modified, since the defaults are read from a new instance, like DefaultedConfig.
+func Generate(cfg flagext.RegistererWithLogger, logger log.Logger, opts Options) (*Result, error) {
+	defaulted, flags := DefaultedConfig(cfg, logger)
+	blocks, err := ConfigWithOptions(defaulted, flags, RootBlocks, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{
+		Config:  defaulted,
+		Flags:   flags,
+		Blocks:  blocks,
+		Anchors: map[string]string{},
+		Stats:   Stats{Blocks: len(blocks), Categories: map[string]int{}},
+	}
+
+	// Anchors and the flat index are computed from all the blocks, so that they don't
+	// depend on any filtering applied by the caller to the documented fields.
+	for _, block := range blocks[1:] {
+		res.Anchors[block.Name] = "#" + slugify(block.Name)
+	}
+	res.Entries = appendFlatEntries(nil, blocks[0], "")
+	sort.SliceStable(res.Entries, func(i, j int) bool {
+		return res.Entries[i].Path < res.Entries[j].Path
+	})
+
+	res.Warnings = append(res.Warnings, ValidateAliases(blocks)...)
+	res.Warnings = append(res.Warnings, ValidateFieldWarnings(blocks)...)
+
+	res.Stats.Fields = len(res.Entries)
+	for _, entry := range res.Entries {
+		res.Stats.Categories[entryCategory(entry.Entry)]++
+		if entry.Entry.DeprecatedInFavorOf != "" {
+			res.Stats.Deprecated++
+		}
+	}
+
+	return res, nil
+}
+
+func appendFlatEntries(entries []FlatEntry, block *ConfigBlock, path string) []FlatEntry {
+	for _, entry := range block.Entries {
+		entryPath := joinPath(path, entry.Name)
+		if entry.Kind == KindBlock {
+			entries = appendFlatEntries(entries, entry.Block, entryPath)
+			continue
+		}
+
+		entries = append(entries, FlatEntry{Path: entryPath, Entry: entry})
+		if entry.Element != nil {
+			entries = appendFlatEntries(entries, entry.Element, entryPath+"[]")
+		}
+	}
+	return entries
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type generateStorageConfig struct {
+	Backend string `yaml:"backend"`
+	Dir     string `yaml:"dir" category:"advanced" doc:"warning=Must be persistent"`
+}
+
+type generateConfig struct {
+	Address string                `yaml:"address"`
+	Old     string                `yaml:"old" doc:"deprecated=address"`
+	Storage generateStorageConfig `yaml:"storage"`
+	Targets []struct {
+		URL string `yaml:"url"`
+	} `yaml:"targets"`
+}
+
+func (cfg *generateConfig) RegisterFlags(fs *flag.FlagSet, _ log.Logger) {
+	fs.StringVar(&cfg.Address, "address", "localhost", "The address.")
+	fs.StringVar(&cfg.Storage.Backend, "storage.backend", "filesystem", "The storage backend.")
+	fs.StringVar(&cfg.Storage.Dir, "storage.dir", "./data", "The storage directory.")
+}
+
+func TestGenerate(t *testing.T) {
+	res, err := Generate(&generateConfig{}, log.NewNopLogger(), Options{StrictDocTags: true})
+	require.NoError(t, err)
+
+	// The result matches the one pieced together from the individual functions. The CLI flags are
+	// matched by the address of the fields, so the blocks are parsed from the same defaulted config.
+	cfg, flags := DefaultedConfig(&generateConfig{}, log.NewNopLogger())
+	assert.Equal(t, cfg, res.Config)
+	assert.Len(t, res.Flags, len(flags))
+
+	blocks, err := ConfigWithOptions(res.Config, res.Flags, RootBlocks, Options{StrictDocTags: true})
+	require.NoError(t, err)
+	assert.Equal(t, blocks, res.Blocks)
+	assert.Equal(t, append(ValidateAliases(blocks), ValidateFieldWarnings(blocks)...), res.Warnings)
+
+	anchors := map[string]string{}
+	for _, block := range blocks[1:] {
+		anchors[block.Name] = "#" + slugify(block.Name)
+	}
+	assert.Equal(t, anchors, res.Anchors)
+
+	var paths []string
+	for _, entry := range res.Entries {
+		paths = append(paths, entry.Path)
+	}
+	assert.Equal(t, []string{"address", "old", "storage.backend", "storage.dir", "targets", "targets[].url"}, paths)
+	assert.Equal(t, "./data", res.Entries[3].Entry.FieldDefault)
+
+	assert.Equal(t, []string{"field storage.dir has a warning not ending with a terminal punctuation mark: \"Must be persistent\""}, errorStrings(res.Warnings))
+	assert.Equal(t, Stats{
+		Blocks:     len(blocks),
+		Fields:     6,
+		Deprecated: 1,
+		Categories: map[string]int{"basic": 5, "advanced": 1},
+	}, res.Stats)
+}
+
+func TestGenerate_Error(t *testing.T) {
+	_, err := Generate(&generateConfig{}, log.NewNopLogger(), Options{
+		EntryTransform: func(_ []string, e *ConfigEntry) (*ConfigEntry, bool) {
+			e.Name = "duplicated"
+			return e, true
+		},
+	})
+	assert.Error(t, err)
+}
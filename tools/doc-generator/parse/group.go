@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"sort"
+	"strings"
+)
+
+// componentPkgPrefix is the prefix of the packages owning config options. Options declared
+// by any other package, like the vendored ones, are grouped under the referencing component.
+const componentPkgPrefix = "github.com/grafana/mimir/"
+
+// FlatEntry is a field entry along with its dot-separated YAML path from the top-level block.
+type FlatEntry struct {
+	Path  string
+	Entry *ConfigEntry
+
+	// Whether the field is declared by a shared package, rather than by the component it's grouped under.
+	Shared bool
+}
+
+// String returns the path of the entry, marked with "(shared)" in case it's declared by a shared package.
+func (e FlatEntry) String() string {
+	if e.Shared {
+		return e.Path + " (shared)"
+	}
+	return e.Path
+}
+
+// GroupByPackage returns the field entries of the blocks, as returned by Config, grouped by the
+// package declaring them, like "pkg/ingester", regardless of where they appear in the YAML tree.
+// Fields declared by shared packages, like the vendored ones, are grouped under the package of
+// the closest block referencing them, and marked as shared. The entries of each group are sorted
+// by path.
+func GroupByPackage(blocks []*ConfigBlock) map[string][]FlatEntry {
+	groups := map[string][]FlatEntry{}
+	if len(blocks) > 0 {
+		groupBlockEntries(blocks[0], "", "", groups)
+	}
+
+	for _, entries := range groups {
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Path < entries[j].Path
+		})
+	}
+	return groups
+}
+
+// groupBlockEntries adds the field entries of the block to groups. owner is the component
+// package of the closest entry referencing the block.
+func groupBlockEntries(block *ConfigBlock, path, owner string, groups map[string][]FlatEntry) {
+	for _, entry := range block.Entries {
+		entryPath := joinPath(path, entry.Name)
+
+		entryOwner, shared := owner, true
+		if pkg, ok := componentPackage(entry.PkgPath); ok {
+			entryOwner, shared = pkg, false
+		}
+
+		if entry.Kind == KindBlock {
+			groupBlockEntries(entry.Block, entryPath, entryOwner, groups)
+			continue
+		}
+
+		groups[entryOwner] = append(groups[entryOwner], FlatEntry{Path: entryPath, Entry: entry, Shared: shared})
+		if entry.Element != nil {
+			groupBlockEntries(entry.Element, entryPath+"[]", entryOwner, groups)
+		}
+	}
+}
+
+// componentPackage returns the package path relative to the module, like "pkg/ingester",
+// and whether it's a component package.
+func componentPackage(pkgPath string) (string, bool) {
+	if !strings.HasPrefix(pkgPath, componentPkgPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(pkgPath, componentPkgPrefix), true
+}
@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+
+	"github.com/grafana/dskit/backoff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+type groupIngesterConfig struct {
+	Rule    validation.ForwardingRule `yaml:",inline"`
+	Backoff backoff.Config            `yaml:"backoff"`
+	Limit   int                       `yaml:"limit"`
+}
+
+func TestGroupByPackage(t *testing.T) {
+	type config struct {
+		Ingester groupIngesterConfig `yaml:"ingester"`
+		Client   backoff.Config      `yaml:"client"`
+		Name     string              `yaml:"name"`
+	}
+
+	rootBlocks := []RootBlock{
+		{Name: "backoff_config", Desc: "The backoff block.", StructType: reflect.TypeOf(backoff.Config{})},
+	}
+
+	blocks, err := Config(&config{}, testFlags(flag.NewFlagSet("", flag.PanicOnError)), rootBlocks)
+	require.NoError(t, err)
+
+	groups := GroupByPackage(blocks)
+
+	paths := map[string][]string{}
+	for pkg, entries := range groups {
+		for _, entry := range entries {
+			paths[pkg] = append(paths[pkg], entry.String())
+		}
+	}
+
+	assert.Equal(t, map[string][]string{
+		// Fields of the inline struct are grouped under its own package.
+		"pkg/util/validation": {
+			"ingester.endpoint",
+			"ingester.ingest",
+		},
+		// Fields of the vendored root block are grouped under the package referencing it.
+		"tools/doc-generator/parse": {
+			"client.max_period (shared)",
+			"client.max_retries (shared)",
+			"client.min_period (shared)",
+			"ingester.backoff.max_period (shared)",
+			"ingester.backoff.max_retries (shared)",
+			"ingester.backoff.min_period (shared)",
+			"ingester.limit",
+			"name",
+		},
+	}, paths)
+
+	assert.Equal(t, "limit", groups["tools/doc-generator/parse"][6].Entry.Name)
+	assert.False(t, groups["tools/doc-generator/parse"][6].Shared)
+}
+
+func TestGroupByPackage_Empty(t *testing.T) {
+	assert.Empty(t, GroupByPackage(nil))
+}
@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"fmt"
+	"reflect"
+)
+
+var examplerConfigType = reflect.TypeOf((*ExamplerConfig)(nil)).Elem()
+
+// ValidateHiddenFields returns an error for each hidden field of the config, or field nested
+// in a hidden struct, which has a category tag, a doc description or implements ExamplerConfig:
+// hidden fields aren't documented, so such fields were most likely meant to be public.
+// Hidden fields are skipped by Config, so the config is walked on its own, and fields
+// are located by the package path and type of their struct, like
+// "github.com/grafana/mimir/pkg/mimir.Config.Ingester". Fields in allowlist are allowed.
+func ValidateHiddenFields(cfg interface{}, allowlist []string) []error {
+	allowed := map[string]bool{}
+	for _, location := range allowlist {
+		allowed[location] = true
+	}
+
+	t := reflect.TypeOf(cfg)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	w := &hiddenFieldsWalker{allowed: allowed, visited: map[hiddenFieldsVisit]bool{}}
+	w.walk(t, structLocation(t, ""), "")
+	return w.errs
+}
+
+// hiddenFieldsVisit is a struct type walked by hiddenFieldsWalker, which is walked again
+// if it's reached through a hidden field after being reached through a public one.
+type hiddenFieldsVisit struct {
+	t      reflect.Type
+	hidden bool
+}
+
+type hiddenFieldsWalker struct {
+	allowed map[string]bool
+	visited map[hiddenFieldsVisit]bool
+	errs    []error
+}
+
+// walk checks the fields of the struct type t, located at location. If hiddenBy isn't
+// empty, the struct is nested in the hidden field located at hiddenBy.
+func (w *hiddenFieldsWalker) walk(t reflect.Type, location, hiddenBy string) {
+	visit := hiddenFieldsVisit{t: t, hidden: hiddenBy != ""}
+	if t.Name() != "" {
+		if w.visited[visit] {
+			return
+		}
+		w.visited[visit] = true
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Tag.Get("yaml") == "-" || (field.PkgPath != "" && !field.Anonymous) {
+			continue
+		}
+
+		fieldLocation := location + "." + field.Name
+		fieldHiddenBy := hiddenBy
+		if fieldHiddenBy == "" && isFieldHidden(field) {
+			fieldHiddenBy = fieldLocation
+		}
+
+		if fieldHiddenBy != "" && !w.allowed[fieldLocation] {
+			w.check(field, fieldLocation, fieldHiddenBy)
+		}
+
+		if ft := structType(field.Type); ft != nil {
+			if _, custom := getFieldCustomType(ft); !custom {
+				w.walk(ft, structLocation(ft, fieldLocation), fieldHiddenBy)
+			}
+		}
+	}
+}
+
+func (w *hiddenFieldsWalker) check(field reflect.StructField, location, hiddenBy string) {
+	reason := "hidden field " + location
+	if hiddenBy != location {
+		reason = fmt.Sprintf("field %s, hidden by %s,", location, hiddenBy)
+	}
+
+	if field.Tag.Get("category") != "" {
+		w.errs = append(w.errs, fmt.Errorf("%s has the category tag %q", reason, field.Tag.Get("category")))
+	}
+	if getDocTagValue(field, "description") != "" {
+		w.errs = append(w.errs, fmt.Errorf("%s has a doc description", reason))
+	}
+
+	t := field.Type
+	if t.Kind() != reflect.Ptr {
+		t = reflect.PtrTo(t)
+	}
+	if t.Implements(examplerConfigType) {
+		w.errs = append(w.errs, fmt.Errorf("%s implements ExamplerConfig", reason))
+	}
+}
+
+// structType returns the struct type of the values of t, which can be a struct, or a pointer,
+// slice or map of structs, or nil if t doesn't hold structs.
+func structType(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		case reflect.Struct:
+			return t
+		default:
+			return nil
+		}
+	}
+}
+
+// structLocation returns the location of the fields of the struct type t: its package path
+// and name, or the location of the field of type t if it's an anonymous struct.
+func structLocation(t reflect.Type, fieldLocation string) string {
+	if t.Name() == "" {
+		return fieldLocation
+	}
+	return t.PkgPath() + "." + t.Name()
+}
@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const hiddenTestPkg = "github.com/grafana/mimir/tools/doc-generator/parse."
+
+type hiddenTestLeafConfig struct {
+	Public   int             `yaml:"public" category:"advanced" doc:"description=A public field."`
+	Examples exampleTrackers `yaml:"examples"`
+}
+
+type hiddenTestNestedConfig struct {
+	Leaf  hiddenTestLeafConfig   `yaml:"leaf"`
+	Items []hiddenTestLeafConfig `yaml:"items"`
+}
+
+type hiddenTestConfig struct {
+	// Public fields can be documented.
+	Public  int                    `yaml:"public" category:"experimental"`
+	Visible hiddenTestNestedConfig `yaml:"visible"`
+
+	// Hidden fields, and the fields nested in hidden structs, can't.
+	Clean       int                     `yaml:"clean" doc:"hidden"`
+	Categorized int                     `yaml:"categorized" category:"advanced" doc:"hidden"`
+	Described   int                     `yaml:"described" doc:"hidden|description=A hidden field."`
+	Examples    exampleTrackers         `yaml:"examples" doc:"hidden"`
+	Nested      *hiddenTestNestedConfig `yaml:"nested" doc:"hidden"`
+	Inline      struct {
+		Deep hiddenTestLeafConfig `yaml:"deep"`
+	} `yaml:"inline" doc:"hidden"`
+
+	// Fields which aren't part of the config are ignored.
+	Ignored hiddenTestLeafConfig `yaml:"-" doc:"hidden"`
+	private int                  //nolint:unused,structcheck
+}
+
+func TestValidateHiddenFields(t *testing.T) {
+	errs := ValidateHiddenFields(&hiddenTestConfig{}, nil)
+
+	assert.Equal(t, []string{
+		`hidden field ` + hiddenTestPkg + `hiddenTestConfig.Categorized has the category tag "advanced"`,
+		`hidden field ` + hiddenTestPkg + `hiddenTestConfig.Described has a doc description`,
+		`hidden field ` + hiddenTestPkg + `hiddenTestConfig.Examples implements ExamplerConfig`,
+		`field ` + hiddenTestPkg + `hiddenTestLeafConfig.Public, hidden by ` + hiddenTestPkg + `hiddenTestConfig.Nested, has the category tag "advanced"`,
+		`field ` + hiddenTestPkg + `hiddenTestLeafConfig.Public, hidden by ` + hiddenTestPkg + `hiddenTestConfig.Nested, has a doc description`,
+		`field ` + hiddenTestPkg + `hiddenTestLeafConfig.Examples, hidden by ` + hiddenTestPkg + `hiddenTestConfig.Nested, implements ExamplerConfig`,
+	}, errorStrings(errs))
+}
+
+func TestValidateHiddenFields_InlineStruct(t *testing.T) {
+	type config struct {
+		Inline struct {
+			Deep struct {
+				Described int `yaml:"described" doc:"description=A nested field."`
+			} `yaml:"deep"`
+		} `yaml:"inline" doc:"hidden"`
+	}
+
+	// Fields of anonymous structs are located through the field of the struct.
+	errs := ValidateHiddenFields(config{}, nil)
+	assert.Equal(t, []string{
+		`field ` + hiddenTestPkg + `config.Inline.Deep.Described, hidden by ` + hiddenTestPkg + `config.Inline, has a doc description`,
+	}, errorStrings(errs))
+}
+
+func TestValidateHiddenFields_Allowlist(t *testing.T) {
+	errs := ValidateHiddenFields(&hiddenTestConfig{}, []string{
+		hiddenTestPkg + "hiddenTestConfig.Categorized",
+		hiddenTestPkg + "hiddenTestConfig.Examples",
+		hiddenTestPkg + "hiddenTestLeafConfig.Public",
+	})
+
+	assert.Equal(t, []string{
+		`hidden field ` + hiddenTestPkg + `hiddenTestConfig.Described has a doc description`,
+		`field ` + hiddenTestPkg + `hiddenTestLeafConfig.Examples, hidden by ` + hiddenTestPkg + `hiddenTestConfig.Nested, implements ExamplerConfig`,
+	}, errorStrings(errs))
+}
+
+func errorStrings(errs []error) []string {
+	var actual []string
+	for _, err := range errs {
+		actual = append(actual, err.Error())
+	}
+	return actual
+}
@@ -0,0 +1,207 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/pkg/errors"
+)
+
+// Implementation is a known implementation of an interface config field, selected by name.
+type Implementation struct {
+	// The name selecting the implementation, like the value of a "type" discriminator.
+	Name string
+	Desc string
+
+	// The struct type of the implementation, or a pointer to it.
+	Type reflect.Type
+}
+
+// implementationSet are the known implementations of an interface, selected by the discriminator field.
+type implementationSet struct {
+	discriminator string
+
+	// Sorted by name.
+	impls []Implementation
+}
+
+// Known implementations of the interface config fields, by interface type.
+var implementations = map[reflect.Type]*implementationSet{}
+
+// RegisterImplementations registers the known implementations of the interface iface, like
+// (*Backend)(nil), selected by the value of the discriminator field, like "type". Config
+// documents the fields of type iface as a block holding the discriminator field followed
+// by the fields of each implementation. Otherwise, interface fields can't be documented.
+// It panics if an implementation isn't a struct implementing iface, or if iface has been
+// registered with another discriminator.
+func RegisterImplementations(iface interface{}, discriminator string, impls ...Implementation) {
+	t := reflect.TypeOf(iface)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+		panic(fmt.Sprintf("%v is not a pointer to interface", t))
+	}
+	t = t.Elem()
+
+	set, ok := implementations[t]
+	if !ok {
+		set = &implementationSet{discriminator: discriminator}
+	} else if set.discriminator != discriminator {
+		panic(fmt.Sprintf("%s is registered with both discriminators %s and %s", t, set.discriminator, discriminator))
+	}
+
+	for _, impl := range impls {
+		implType := impl.Type
+		if implType.Kind() == reflect.Ptr {
+			implType = implType.Elem()
+		}
+		if implType.Kind() != reflect.Struct {
+			panic(fmt.Sprintf("implementation %s of %s is not a struct", impl.Name, t))
+		}
+		if !implType.Implements(t) && !reflect.PtrTo(implType).Implements(t) {
+			panic(fmt.Sprintf("implementation %s of %s doesn't implement it", impl.Name, t))
+		}
+
+		impl.Type = implType
+		set.impls = append(set.impls, impl)
+	}
+
+	sort.SliceStable(set.impls, func(i, j int) bool {
+		return set.impls[i].Name < set.impls[j].Name
+	})
+	implementations[t] = set
+}
+
+// getImplementations returns the registered implementations of the interface type t, if any.
+func getImplementations(t reflect.Type) (*implementationSet, bool) {
+	if t.Kind() != reflect.Interface {
+		return nil, false
+	}
+	set, ok := implementations[t]
+	return set, ok
+}
+
+// implementationsBlock adds to block the entry of the interface field, as a block holding the
+// discriminator field followed by the fields of each implementation, and returns the other
+// blocks found in them.
+func implementationsBlock(block *ConfigBlock, field reflect.StructField, fieldName string, set *implementationSet, rootBlocks []RootBlock, opts Options) ([]*ConfigBlock, error) {
+	desc := getFieldDescription(field, "")
+	fieldBlock := &ConfigBlock{
+		Name:     fieldName,
+		Desc:     desc,
+		Category: getFieldCategory(field, ""),
+	}
+
+	var categoryInherited bool
+	if fieldBlock.Category == "" && block.Category != "" {
+		fieldBlock.Category = block.Category
+		categoryInherited = true
+	}
+
+	block.Add(&ConfigEntry{
+		Kind:      KindBlock,
+		Name:      fieldName,
+		Required:  isFieldRequired(field),
+		Block:     fieldBlock,
+		BlockDesc: desc,
+		OmitEmpty: parseYAMLTag(field).omitEmpty,
+		GoType:    field.Type.String(),
+
+		CategoryInherited: categoryInherited,
+	})
+
+	names := make([]string, 0, len(set.impls))
+	for _, impl := range set.impls {
+		names = append(names, impl.Name)
+	}
+	fieldBlock.Add(&ConfigEntry{
+		Kind:          KindField,
+		Name:          set.discriminator,
+		Required:      true,
+		NoCLI:         true,
+		FieldDesc:     fmt.Sprintf("The implementation to use. Supported values: %s.", strings.Join(names, ", ")),
+		FieldType:     "string",
+		FieldTypeSpec: scalarType("string"),
+		FieldCategory: fieldBlock.Category,
+
+		CategoryInherited: fieldBlock.Category != "",
+	})
+
+	var blocks []*ConfigBlock
+	for _, impl := range set.impls {
+		implBlock := &ConfigBlock{
+			Name:     impl.Name,
+			Category: fieldBlock.Category,
+		}
+
+		// The implementations aren't held by the config, so the defaults of their fields
+		// are read from the CLI flags they register, if any, on an instance of their own.
+		implValue := reflect.New(impl.Type).Interface()
+		implFlags, registered := implementationFlags(implValue)
+
+		otherBlocks, err := config(implBlock, implValue, implFlags, rootBlocks, opts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "implementation=%s", impl.Name)
+		}
+		blocks = append(blocks, otherBlocks...)
+
+		selector := set.discriminator + "=" + impl.Name
+		for _, entry := range implBlock.Entries {
+			entry.InlinedFrom = append([]string{impl.Name}, entry.InlinedFrom...)
+			setImplementationEntry(entry, selector, registered)
+			fieldBlock.Add(entry)
+		}
+		if impl.Desc != "" {
+			if fieldBlock.InlinedDescs == nil {
+				fieldBlock.InlinedDescs = map[string]string{}
+			}
+			fieldBlock.InlinedDescs[impl.Name] = impl.Desc
+		}
+	}
+	return blocks, nil
+}
+
+// implementationFlags returns the CLI flags registered by the implementation cfg, and whether
+// it registers any. They're only used to read the defaults, since the application doesn't
+// register them.
+func implementationFlags(cfg interface{}) (map[uintptr][]*flag.Flag, bool) {
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	switch r := cfg.(type) {
+	case flagext.Registerer:
+		r.RegisterFlags(fs)
+	case flagext.RegistererWithLogger:
+		r.RegisterFlags(fs, log.NewNopLogger())
+	default:
+		return nil, false
+	}
+	return FlagsFromSet(fs), true
+}
+
+// setImplementationEntry marks the entry, and the entries of its blocks, as only applying when
+// the selector chooses their implementation. Their CLI flags are cleared, since they aren't
+// registered by the application, and so are their defaults if the implementation doesn't
+// register CLI flags, since they would be the zero values rather than the defaults.
+func setImplementationEntry(entry *ConfigEntry, selector string, registered bool) {
+	entry.FieldSelector = selector
+	entry.FieldFlag = ""
+	entry.FieldFlagAlternates = nil
+	if !registered && entry.Kind != KindBlock {
+		entry.NoDefault = true
+		entry.FieldDefault = ""
+		entry.FieldRawDefault = ""
+	}
+
+	for _, b := range []*ConfigBlock{entry.Block, entry.Element} {
+		if b == nil || entry.Root {
+			continue
+		}
+		for _, e := range b.Entries {
+			setImplementationEntry(e, selector, registered)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testBackend interface {
+	Open() error
+}
+
+type testS3Backend struct {
+	Bucket   string `yaml:"bucket" doc:"required"`
+	Endpoint string `yaml:"endpoint"`
+}
+
+func (testS3Backend) Open() error { return nil }
+
+func (b *testS3Backend) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&b.Bucket, "s3.bucket", "", "The bucket.")
+	fs.StringVar(&b.Endpoint, "s3.endpoint", "s3.amazonaws.com", "The endpoint.")
+}
+
+type testFilesystemBackend struct {
+	Dir      string `yaml:"dir"`
+	Endpoint string `yaml:"endpoint" doc:"description=Unused."`
+}
+
+func (*testFilesystemBackend) Open() error { return nil }
+
+type testStorageConfig struct {
+	Backend testBackend `yaml:"backend" category:"advanced" doc:"description=The storage backend."`
+}
+
+func TestConfig_InterfaceImplementations(t *testing.T) {
+	RegisterImplementations((*testBackend)(nil), "type",
+		Implementation{Name: "s3", Desc: "The S3 backend.", Type: reflect.TypeOf(testS3Backend{})},
+		Implementation{Name: "filesystem", Desc: "The filesystem backend.", Type: reflect.TypeOf(&testFilesystemBackend{})},
+	)
+
+	blocks, err := Config(&testStorageConfig{}, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+
+	entries := blocks[0].Entries
+	require.Len(t, entries, 1)
+
+	// The interface field is a block holding the discriminator field, followed by the fields
+	// of each implementation, sorted by name.
+	backend := entries[0]
+	assert.Equal(t, KindBlock, backend.Kind)
+	assert.Equal(t, "backend", backend.Name)
+	assert.Equal(t, "The storage backend.", backend.BlockDesc)
+	assert.Equal(t, "advanced", backend.Block.Category)
+	assert.Equal(t, map[string]string{"s3": "The S3 backend.", "filesystem": "The filesystem backend."}, backend.Block.InlinedDescs)
+	require.Len(t, backend.Block.Entries, 5)
+
+	discriminator := backend.Block.Entries[0]
+	assert.Equal(t, "type", discriminator.Name)
+	assert.True(t, discriminator.Required)
+	assert.Equal(t, "string", discriminator.FieldType)
+	assert.Equal(t, "The implementation to use. Supported values: filesystem, s3.", discriminator.Description())
+	assert.True(t, discriminator.CategoryInherited)
+
+	// The filesystem backend doesn't register CLI flags, so the defaults of its fields aren't known.
+	dir := backend.Block.Entries[1]
+	assert.Equal(t, "dir", dir.Name)
+	assert.Equal(t, []string{"filesystem"}, dir.InlinedFrom)
+	assert.Equal(t, "advanced", dir.FieldCategory)
+	assert.Equal(t, "type=filesystem", dir.FieldSelector)
+	assert.True(t, dir.NoDefault)
+	assert.Equal(t, "", dir.FieldDefault)
+	assert.Equal(t, " (only when type=filesystem)", dir.Description())
+
+	// The fields of different implementations may have the same name.
+	fsEndpoint := backend.Block.Entries[2]
+	assert.Equal(t, "endpoint", fsEndpoint.Name)
+	assert.Equal(t, "Unused. (only when type=filesystem)", fsEndpoint.Description())
+
+	// The S3 backend registers CLI flags, which only provide the defaults and the descriptions.
+	bucket := backend.Block.Entries[3]
+	assert.Equal(t, "bucket", bucket.Name)
+	assert.True(t, bucket.Required)
+	assert.False(t, bucket.NoDefault)
+	assert.Equal(t, "", bucket.FieldFlag)
+	assert.Equal(t, "The bucket. (only when type=s3)", bucket.Description())
+
+	s3Endpoint := backend.Block.Entries[4]
+	assert.Equal(t, "endpoint", s3Endpoint.Name)
+	assert.Equal(t, []string{"s3"}, s3Endpoint.InlinedFrom)
+	assert.Equal(t, "s3.amazonaws.com", s3Endpoint.FieldDefault)
+	assert.Equal(t, "", s3Endpoint.FieldFlag)
+	assert.Equal(t, "type=s3", s3Endpoint.FieldSelector)
+
+	data, err := MarshalJSON(blocks)
+	require.NoError(t, err)
+	actual, err := UnmarshalJSON(data)
+	require.NoError(t, err)
+	assert.Equal(t, blocks, actual)
+}
+
+type testQueue interface {
+	Push() error
+}
+
+type testKafkaQueue struct {
+	Type string `yaml:"type"`
+}
+
+func (testKafkaQueue) Push() error { return nil }
+
+func TestConfig_InterfaceImplementationsCollision(t *testing.T) {
+	RegisterImplementations((*testQueue)(nil), "type",
+		Implementation{Name: "kafka", Type: reflect.TypeOf(testKafkaQueue{})},
+	)
+
+	// The fields of an implementation can't have the name of the discriminator.
+	_, err := Config(&struct {
+		Queue testQueue `yaml:"queue"`
+	}{}, nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field name type is used by both a field of the struct and a field of the implementation selected by type=kafka")
+}
+
+type testUnregisteredBackend interface {
+	Close() error
+}
+
+func TestConfig_InterfaceWithoutImplementations(t *testing.T) {
+	_, err := Config(&struct {
+		Backend testUnregisteredBackend `yaml:"backend"`
+	}{}, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestRegisterImplementations_Invalid(t *testing.T) {
+	assert.PanicsWithValue(t, "parse.testS3Backend is not a pointer to interface", func() {
+		RegisterImplementations(testS3Backend{}, "type")
+	})
+	assert.PanicsWithValue(t, "implementation s3 of parse.testUnregisteredBackend doesn't implement it", func() {
+		RegisterImplementations((*testUnregisteredBackend)(nil), "type", Implementation{Name: "s3", Type: reflect.TypeOf(testS3Backend{})})
+	})
+	assert.PanicsWithValue(t, "implementation name of parse.testBackend is not a struct", func() {
+		RegisterImplementations((*testBackend)(nil), "type", Implementation{Name: "name", Type: reflect.TypeOf("")})
+	})
+	assert.PanicsWithValue(t, "parse.testBackend is registered with both discriminators type and kind", func() {
+		RegisterImplementations((*testBackend)(nil), "kind")
+	})
+}
@@ -0,0 +1,375 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// JSONVersion is the version of the JSON document generated by MarshalJSON.
+// It must be increased on every backward incompatible change of the format.
+const JSONVersion = 1
+
+const jsonRefPrefix = "#/blocks/"
+
+type jsonDocument struct {
+	Version int          `json:"version"`
+	Blocks  []*jsonBlock `json:"blocks"`
+}
+
+// jsonBlock is either a block or, when Ref is set, a reference to one of
+// the top-level blocks of the document.
+type jsonBlock struct {
+	Ref           string            `json:"$ref,omitempty"`
+	Name          string            `json:"name,omitempty"`
+	Desc          string            `json:"desc,omitempty"`
+	Entries       []*jsonEntry      `json:"entries,omitempty"`
+	FlagsPrefix   string            `json:"flagsPrefix,omitempty"`
+	FlagsPrefixes []string          `json:"flagsPrefixes,omitempty"`
+	InlinedDescs  map[string]string `json:"inlinedDescs,omitempty"`
+	Category      string            `json:"category,omitempty"`
+}
+
+type jsonEntry struct {
+	Kind      EntryKind `json:"kind"`
+	Name      string    `json:"name"`
+	Required  bool      `json:"required"`
+	GoType    string    `json:"goType,omitempty"`
+	PkgPath   string    `json:"pkgPath,omitempty"`
+	OmitEmpty bool      `json:"omitEmpty,omitempty"`
+	NoCLI     bool      `json:"noCli,omitempty"`
+	Alias     bool      `json:"alias,omitempty"`
+	AliasesOf []string  `json:"aliasesOf,omitempty"`
+
+	Block     *jsonBlock `json:"block,omitempty"`
+	BlockDesc string     `json:"blockDesc,omitempty"`
+	Root      bool       `json:"root,omitempty"`
+	RefBlock  string     `json:"refBlock,omitempty"`
+
+	FieldFlag           string       `json:"fieldFlag,omitempty"`
+	FieldFlagAlternates []string     `json:"fieldFlagAlternates,omitempty"`
+	FieldDesc           string       `json:"fieldDesc,omitempty"`
+	FieldType           string       `json:"fieldType,omitempty"`
+	FieldOptional       bool         `json:"fieldOptional,omitempty"`
+	FieldDefault        string       `json:"fieldDefault,omitempty"`
+	FieldRawDefault     string       `json:"fieldRawDefault,omitempty"`
+	FieldExample        *jsonExample `json:"fieldExample,omitempty"`
+	FieldCategory       string       `json:"fieldCategory,omitempty"`
+	CategoryInherited   bool         `json:"categoryInherited,omitempty"`
+
+	FieldSentinels   map[string]string `json:"fieldSentinels,omitempty"`
+	FieldZeroMeaning string            `json:"fieldZeroMeaning,omitempty"`
+	FieldFeature     string            `json:"fieldFeature,omitempty"`
+	FieldWarnings    []string          `json:"fieldWarnings,omitempty"`
+	FieldBits        []jsonBitFlag     `json:"fieldBits,omitempty"`
+	FieldMin         *float64          `json:"fieldMin,omitempty"`
+	FieldMax         *float64          `json:"fieldMax,omitempty"`
+	FieldUnit        string            `json:"fieldUnit,omitempty"`
+
+	DeprecatedInFavorOf string   `json:"deprecatedInFavorOf,omitempty"`
+	FieldConflictsWith  []string `json:"fieldConflictsWith,omitempty"`
+	FieldReloadable     bool     `json:"fieldReloadable,omitempty"`
+	StartupOnly         bool     `json:"startupOnly,omitempty"`
+	FieldSecret         bool     `json:"fieldSecret,omitempty"`
+	NoDefault           bool     `json:"noDefault,omitempty"`
+	FieldSelector       string   `json:"fieldSelector,omitempty"`
+
+	FieldDefaultChanges []jsonDefaultChange `json:"fieldDefaultChanges,omitempty"`
+
+	Element     *jsonBlock `json:"element,omitempty"`
+	InlinedFrom []string   `json:"inlinedFrom,omitempty"`
+}
+
+type jsonBitFlag struct {
+	Value uint64 `json:"value"`
+	Name  string `json:"name"`
+}
+
+type jsonDefaultChange struct {
+	Version string `json:"version"`
+	Old     string `json:"old"`
+}
+
+type jsonExample struct {
+	Comment         string   `json:"comment,omitempty"`
+	Yaml            string   `json:"yaml"`
+	ElementComments []string `json:"elementComments,omitempty"`
+}
+
+// MarshalJSON returns the JSON document of the input blocks, as returned by Config.
+// Blocks referenced by entries which are part of the input blocks (like root blocks)
+// are not duplicated but referenced through a "$ref" to their position in the document.
+func MarshalJSON(blocks []*ConfigBlock) ([]byte, error) {
+	refs := make(map[*ConfigBlock]int, len(blocks))
+	for i, block := range blocks {
+		if _, ok := refs[block]; !ok {
+			refs[block] = i
+		}
+	}
+
+	doc := jsonDocument{Version: JSONVersion}
+	for _, block := range blocks {
+		b, err := toJSONBlock(block, refs)
+		if err != nil {
+			return nil, err
+		}
+		doc.Blocks = append(doc.Blocks, b)
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func toJSONBlock(block *ConfigBlock, refs map[*ConfigBlock]int) (*jsonBlock, error) {
+	b := &jsonBlock{
+		Name:          block.Name,
+		Desc:          block.Desc,
+		FlagsPrefix:   block.FlagsPrefix,
+		FlagsPrefixes: block.FlagsPrefixes,
+		InlinedDescs:  block.InlinedDescs,
+		Category:      block.Category,
+	}
+
+	for _, entry := range block.Entries {
+		e := &jsonEntry{
+			Kind:          entry.Kind,
+			Name:          entry.Name,
+			Required:      entry.Required,
+			GoType:        entry.GoType,
+			PkgPath:       entry.PkgPath,
+			OmitEmpty:     entry.OmitEmpty,
+			NoCLI:         entry.NoCLI,
+			Alias:         entry.Alias,
+			AliasesOf:     entry.AliasesOf,
+			BlockDesc:     entry.BlockDesc,
+			Root:          entry.Root,
+			RefBlock:      entry.RefBlock,
+			FieldFlag:     entry.FieldFlag,
+			FieldDesc:     entry.FieldDesc,
+			FieldType:     entry.FieldType,
+			FieldDefault:  entry.FieldDefault,
+			FieldCategory: entry.FieldCategory,
+			InlinedFrom:   entry.InlinedFrom,
+
+			FieldRawDefault:     entry.FieldRawDefault,
+			FieldFlagAlternates: entry.FieldFlagAlternates,
+			CategoryInherited:   entry.CategoryInherited,
+			FieldSentinels:      entry.FieldSentinels,
+			FieldZeroMeaning:    entry.FieldZeroMeaning,
+			FieldFeature:        entry.FieldFeature,
+			FieldWarnings:       entry.FieldWarnings,
+			FieldMin:            entry.FieldMin,
+			FieldMax:            entry.FieldMax,
+			FieldUnit:           entry.FieldUnit,
+			DeprecatedInFavorOf: entry.DeprecatedInFavorOf,
+			FieldConflictsWith:  entry.FieldConflictsWith,
+			FieldReloadable:     entry.FieldReloadable,
+			StartupOnly:         entry.StartupOnly,
+			FieldSecret:         entry.FieldSecret,
+			NoDefault:           entry.NoDefault,
+			FieldSelector:       entry.FieldSelector,
+		}
+
+		if entry.FieldTypeSpec != nil {
+			e.FieldOptional = entry.FieldTypeSpec.Optional
+		}
+		for _, bit := range entry.FieldBits {
+			e.FieldBits = append(e.FieldBits, jsonBitFlag{Value: bit.Value, Name: bit.Name})
+		}
+		for _, change := range entry.FieldDefaultChanges {
+			e.FieldDefaultChanges = append(e.FieldDefaultChanges, jsonDefaultChange{Version: change.Version, Old: change.Old})
+		}
+
+		var err error
+		if e.Block, err = toJSONBlockRef(entry.Block, refs); err != nil {
+			return nil, err
+		}
+		if e.Element, err = toJSONBlockRef(entry.Element, refs); err != nil {
+			return nil, err
+		}
+
+		if entry.FieldExample != nil {
+			data, err := yaml.Marshal(entry.FieldExample.Yaml)
+			if err != nil {
+				return nil, errors.Wrapf(err, "can't marshal example of %s", entry.Name)
+			}
+			e.FieldExample = &jsonExample{Comment: entry.FieldExample.Comment, Yaml: string(data), ElementComments: entry.FieldExample.ElementComments}
+		}
+
+		b.Entries = append(b.Entries, e)
+	}
+
+	return b, nil
+}
+
+func toJSONBlockRef(block *ConfigBlock, refs map[*ConfigBlock]int) (*jsonBlock, error) {
+	if block == nil {
+		return nil, nil
+	}
+	if i, ok := refs[block]; ok {
+		return &jsonBlock{Ref: jsonRefPrefix + strconv.Itoa(i)}, nil
+	}
+
+	return toJSONBlock(block, refs)
+}
+
+// UnmarshalJSON parses a JSON document generated by MarshalJSON back into blocks.
+func UnmarshalJSON(data []byte) ([]*ConfigBlock, error) {
+	return unmarshalJSON(data, false)
+}
+
+// LoadBlocks reads a JSON document generated by MarshalJSON, possibly by an older version of
+// the format, and returns its blocks, like Config would, so that they can be written, linted or
+// compared without parsing the config through reflection. Unknown properties are ignored, while
+// documents without version or generated by a newer version of the format are rejected.
+func LoadBlocks(r io.Reader) ([]*ConfigBlock, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't read the config blocks")
+	}
+
+	blocks, err := unmarshalJSON(data, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't load the config blocks")
+	}
+	return blocks, nil
+}
+
+// unmarshalJSON parses a JSON document generated by MarshalJSON. When lenient, documents
+// generated by older versions are accepted too: properties missing in them are left empty,
+// and unknown properties are ignored. Documents without version are always rejected.
+func unmarshalJSON(data []byte, lenient bool) ([]*ConfigBlock, error) {
+	doc := jsonDocument{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	// Versions start at 1, so that a document without version, like {}, isn't mistaken for an empty one.
+	minVersion := JSONVersion
+	if lenient {
+		minVersion = 1
+	}
+	if doc.Version < minVersion || doc.Version > JSONVersion {
+		return nil, fmt.Errorf("unsupported version %d, expected %d", doc.Version, JSONVersion)
+	}
+
+	// Blocks are allocated upfront, so that references can be resolved regardless of their position.
+	blocks := make([]*ConfigBlock, 0, len(doc.Blocks))
+	for range doc.Blocks {
+		blocks = append(blocks, &ConfigBlock{})
+	}
+
+	for i, b := range doc.Blocks {
+		if err := fromJSONBlock(b, blocks[i], blocks); err != nil {
+			return nil, err
+		}
+	}
+
+	return blocks, nil
+}
+
+func fromJSONBlock(b *jsonBlock, block *ConfigBlock, blocks []*ConfigBlock) error {
+	block.Name = b.Name
+	block.Desc = b.Desc
+	block.FlagsPrefix = b.FlagsPrefix
+	block.FlagsPrefixes = b.FlagsPrefixes
+	block.InlinedDescs = b.InlinedDescs
+	block.Category = b.Category
+
+	for _, e := range b.Entries {
+		entry := &ConfigEntry{
+			Kind:          e.Kind,
+			Name:          e.Name,
+			Required:      e.Required,
+			GoType:        e.GoType,
+			PkgPath:       e.PkgPath,
+			OmitEmpty:     e.OmitEmpty,
+			NoCLI:         e.NoCLI,
+			Alias:         e.Alias,
+			AliasesOf:     e.AliasesOf,
+			BlockDesc:     e.BlockDesc,
+			Root:          e.Root,
+			RefBlock:      e.RefBlock,
+			FieldFlag:     e.FieldFlag,
+			FieldDesc:     e.FieldDesc,
+			FieldType:     e.FieldType,
+			FieldDefault:  e.FieldDefault,
+			FieldCategory: e.FieldCategory,
+			InlinedFrom:   e.InlinedFrom,
+
+			FieldRawDefault:     e.FieldRawDefault,
+			FieldFlagAlternates: e.FieldFlagAlternates,
+			CategoryInherited:   e.CategoryInherited,
+			FieldSentinels:      e.FieldSentinels,
+			FieldZeroMeaning:    e.FieldZeroMeaning,
+			FieldFeature:        e.FieldFeature,
+			FieldWarnings:       e.FieldWarnings,
+			FieldMin:            e.FieldMin,
+			FieldMax:            e.FieldMax,
+			FieldUnit:           e.FieldUnit,
+			DeprecatedInFavorOf: e.DeprecatedInFavorOf,
+			FieldConflictsWith:  e.FieldConflictsWith,
+			FieldReloadable:     e.FieldReloadable,
+			StartupOnly:         e.StartupOnly,
+			FieldSecret:         e.FieldSecret,
+			NoDefault:           e.NoDefault,
+			FieldSelector:       e.FieldSelector,
+		}
+
+		for _, bit := range e.FieldBits {
+			entry.FieldBits = append(entry.FieldBits, BitFlag{Value: bit.Value, Name: bit.Name})
+		}
+		for _, change := range e.FieldDefaultChanges {
+			entry.FieldDefaultChanges = append(entry.FieldDefaultChanges, DefaultChange{Version: change.Version, Old: change.Old})
+		}
+		if e.FieldType != "" {
+			entry.FieldTypeSpec = ParseTypeSpec(e.FieldType)
+			entry.FieldTypeSpec.Optional = e.FieldOptional
+		}
+
+		var err error
+		if entry.Block, err = fromJSONBlockRef(e.Block, blocks); err != nil {
+			return errors.Wrapf(err, "block of %s", e.Name)
+		}
+		if entry.Element, err = fromJSONBlockRef(e.Element, blocks); err != nil {
+			return errors.Wrapf(err, "element of %s", e.Name)
+		}
+
+		if e.FieldExample != nil {
+			var yml interface{}
+			if err := yaml.Unmarshal([]byte(e.FieldExample.Yaml), &yml); err != nil {
+				return errors.Wrapf(err, "can't unmarshal example of %s", e.Name)
+			}
+			entry.FieldExample = &FieldExample{Comment: e.FieldExample.Comment, Yaml: yml, ElementComments: e.FieldExample.ElementComments}
+		}
+
+		block.Add(entry)
+	}
+
+	return nil
+}
+
+func fromJSONBlockRef(ref *jsonBlock, blocks []*ConfigBlock) (*ConfigBlock, error) {
+	if ref == nil {
+		return nil, nil
+	}
+
+	if ref.Ref != "" {
+		i, err := strconv.Atoi(strings.TrimPrefix(ref.Ref, jsonRefPrefix))
+		if err != nil || !strings.HasPrefix(ref.Ref, jsonRefPrefix) || i < 0 || i >= len(blocks) {
+			return nil, fmt.Errorf("invalid block reference %q", ref.Ref)
+		}
+		return blocks[i], nil
+	}
+
+	block := &ConfigBlock{}
+	if err := fromJSONBlock(ref, block, blocks); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
@@ -0,0 +1,215 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type jsonExampleTargets []string
+
+func (jsonExampleTargets) ExampleDoc() (comment string, yaml interface{}) {
+	return "Scrape two targets.", []interface{}{"a", "b"}
+}
+
+func TestMarshalJSON_RoundTrip(t *testing.T) {
+	type RootConfig struct {
+		Address string `yaml:"address" doc:"required"`
+	}
+
+	type InlineConfig struct {
+		Inlined string `yaml:"inlined" category:"advanced" doc:"feature=netgo"`
+	}
+
+	type SubConfig struct {
+		Name string `yaml:"name"`
+	}
+
+	type config struct {
+		InlineConfig `yaml:",inline" doc:"description=The inline options."`
+		Root         RootConfig `yaml:"root"`
+		Other        RootConfig `yaml:"other"`
+		Nested       struct {
+			Enabled bool   `yaml:"enabled"`
+			Secret  string `yaml:"secret" doc:"nocli"`
+		} `yaml:"nested" category:"experimental" doc:"description=The nested block."`
+		Subs    []SubConfig        `yaml:"subs"`
+		Example jsonExampleTargets `yaml:"example"`
+		Mask    uint64             `yaml:"mask" doc:"bits=1:foo,2:bar"`
+		Shards  int                `yaml:"shards" doc:"min=1|max=64"`
+		Token   flagext.Secret     `yaml:"token"`
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.StringVar(&cfg.Inlined, "inlined", "value", "An inlined option.")
+	fs.StringVar(&cfg.Root.Address, "root.address", "localhost", "The address.")
+	fs.StringVar(&cfg.Other.Address, "other.address", "localhost", "The address.")
+	fs.BoolVar(&cfg.Nested.Enabled, "nested.enabled", true, "Whether it's enabled.")
+	fs.Uint64Var(&cfg.Mask, "mask", 2, "The mask.")
+	fs.IntVar(&cfg.Shards, "shards", 16, "The number of shards.")
+	fs.Var(&cfg.Token, "token", "The token.")
+
+	rootBlocks := []RootBlock{{Name: "root_config", Desc: "The root_config block.", StructType: reflect.TypeOf(RootConfig{})}}
+	blocks, err := Config(cfg, testFlags(fs), rootBlocks)
+	require.NoError(t, err)
+	require.Len(t, blocks, 4)
+
+	data, err := MarshalJSON(blocks)
+	require.NoError(t, err)
+
+	// Root blocks are referenced rather than duplicated, and examples are embedded as YAML.
+	doc := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, float64(JSONVersion), doc["version"])
+	topEntries := doc["blocks"].([]interface{})[0].(map[string]interface{})["entries"].([]interface{})
+	assert.Equal(t, map[string]interface{}{"$ref": "#/blocks/1"}, topEntries[1].(map[string]interface{})["block"])
+	assert.Equal(t, map[string]interface{}{"$ref": "#/blocks/2"}, topEntries[2].(map[string]interface{})["block"])
+	assert.Equal(t, map[string]interface{}{"comment": "Scrape two targets.", "yaml": "example:\n    - a\n    - b\n"}, topEntries[5].(map[string]interface{})["fieldExample"])
+	assert.Equal(t, true, topEntries[8].(map[string]interface{})["fieldSecret"])
+
+	actual, err := UnmarshalJSON(data)
+	require.NoError(t, err)
+	assert.Equal(t, blocks, actual)
+
+	// References are resolved to the same block instance.
+	assert.Same(t, actual[1], actual[0].Entries[1].Block)
+	assert.Same(t, actual[3], actual[0].Entries[4].Element)
+}
+
+func TestUnmarshalJSON_Errors(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected string
+	}{
+		"unsupported version": {
+			input:    `{"version": 2, "blocks": []}`,
+			expected: "unsupported version 2, expected 1",
+		},
+		"missing version": {
+			input:    `{}`,
+			expected: "unsupported version 0, expected 1",
+		},
+		"invalid reference": {
+			input:    `{"version": 1, "blocks": [{"name": "", "entries": [{"kind": "block", "name": "foo", "block": {"$ref": "#/blocks/1"}}]}]}`,
+			expected: `block of foo: invalid block reference "#/blocks/1"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := UnmarshalJSON([]byte(test.input))
+			assert.EqualError(t, err, test.expected)
+		})
+	}
+}
+
+func TestLoadBlocks_RoundTrip(t *testing.T) {
+	type RootConfig struct {
+		Address string `yaml:"address" doc:"required"`
+	}
+
+	type config struct {
+		Root     RootConfig         `yaml:"root"`
+		Shards   int                `yaml:"shards" category:"advanced" doc:"min=1|max=64|sentinel=1:no sharding"`
+		Timeout  time.Duration      `yaml:"timeout" doc:"zero=no timeout|default_changed=2.5:1m|reloadable"`
+		Local    bool               `yaml:"local" doc:"conflicts_with=remote|warning=Not for production."`
+		Remote   string             `yaml:"remote"`
+		Subs     []RootConfig       `yaml:"subs"`
+		Example  jsonExampleTargets `yaml:"example"`
+		Mask     uint64             `yaml:"mask" doc:"bits=1:foo,2:bar"`
+		Disabled []string           `yaml:"disabled" category:"experimental" doc:"feature=netgo"`
+		Ratio    *float64           `yaml:"ratio" doc:"unit=percent"`
+		MaxBytes uint64             `yaml:"max_bytes" doc:"unit=bytes"`
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.StringVar(&cfg.Root.Address, "root.address", "localhost", "The address.")
+	fs.IntVar(&cfg.Shards, "shards", 1, "The number of shards.")
+	fs.DurationVar(&cfg.Timeout, "timeout", time.Minute, "The timeout.")
+	fs.BoolVar(&cfg.Local, "local", false, "Whether it's local.")
+	fs.StringVar(&cfg.Remote, "remote", "", "The remote.")
+	fs.Uint64Var(&cfg.Mask, "mask", 1, "The mask.")
+	fs.Uint64Var(&cfg.MaxBytes, "max-bytes", 1048576, "The maximum size.")
+
+	rootBlocks := []RootBlock{{Name: "root_config", Desc: "The root_config block.", StructType: reflect.TypeOf(RootConfig{})}}
+	blocks, err := Config(cfg, testFlags(fs), rootBlocks)
+	require.NoError(t, err)
+
+	exported, err := MarshalJSON(blocks)
+	require.NoError(t, err)
+
+	loaded, err := LoadBlocks(bytes.NewReader(exported))
+	require.NoError(t, err)
+	assert.Equal(t, blocks, loaded)
+
+	reexported, err := MarshalJSON(loaded)
+	require.NoError(t, err)
+	assert.Equal(t, string(exported), string(reexported))
+
+	// The loaded blocks can be linted and compared like the parsed ones.
+	assert.Empty(t, ValidateConflicts(loaded))
+	assert.True(t, DiffConfig(blocks, loaded).Empty())
+	assert.Equal(t, "(advanced) The number of shards. (1 = no sharding) (must be between 1 and 64)", indexEntriesByPath(loaded)["shards"].Description())
+}
+
+func TestLoadBlocks_UnknownProperties(t *testing.T) {
+	// Properties added by newer versions of the format, without a version change, are ignored.
+	input := `{
+		"version": 1,
+		"generator": "future",
+		"blocks": [{
+			"name": "",
+			"owner": "team-a",
+			"entries": [{"kind": "field", "name": "address", "fieldType": "string", "fieldDefault": "localhost", "fieldUnit": "host"}]
+		}]
+	}`
+
+	blocks, err := LoadBlocks(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+	require.Len(t, blocks[0].Entries, 1)
+
+	entry := blocks[0].Entries[0]
+	assert.Equal(t, "address", entry.Name)
+	assert.Equal(t, "localhost", entry.FieldDefault)
+	assert.Equal(t, scalarType("string"), entry.FieldTypeSpec)
+}
+
+func TestLoadBlocks_Errors(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected string
+	}{
+		"newer version": {
+			input:    `{"version": 2, "blocks": []}`,
+			expected: "can't load the config blocks: unsupported version 2, expected 1",
+		},
+		"missing version": {
+			input:    `{}`,
+			expected: "can't load the config blocks: unsupported version 0, expected 1",
+		},
+		"invalid document": {
+			input:    `{"version": 1, "blocks": {}}`,
+			expected: "can't load the config blocks: json: cannot unmarshal object into Go struct field jsonDocument.blocks of type []*parse.jsonBlock",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadBlocks(strings.NewReader(test.input))
+			assert.EqualError(t, err, test.expected)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// MinimalYAML returns the smallest YAML config which reproduces cfg, which must be a pointer to
+// struct, like the one to attach to a bug report. Fields registered as CLI flags, as returned by
+// Flags, are included only if their value differs from the default value of the flag, while the
+// other fields are included only if their value isn't the zero value of their type.
+func MinimalYAML(cfg interface{}, flags map[uintptr][]*flag.Flag) ([]byte, error) {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s is not a pointer to struct", v.Type())
+	}
+
+	node, err := minimalNode(v.Elem(), flags)
+	if err != nil {
+		return nil, err
+	}
+	if len(node.Content) == 0 {
+		return []byte("{}\n"), nil
+	}
+
+	return yaml.Marshal(node)
+}
+
+// minimalNode returns the YAML mapping of the fields of the struct v which aren't set to their default.
+func minimalNode(v reflect.Value, flags map[uintptr][]*flag.Flag) (*yaml.Node, error) {
+	t := v.Type()
+	node := &yaml.Node{Kind: yaml.MappingNode}
+
+	fields, err := structFields(t, v, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "config=%s.%s", t.PkgPath(), t.Name())
+	}
+
+	for _, f := range fields {
+		field, fieldValue := f.field, f.value
+
+		// Skip the fields which can't be configured, like Config does.
+		fieldName := getFieldName(field)
+		if fieldName == "" && !isFieldInline(field) {
+			continue
+		}
+		if field.Type.Kind() == reflect.Func || strings.HasPrefix(field.Name, "UnusedFlag") || !fieldValue.CanInterface() {
+			continue
+		}
+
+		// Recurse into structs which aren't custom types nor flag values, looking through pointers.
+		// They're checked before the flags, because a struct has the same address as its first field.
+		structValue := fieldValue
+		if structValue.Kind() == reflect.Ptr && structValue.Type().Elem().Kind() == reflect.Struct {
+			if structValue.IsNil() {
+				continue
+			}
+			structValue = structValue.Elem()
+		}
+		_, custom := getFieldCustomType(field.Type)
+		_, isFlagValue := structValue.Addr().Interface().(flag.Value)
+		if structValue.Kind() == reflect.Struct && !custom && !isFlagValue {
+			sub, err := minimalNode(structValue, flags)
+			if err != nil {
+				return nil, err
+			}
+
+			if isFieldInline(field) {
+				node.Content = append(node.Content, sub.Content...)
+			} else if len(sub.Content) > 0 {
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: fieldName}, sub)
+			}
+			continue
+		}
+
+		if fieldFlags := flags[fieldValue.Addr().Pointer()]; len(fieldFlags) > 0 {
+			if fieldFlags[0].Value.String() == fieldFlags[0].DefValue {
+				continue
+			}
+		} else if fieldValue.IsZero() {
+			continue
+		}
+		if err := appendMinimalValue(node, fieldName, fieldValue); err != nil {
+			return nil, err
+		}
+	}
+
+	return node, nil
+}
+
+func appendMinimalValue(node *yaml.Node, name string, v reflect.Value) error {
+	value := &yaml.Node{}
+	if err := value.Encode(v.Interface()); err != nil {
+		return errors.Wrapf(err, "field %s: can't encode value", name)
+	}
+
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, value)
+	return nil
+}
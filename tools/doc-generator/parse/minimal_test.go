@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinimalYAML(t *testing.T) {
+	type InlineConfig struct {
+		Inlined string `yaml:"inlined"`
+	}
+
+	type config struct {
+		InlineConfig `yaml:",inline"`
+		Address      string `yaml:"address"`
+		Port         int    `yaml:"port"`
+		Server       struct {
+			Timeout time.Duration `yaml:"timeout"`
+			Enabled bool          `yaml:"enabled"`
+		} `yaml:"server"`
+		Untouched struct {
+			Enabled bool `yaml:"enabled"`
+		} `yaml:"untouched"`
+		Optional *struct {
+			Name string `yaml:"name"`
+		} `yaml:"optional"`
+		Labels  map[string]string `yaml:"labels"`
+		Targets []string          `yaml:"targets"`
+		Hidden  string            `yaml:"-"`
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.StringVar(&cfg.Inlined, "inlined", "value", "An inlined option.")
+	fs.StringVar(&cfg.Address, "address", "localhost", "The address.")
+	fs.IntVar(&cfg.Port, "port", 80, "The port.")
+	fs.DurationVar(&cfg.Server.Timeout, "server.timeout", time.Minute, "The timeout.")
+	fs.BoolVar(&cfg.Server.Enabled, "server.enabled", true, "Whether it's enabled.")
+	fs.BoolVar(&cfg.Untouched.Enabled, "untouched.enabled", false, "Whether it's enabled.")
+	flags := testFlags(fs)
+
+	t.Run("defaults", func(t *testing.T) {
+		data, err := MinimalYAML(cfg, flags)
+		require.NoError(t, err)
+		assert.Equal(t, "{}\n", string(data))
+	})
+
+	t.Run("customized", func(t *testing.T) {
+		require.NoError(t, fs.Set("inlined", "other"))
+		require.NoError(t, fs.Set("port", "8080"))
+		require.NoError(t, fs.Set("server.timeout", "5m"))
+		cfg.Labels = map[string]string{"cluster": "prod"}
+		cfg.Hidden = "secret"
+
+		data, err := MinimalYAML(cfg, flags)
+		require.NoError(t, err)
+		assert.Equal(t, "inlined: other\n"+
+			"port: 8080\n"+
+			"server:\n"+
+			"    timeout: 5m0s\n"+
+			"labels:\n"+
+			"    cluster: prod\n", string(data))
+	})
+}
+
+func TestMinimalYAML_NotAStructPointer(t *testing.T) {
+	_, err := MinimalYAML(struct{}{}, nil)
+	assert.EqualError(t, err, "struct {} is not a pointer to struct")
+}
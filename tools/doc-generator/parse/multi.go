@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"flag"
+
+	"github.com/pkg/errors"
+)
+
+// NamedConfig is a top-level config parsed by ConfigMulti, like the config of a binary.
+type NamedConfig struct {
+	Name   string
+	Config interface{}
+
+	// The CLI flags registered by Config, as returned by Flags.
+	Flags map[uintptr][]*flag.Flag
+}
+
+// MultiResult is the result of ConfigMulti.
+type MultiResult struct {
+	// The blocks of each input, in the order of the inputs.
+	Inputs []*InputBlocks
+
+	// The root blocks referenced by more than one input, in the order of the root blocks.
+	Shared []*SharedBlock
+}
+
+// InputBlocks are the blocks of an input of ConfigMulti. The top-level block is the first one,
+// followed by the other blocks of the input, like slice elements, and then by the root blocks
+// referenced only by this input. Entries referencing a shared root block point to the block
+// in MultiResult.Shared.
+type InputBlocks struct {
+	Name   string
+	Blocks []*ConfigBlock
+}
+
+// SharedBlock is a root block referenced by more than one input of ConfigMulti.
+type SharedBlock struct {
+	Block *ConfigBlock
+
+	// The names of the inputs referencing the block, in the order of the inputs.
+	ReferencedBy []string
+}
+
+// ConfigMulti parses each input like ConfigWithOptions, expanding each root block once across
+// all the inputs, so that root blocks shared by multiple inputs can be documented once.
+func ConfigMulti(inputs []NamedConfig, rootBlocks []RootBlock, opts Options) (*MultiResult, error) {
+	roots := make(map[string]struct{}, len(rootBlocks))
+	for _, rootBlock := range rootBlocks {
+		roots[rootBlock.Name] = struct{}{}
+	}
+
+	// The first expansion of each root block is the one referenced by all the inputs.
+	expanded := map[string]*ConfigBlock{}
+	referencedBy := map[string][]string{}
+
+	result := &MultiResult{}
+	for _, input := range inputs {
+		blocks, err := ConfigWithOptions(input.Config, input.Flags, rootBlocks, opts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "input=%s", input.Name)
+		}
+
+		inputBlocks := &InputBlocks{Name: input.Name, Blocks: blocks[:1]}
+		for _, block := range blocks[1:] {
+			if _, ok := roots[block.Name]; !ok {
+				inputBlocks.Blocks = append(inputBlocks.Blocks, block)
+				continue
+			}
+
+			refs := referencedBy[block.Name]
+			if len(refs) == 0 || refs[len(refs)-1] != input.Name {
+				referencedBy[block.Name] = append(refs, input.Name)
+			}
+			if _, ok := expanded[block.Name]; !ok {
+				expanded[block.Name] = block
+			}
+		}
+
+		for _, block := range blocks {
+			replaceRootBlocks(block, expanded)
+		}
+		result.Inputs = append(result.Inputs, inputBlocks)
+	}
+
+	// Root blocks referenced by a single input are documented along with it.
+	for _, rootBlock := range rootBlocks {
+		block, ok := expanded[rootBlock.Name]
+		if !ok {
+			continue
+		}
+
+		refs := referencedBy[rootBlock.Name]
+		if len(refs) > 1 {
+			result.Shared = append(result.Shared, &SharedBlock{Block: block, ReferencedBy: refs})
+			continue
+		}
+
+		for _, input := range result.Inputs {
+			if input.Name == refs[0] {
+				input.Blocks = append(input.Blocks, block)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// replaceRootBlocks replaces the root blocks referenced by the entries of the block, and of
+// its non-root sub-blocks, with their expansion in expanded.
+func replaceRootBlocks(block *ConfigBlock, expanded map[string]*ConfigBlock) {
+	for _, entry := range block.Entries {
+		if entry.Kind == KindBlock {
+			if !entry.Root {
+				replaceRootBlocks(entry.Block, expanded)
+			} else if b, ok := expanded[entry.Block.Name]; ok {
+				entry.Block = b
+			}
+		}
+		if entry.Element != nil {
+			if b, ok := expanded[entry.Element.Name]; ok {
+				entry.Element = b
+			}
+		}
+	}
+}
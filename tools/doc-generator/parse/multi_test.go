@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type multiServerConfig struct {
+	Port int `yaml:"port"`
+}
+
+type multiStorageConfig struct {
+	Bucket string `yaml:"bucket"`
+}
+
+func TestConfigMulti(t *testing.T) {
+	type mainConfig struct {
+		Server  multiServerConfig  `yaml:"server"`
+		Storage multiStorageConfig `yaml:"storage"`
+		Other   multiStorageConfig `yaml:"other_storage"`
+	}
+
+	type toolConfig struct {
+		Server  multiServerConfig `yaml:"server"`
+		Verbose bool              `yaml:"verbose"`
+	}
+
+	rootBlocks := []RootBlock{
+		{Name: "server_config", Desc: "The server block.", StructType: reflect.TypeOf(multiServerConfig{})},
+		{Name: "storage_config", Desc: "The storage block.", StructType: reflect.TypeOf(multiStorageConfig{})},
+		{Name: "unused_config", Desc: "The unused block.", StructType: reflect.TypeOf(struct{}{})},
+	}
+
+	mainCfg := &mainConfig{}
+	mainFlags := flag.NewFlagSet("", flag.PanicOnError)
+	mainFlags.IntVar(&mainCfg.Server.Port, "server.port", 80, "The port.")
+
+	toolCfg := &toolConfig{}
+	toolFlags := flag.NewFlagSet("", flag.PanicOnError)
+	toolFlags.IntVar(&toolCfg.Server.Port, "server.port", 8080, "The port.")
+
+	result, err := ConfigMulti([]NamedConfig{
+		{Name: "main", Config: mainCfg, Flags: testFlags(mainFlags)},
+		{Name: "tool", Config: toolCfg, Flags: testFlags(toolFlags)},
+	}, rootBlocks, Options{})
+	require.NoError(t, err)
+
+	// The server block is shared, and expanded once.
+	require.Len(t, result.Shared, 1)
+	shared := result.Shared[0]
+	assert.Equal(t, "server_config", shared.Block.Name)
+	assert.Equal(t, []string{"main", "tool"}, shared.ReferencedBy)
+	assert.Equal(t, "80", shared.Block.Entries[0].FieldDefault)
+
+	require.Len(t, result.Inputs, 2)
+	main, tool := result.Inputs[0], result.Inputs[1]
+	assert.Equal(t, "main", main.Name)
+	assert.Equal(t, "tool", tool.Name)
+
+	// The storage block is referenced twice by the main config only, so it's expanded once along with it.
+	require.Len(t, main.Blocks, 2)
+	assert.Equal(t, "", main.Blocks[0].Name)
+	assert.Equal(t, "storage_config", main.Blocks[1].Name)
+	assert.Same(t, main.Blocks[1], main.Blocks[0].Entries[1].Block)
+	assert.Same(t, main.Blocks[1], main.Blocks[0].Entries[2].Block)
+
+	require.Len(t, tool.Blocks, 1)
+	assert.Equal(t, "", tool.Blocks[0].Name)
+
+	// Both inputs reference the shared block.
+	assert.Same(t, shared.Block, main.Blocks[0].Entries[0].Block)
+	assert.Same(t, shared.Block, tool.Blocks[0].Entries[0].Block)
+}
+
+func TestConfigMulti_Error(t *testing.T) {
+	_, err := ConfigMulti([]NamedConfig{
+		{Name: "invalid", Config: &struct {
+			Shards map[struct{}]string `yaml:"shards"`
+		}{}},
+	}, nil, Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "input=invalid")
+}
@@ -6,21 +6,26 @@
 package parse
 
 import (
+	"encoding"
 	"flag"
 	"fmt"
 	"net/url"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/go-kit/log"
 	"github.com/grafana/dskit/flagext"
-	"github.com/grafana/regexp"
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/weaveworks/common/logging"
+	"gopkg.in/yaml.v3"
 
 	"github.com/grafana/mimir/pkg/ingester/activeseries"
 	"github.com/grafana/mimir/pkg/storage/tsdb"
@@ -28,21 +33,57 @@ import (
 	"github.com/grafana/mimir/pkg/util/validation"
 )
 
-var (
-	yamlFieldNameParser   = regexp.MustCompile("^[^,]+")
-	yamlFieldInlineParser = regexp.MustCompile("^[^,]*,inline$")
-)
-
 // ExamplerConfig can be implemented by configs to provide examples.
 // If string is non-empty, it will be added as comment.
 // If yaml value is non-empty, it will be marshaled as yaml under the same key as it would appear in config.
+// If yaml value is a []ExampleEntry, the example is a list whose elements are annotated by their own comment.
 type ExamplerConfig interface {
 	ExampleDoc() (comment string, yaml interface{})
 }
 
+// ExampleEntry is an element of a list example, with the comment rendered above it.
+type ExampleEntry struct {
+	Comment string
+	Yaml    interface{}
+}
+
 type FieldExample struct {
 	Comment string
 	Yaml    interface{}
+
+	// The comments of the elements of the example list, by position, in case the example
+	// has been provided as a list of ExampleEntry. Empty comments aren't rendered.
+	ElementComments []string
+}
+
+// YAML returns the YAML of the example, with the comment of each element of the
+// example list rendered above the element.
+func (e *FieldExample) YAML() ([]byte, error) {
+	if len(e.ElementComments) == 0 {
+		return yaml.Marshal(e.Yaml)
+	}
+
+	node := &yaml.Node{}
+	if err := node.Encode(e.Yaml); err != nil {
+		return nil, err
+	}
+
+	// The example is nested under the keys of the field path, so the list is the value of the
+	// innermost single mapping entry.
+	list := node
+	for list.Kind == yaml.MappingNode && len(list.Content) == 2 {
+		list = list.Content[1]
+	}
+	if list.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("the example with element comments isn't a list")
+	}
+	for i, elem := range list.Content {
+		if i < len(e.ElementComments) {
+			elem.HeadComment = e.ElementComments[i]
+		}
+	}
+
+	return yaml.Marshal(node)
 }
 
 type ConfigBlock struct {
@@ -51,6 +92,15 @@ type ConfigBlock struct {
 	Entries       []*ConfigEntry
 	FlagsPrefix   string
 	FlagsPrefixes []string
+
+	// Descriptions of the structs inlined into this block, by inline label.
+	InlinedDescs map[string]string
+
+	// The category of the block, set through the category tag of the field referencing the
+	// block, or inherited from the parent block. The fields of the block without a category
+	// of their own inherit it. Root blocks, which may be referenced by multiple fields, have
+	// no category.
+	Category string
 }
 
 func (b *ConfigBlock) Add(entry *ConfigEntry) {
@@ -71,29 +121,212 @@ type ConfigEntry struct {
 	Name     string
 	Required bool
 
+	// The Go type of the config field, like "activeseries.CustomTrackersConfig",
+	// to find it in the source code.
+	GoType string
+
+	// The package path of the struct declaring the config field, like
+	// "github.com/grafana/mimir/pkg/ingester".
+	PkgPath string
+
+	// Whether the yaml tag of the field has the omitempty option.
+	OmitEmpty bool
+
+	// Whether the field is tagged as not settable through a CLI flag.
+	NoCLI bool
+
+	// Whether the field is tagged as an intended alias of the other fields set by the same CLI flag.
+	Alias bool
+
+	// The dotted paths of the other fields set by the same CLI flag, if any.
+	AliasesOf []string
+
 	// In case the Kind is KindBlock
 	Block     *ConfigBlock
 	BlockDesc string
 	Root      bool
 
+	// The name of the root block referenced by the entry type, if any. It's set both
+	// for root blocks and for fields whose type (or element type) is a root block,
+	// so that renderers can link to the referenced block section.
+	RefBlock string
+
 	// In case the Kind is KindField
-	FieldFlag     string
-	FieldDesc     string
+	FieldFlag string
+	FieldDesc string
+
+	// Other flags registered for the same config field, in case it's registered with multiple prefixes.
+	FieldFlagAlternates []string
+
+	// The documented type of the field, which is the String() of FieldTypeSpec.
 	FieldType     string
+	FieldTypeSpec *TypeSpec
+
 	FieldDefault  string
 	FieldExample  *FieldExample
 	FieldCategory string
 
+	// Whether the field holds a secret, like a password, whose value shouldn't be exposed.
+	FieldSecret bool
+
+	// Whether the field has no known default, in which case FieldDefault is empty. It's the case
+	// of the fields of an implementation of an interface field not registering CLI flags.
+	NoDefault bool
+
+	// The discriminator value selecting the implementation of an interface field the entry
+	// belongs to, like "type=s3", in which case the entry only applies when selected.
+	FieldSelector string
+
+	// The default of the field as set, like "1073741824", in case FieldDefault
+	// has been formatted for humans according to the unit, like "1GiB".
+	FieldRawDefault string
+
+	// Whether the category of the field, or of the block, is inherited from the parent block
+	// rather than set on the entry itself.
+	CategoryInherited bool
+
+	// Meaning of the special values of the field, like 0 meaning "disabled", by value.
+	FieldSentinels map[string]string
+
+	// Meaning of the zero value of a duration field, like "disabled" or "no timeout".
+	FieldZeroMeaning string
+
+	// The build tag or feature flag the field is gated behind, if any.
+	FieldFeature string
+
+	// Operational caveats of the field, like requiring filesystem write access,
+	// rendered distinctly from the description.
+	FieldWarnings []string
+
+	// The named options of a bit flags field, sorted by value.
+	FieldBits []BitFlag
+
+	// The inclusive bounds of a numeric field, enforced at startup, nil if unbounded.
+	FieldMin *float64
+	FieldMax *float64
+
+	// The unit of a numeric field, like "bytes" or "seconds", rendered along with its type.
+	FieldUnit string
+
+	// The field which should be used instead of this deprecated one, if any.
+	DeprecatedInFavorOf string
+
+	// The fields which can't be set together with this one, either as a dotted YAML path
+	// from their root block (or from the top-level block) or as a CLI flag like "-store.engine".
+	// They include the fields tagged as conflicting with this one.
+	FieldConflictsWith []string
+
+	// Whether the field can be changed at runtime, through the runtime config, without restarting.
+	FieldReloadable bool
+
+	// Whether the field is only applied at startup, so that changing it through a runtime
+	// config reload has no effect.
+	StartupOnly bool
+
+	// The past changes of the default value of the field, in the order they're tagged.
+	FieldDefaultChanges []DefaultChange
+
 	// In case the Kind is KindMap or KindSlice
 	Element *ConfigBlock
+
+	// The labels of the inline structs the entry has been reached through, from
+	// the outermost to the innermost one.
+	InlinedFrom []string
 }
 
 func (e ConfigEntry) Description() string {
-	if e.FieldCategory == "" || e.FieldCategory == "basic" {
-		return e.FieldDesc
+	desc := e.FieldDesc
+	// Sentinels are raw values, like "0" for a size in bytes documented as "0B".
+	raw := e.RawDefault()
+	if meaning, ok := e.FieldSentinels[raw]; ok {
+		desc = fmt.Sprintf("%s (%s = %s)", desc, raw, meaning)
+	}
+	if _, ok := e.FieldSentinels[zeroDuration]; e.FieldZeroMeaning != "" && !ok {
+		desc = fmt.Sprintf("%s (0 = %s)", desc, e.FieldZeroMeaning)
+	}
+	if bounds := e.Bounds(); bounds != "" {
+		desc = fmt.Sprintf("%s (must be %s)", desc, bounds)
+	}
+	if len(e.FieldBits) > 0 {
+		names := make([]string, 0, len(e.FieldBits))
+		for _, bit := range e.FieldBits {
+			names = append(names, bit.Name)
+		}
+		desc = fmt.Sprintf("%s Supported options: %s.", desc, strings.Join(names, ", "))
+	}
+	if e.FieldSelector != "" {
+		desc = fmt.Sprintf("%s (only when %s)", desc, e.FieldSelector)
+	}
+
+	// Inherited categories are described once, by the block they're inherited from.
+	if e.FieldCategory == "" || e.FieldCategory == "basic" || e.CategoryInherited {
+		return desc
+	}
+
+	return fmt.Sprintf("(%s) %s", e.FieldCategory, desc)
+}
+
+// RawDefault returns the default of the field as set, rather than formatted for humans,
+// so that it can be parsed as a value of the field type.
+func (e ConfigEntry) RawDefault() string {
+	if e.FieldRawDefault != "" {
+		return e.FieldRawDefault
+	}
+	return e.FieldDefault
+}
+
+// IsUnset returns whether the field is optional, like a pointer to a scalar, and unset by default.
+func (e ConfigEntry) IsUnset() bool {
+	return e.FieldTypeSpec != nil && e.FieldTypeSpec.Optional && e.FieldDefault == unsetDefault
+}
+
+// DisplayDefault returns the default of the field as documented: quoted if it's a set string,
+// with durations cleaned up and placeholders for the fields without a meaningful default.
+func (e ConfigEntry) DisplayDefault() string {
+	switch {
+	case e.NoDefault:
+		return "(not applicable)"
+	case e.FieldType == "string" && !e.IsUnset():
+		return strconv.Quote(e.FieldDefault)
+	case e.FieldType == "duration":
+		return cleanupDuration(e.FieldDefault)
+	case (e.FieldType == "time" || e.FieldType == "date") && e.FieldDefault == "":
+		return "(no default)"
+	default:
+		return e.FieldDefault
+	}
+}
+
+// Bounds returns the bounds of a numeric field, like "between 1 and 64" or "at least 0",
+// or an empty string if the field is unbounded.
+func (e ConfigEntry) Bounds() string {
+	switch {
+	case e.FieldMin != nil && e.FieldMax != nil:
+		return fmt.Sprintf("between %s and %s", formatBound(*e.FieldMin), formatBound(*e.FieldMax))
+	case e.FieldMin != nil:
+		return "at least " + formatBound(*e.FieldMin)
+	case e.FieldMax != nil:
+		return "at most " + formatBound(*e.FieldMax)
+	default:
+		return ""
+	}
+}
+
+func formatBound(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// BlockDescription returns the description of a block entry, prefixed by the category of
+// the block, unless it's basic or inherited from the parent block.
+func (e ConfigEntry) BlockDescription() string {
+	if e.Block == nil || e.Block.Category == "" || e.Block.Category == "basic" || e.CategoryInherited {
+		return e.BlockDesc
+	}
+	if e.BlockDesc == "" {
+		return fmt.Sprintf("(%s)", e.Block.Category)
 	}
 
-	return fmt.Sprintf("(%s) %s", e.FieldCategory, e.FieldDesc)
+	return fmt.Sprintf("(%s) %s", e.Block.Category, e.BlockDesc)
 }
 
 type RootBlock struct {
@@ -102,31 +335,110 @@ type RootBlock struct {
 	StructType reflect.Type
 }
 
-func Flags(cfg flagext.RegistererWithLogger, logger log.Logger) map[uintptr]*flag.Flag {
+// Flags returns the CLI flags registered by cfg, by the address of the config field they set.
+// Flags registered for the same config field are sorted by name.
+func Flags(cfg flagext.RegistererWithLogger, logger log.Logger) map[uintptr][]*flag.Flag {
 	fs := flag.NewFlagSet("", flag.PanicOnError)
 	cfg.RegisterFlags(fs, logger)
 
-	flags := map[uintptr]*flag.Flag{}
+	return FlagsFromSet(fs)
+}
+
+// FlagsFromSet is like Flags, but returns the CLI flags already registered in fs, for
+// callers which have a populated flag set rather than a config registering its flags.
+func FlagsFromSet(fs *flag.FlagSet) map[uintptr][]*flag.Flag {
+	flags := map[uintptr][]*flag.Flag{}
 	fs.VisitAll(func(f *flag.Flag) {
 		// Skip deprecated flags
 		if f.Value.String() == "deprecated" {
 			return
 		}
 
+		// The same config field may be registered multiple times with different prefixes.
 		ptr := reflect.ValueOf(f.Value).Pointer()
-		flags[ptr] = f
+		flags[ptr] = append(flags[ptr], f)
 	})
 
 	return flags
 }
 
+// DefaultedConfig returns a new instance of the type of cfg, which must be a pointer to struct,
+// holding the defaults set by its RegisterFlags, along with the CLI flags it registers, as
+// returned by Flags. Both are meant to be passed to Config, so that the documented defaults
+// include the ones set in the body of RegisterFlags rather than through a CLI flag, and don't
+// depend on the values held by cfg, which is never modified.
+func DefaultedConfig(cfg flagext.RegistererWithLogger, logger log.Logger) (interface{}, map[uintptr][]*flag.Flag) {
+	t := reflect.TypeOf(cfg)
+	if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("%s is not a pointer to struct", t))
+	}
+
+	defaulted := reflect.New(t.Elem()).Interface().(flagext.RegistererWithLogger)
+	return defaulted, Flags(defaulted, logger)
+}
+
+// Options configures how the config is parsed.
+type Options struct {
+	// StrictDocTags makes parsing fail if a doc struct tag has an unknown key, like a typo'd "hiden".
+	StrictDocTags bool
+
+	// FallbackToJSONTags names the fields without a yaml struct tag after their json struct tag,
+	// if any, instead of their lowercased field name. It's meant for structs shared with APIs.
+	FallbackToJSONTags bool
+
+	// EntryTransform, if set, is invoked for each entry once parsed, with the YAML path of the
+	// entry from its root block (or from the top-level block). Returning false drops the entry,
+	// otherwise the returned entry replaces it. It's meant for downstream distributions to tweak
+	// the documentation, like rewording descriptions, without forking the parser.
+	EntryTransform func(path []string, e *ConfigEntry) (*ConfigEntry, bool)
+
+	// BlockTransform is like EntryTransform, but for the blocks other than the top-level one.
+	// Dropping a block drops the entries referencing it, and the references to a replaced
+	// root block are updated, so that a root block can be renamed.
+	BlockTransform func(path []string, b *ConfigBlock) (*ConfigBlock, bool)
+
+	// ExtraCategories are the values accepted by the category struct tag in addition to the
+	// categories of the fieldcategory package, like the ones of a downstream distribution.
+	ExtraCategories []string
+}
+
 // Config returns a slice of ConfigBlocks. The first ConfigBlock is a recursively expanded cfg.
 // The remaining entries in the slice are all (root or not) ConfigBlocks.
-func Config(cfg interface{}, flags map[uintptr]*flag.Flag, rootBlocks []RootBlock) ([]*ConfigBlock, error) {
-	return config(nil, cfg, flags, rootBlocks)
+func Config(cfg interface{}, flags map[uintptr][]*flag.Flag, rootBlocks []RootBlock) ([]*ConfigBlock, error) {
+	return ConfigWithOptions(cfg, flags, rootBlocks, Options{})
+}
+
+// ConfigWithOptions is like Config, but parses the config according to the options.
+func ConfigWithOptions(cfg interface{}, flags map[uintptr][]*flag.Flag, rootBlocks []RootBlock, opts Options) ([]*ConfigBlock, error) {
+	blocks, err := config(nil, cfg, flags, rootBlocks, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Transforms run before any data derived from the entries, like aliases, is set.
+	blocks = transformBlocks(blocks, opts)
+
+	// Root blocks are documented on their own, so the example paths start from them.
+	seen := map[*ConfigBlock]bool{}
+	for _, block := range blocks {
+		setExamplePaths(block, nil, seen)
+	}
+
+	// Entries are validated once all the inline structs have been expanded.
+	for _, block := range blocks {
+		if err := validateUniqueEntryNames(block, block.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	setEntryAliases(blocks[0])
+	setEntryConflicts(blocks)
+	setRegisteredBlockDescriptions(blocks, rootBlocks)
+	setRegisteredReloadable(blocks, rootBlocks)
+	return blocks, nil
 }
 
-func config(block *ConfigBlock, cfg interface{}, flags map[uintptr]*flag.Flag, rootBlocks []RootBlock) ([]*ConfigBlock, error) {
+func config(block *ConfigBlock, cfg interface{}, flags map[uintptr][]*flag.Flag, rootBlocks []RootBlock, opts Options) ([]*ConfigBlock, error) {
 	blocks := []*ConfigBlock{}
 
 	// If the input block is nil it means we're generating the doc for the top-level block
@@ -151,15 +463,48 @@ func config(block *ConfigBlock, cfg interface{}, flags map[uintptr]*flag.Flag, r
 		return nil, fmt.Errorf("%s is a %s while a %s is expected", v, v.Kind(), reflect.Struct)
 	}
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		fieldValue := v.FieldByIndex(field.Index)
+	fields, err := structFields(t, v, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "config=%s.%s", t.PkgPath(), t.Name())
+	}
+
+	// Index of the first entry added by each field, to annotate the entries
+	// of the fields promoted from unexported embedded structs.
+	firstEntries := make([]int, len(fields))
+	firstEntry := len(block.Entries)
+
+	for i, f := range fields {
+		field, fieldValue := f.field, f.value
+		firstEntries[i] = len(block.Entries)
+
+		if opts.FallbackToJSONTags {
+			field = withJSONTagFallback(field)
+		}
+
+		if opts.StrictDocTags {
+			if err := validateDocTag(field); err != nil {
+				return nil, errors.Wrapf(err, "config=%s.%s", t.PkgPath(), t.Name())
+			}
+		}
+
+		// Skip unexported fields, even if they have a yaml tag, since they can't be configured
+		// and their address can't be taken. Unexported embedded structs have already been
+		// replaced by their promoted fields.
+		if !field.IsExported() {
+			continue
+		}
 
 		// Skip fields explicitly marked as "hidden" in the doc
 		if isFieldHidden(field) {
 			continue
 		}
 
+		// yaml.v2 nests an embedded struct without a yaml tag under its lowercased type name,
+		// rather than inlining it, which is hardly ever the intended shape of the config.
+		if _, ok := field.Tag.Lookup("yaml"); !ok && isEmbeddedStruct(field) {
+			return nil, fmt.Errorf("config=%s.%s: embedded field %s has no yaml tag, while it's only inlined if tagged as inline", t.PkgPath(), t.Name(), field.Type)
+		}
+
 		// Skip fields not exported via yaml (unless they're inline)
 		fieldName := getFieldName(field)
 		if fieldName == "" && !isFieldInline(field) {
@@ -177,18 +522,60 @@ func config(block *ConfigBlock, cfg interface{}, flags map[uintptr]*flag.Flag, r
 			continue
 		}
 
+		// Categories come from a closed set, so that a typo doesn't document a new category.
+		if err := validateFieldCategory(field, opts.ExtraCategories); err != nil {
+			return nil, errors.Wrapf(err, "config=%s.%s", t.PkgPath(), t.Name())
+		}
+
 		// Handle custom fields in vendored libs upon which we have no control.
 		fieldEntry, err := getCustomFieldEntry(field, fieldValue, flags)
 		if err != nil {
 			return nil, err
 		}
 		if fieldEntry != nil {
+			fieldEntry.FieldFlagAlternates = getFieldFlagAlternates(field, fieldValue, flags)
+			fieldEntry.FieldFeature = getFieldFeature(field)
+			fieldEntry.FieldWarnings = getFieldWarnings(field)
+			fieldEntry.DeprecatedInFavorOf = getFieldDeprecatedInFavorOf(field)
+			fieldEntry.FieldConflictsWith = getFieldConflictsWith(field)
+			fieldEntry.FieldReloadable = isFieldReloadable(field)
+			fieldEntry.StartupOnly = isFieldStartupOnly(field)
+			fieldEntry.OmitEmpty = parseYAMLTag(field).omitEmpty
+			fieldEntry.Alias = isFieldAlias(field)
+			fieldEntry.GoType = field.Type.String()
+			fieldEntry.FieldSentinels, err = getFieldSentinels(field)
+			if err != nil {
+				return nil, errors.Wrapf(err, "config=%s.%s", t.PkgPath(), t.Name())
+			}
+			fieldEntry.FieldZeroMeaning, err = getFieldZeroMeaning(field)
+			if err != nil {
+				return nil, errors.Wrapf(err, "config=%s.%s", t.PkgPath(), t.Name())
+			}
+			fieldEntry.FieldDefaultChanges, err = getFieldDefaultChanges(field)
+			if err != nil {
+				return nil, errors.Wrapf(err, "config=%s.%s", t.PkgPath(), t.Name())
+			}
+			fieldEntry.FieldUnit, err = getFieldUnit(field, fieldEntry.FieldTypeSpec)
+			if err != nil {
+				return nil, errors.Wrapf(err, "config=%s.%s", t.PkgPath(), t.Name())
+			}
+
 			block.Add(fieldEntry)
 			continue
 		}
 
+		// Interfaces with known implementations are documented as a block holding the fields of each implementation.
+		if impls, ok := getImplementations(field.Type); ok {
+			implsBlocks, err := implementationsBlock(block, field, fieldName, impls, rootBlocks, opts)
+			if err != nil {
+				return nil, errors.Wrapf(err, "config=%s.%s field=%s", t.PkgPath(), t.Name(), field.Name)
+			}
+			blocks = append(blocks, implsBlocks...)
+			continue
+		}
+
 		// Recursively re-iterate if it's a struct and it's not a custom type.
-		if _, custom := getCustomFieldType(field.Type); (field.Type.Kind() == reflect.Struct || field.Type.Kind() == reflect.Ptr) && !custom {
+		if _, custom := getCustomFieldType(field.Type); (field.Type.Kind() == reflect.Struct || field.Type.Kind() == reflect.Ptr) && !custom && !isOptionalScalar(field.Type) {
 			// Check whether the sub-block is a root config block
 			rootName, rootDesc, isRoot := isRootBlock(field.Type, rootBlocks)
 
@@ -213,6 +600,15 @@ func config(block *ConfigBlock, cfg interface{}, flags map[uintptr]*flag.Flag, r
 					Desc: blockDesc,
 				}
 
+				var categoryInherited bool
+				if !isRoot {
+					subBlock.Category = getFieldCategory(field, "")
+					if block.Category != "" && (subBlock.Category == "" || subBlock.Category == block.Category) {
+						subBlock.Category = block.Category
+						categoryInherited = true
+					}
+				}
+
 				block.Add(&ConfigEntry{
 					Kind:      KindBlock,
 					Name:      fieldName,
@@ -220,6 +616,11 @@ func config(block *ConfigBlock, cfg interface{}, flags map[uintptr]*flag.Flag, r
 					Block:     subBlock,
 					BlockDesc: blockDesc,
 					Root:      isRoot,
+					RefBlock:  rootName,
+					OmitEmpty: parseYAMLTag(field).omitEmpty,
+					GoType:    field.Type.String(),
+
+					CategoryInherited: categoryInherited,
 				})
 
 				if isRoot {
@@ -236,12 +637,29 @@ func config(block *ConfigBlock, cfg interface{}, flags map[uintptr]*flag.Flag, r
 				fieldValue = fieldValue.Addr()
 			}
 
+			// Keep track of the entries added by an inline struct, to annotate them.
+			firstInlined := len(subBlock.Entries)
+
 			// Recursively generate the doc for the sub-block
-			otherBlocks, err := config(subBlock, fieldValue.Interface(), flags, rootBlocks)
+			otherBlocks, err := config(subBlock, fieldValue.Interface(), flags, rootBlocks, opts)
 			if err != nil {
 				return nil, err
 			}
 
+			if isFieldInline(field) {
+				label := getInlineLabel(field)
+				for _, entry := range subBlock.Entries[firstInlined:] {
+					entry.InlinedFrom = append([]string{label}, entry.InlinedFrom...)
+				}
+
+				if desc := getFieldDescription(field, ""); desc != "" {
+					if subBlock.InlinedDescs == nil {
+						subBlock.InlinedDescs = map[string]string{}
+					}
+					subBlock.InlinedDescs[label] = desc
+				}
+			}
+
 			blocks = append(blocks, otherBlocks...)
 			continue
 		}
@@ -256,114 +674,511 @@ func config(block *ConfigBlock, cfg interface{}, flags map[uintptr]*flag.Flag, r
 			_, isCustomType := getFieldCustomType(field.Type)
 			isSliceOfStructs := field.Type.Kind() == reflect.Slice && (field.Type.Elem().Kind() == reflect.Struct || field.Type.Elem().Kind() == reflect.Ptr)
 			if !isCustomType && isSliceOfStructs {
+				elemType := field.Type.Elem()
+				if elemType.Kind() == reflect.Ptr {
+					elemType = elemType.Elem()
+				}
+
+				// If the element is a root block, the element block is named after it,
+				// so that it's documented only once.
 				element = &ConfigBlock{
 					Name: fieldName,
 					Desc: getFieldDescription(field, ""),
 				}
+				if rootName, rootDesc, isRoot := isRootBlock(elemType, rootBlocks); isRoot {
+					element.Name = rootName
+					element.Desc = rootDesc
+				}
 				kind = KindSlice
 
-				_, err = config(element, reflect.New(field.Type.Elem()).Interface(), flags, rootBlocks)
+				otherBlocks, err := config(element, reflect.New(elemType).Interface(), flags, rootBlocks, opts)
 				if err != nil {
 					return nil, errors.Wrapf(err, "couldn't inspect slice, element_type=%s", field.Type.Elem())
 				}
+
+				// The element block is documented once on its own, and referenced by the slice entry.
+				blocks = append(blocks, element)
+				blocks = append(blocks, otherBlocks...)
 			}
 		}
 
 		fieldType, err := getFieldType(field.Type)
+		if err != nil {
+			return nil, errors.Wrapf(err, "config=%s.%s field=%s", t.PkgPath(), t.Name(), field.Name)
+		}
+
+		// The flag of an optional scalar, if any, is registered for the value it points to.
+		flagValue := fieldValue
+		if isOptionalScalar(field.Type) && !fieldValue.IsNil() {
+			flagValue = fieldValue.Elem()
+		}
+
+		fieldFlag, err := getFieldFlag(field, flagValue, flags)
 		if err != nil {
 			return nil, errors.Wrapf(err, "config=%s.%s", t.PkgPath(), t.Name())
 		}
 
-		fieldFlag, err := getFieldFlag(field, fieldValue, flags)
+		fieldSentinels, err := getFieldSentinels(field)
+		if err != nil {
+			return nil, errors.Wrapf(err, "config=%s.%s", t.PkgPath(), t.Name())
+		}
+
+		fieldDefaultChanges, err := getFieldDefaultChanges(field)
+		if err != nil {
+			return nil, errors.Wrapf(err, "config=%s.%s", t.PkgPath(), t.Name())
+		}
+
+		fieldZeroMeaning, err := getFieldZeroMeaning(field)
+		if err != nil {
+			return nil, errors.Wrapf(err, "config=%s.%s", t.PkgPath(), t.Name())
+		}
+
+		labelsDefault, isLabels, err := getLabelsDefault(field, fieldValue)
 		if err != nil {
 			return nil, errors.Wrapf(err, "config=%s.%s", t.PkgPath(), t.Name())
 		}
+
+		fieldExample, err := getFieldExample(fieldName, field.Type)
+		if err != nil {
+			return nil, errors.Wrapf(err, "config=%s.%s", t.PkgPath(), t.Name())
+		}
+
+		fieldBits, err := getFieldBits(field)
+		if err != nil {
+			return nil, errors.Wrapf(err, "config=%s.%s", t.PkgPath(), t.Name())
+		}
+		if fieldBits != nil && acceptsBitNames(field.Type, fieldBits) {
+			fieldType = listType(scalarType("string"))
+		}
+
+		fieldMin, fieldMax, err := getFieldBounds(field, fieldType)
+		if err != nil {
+			return nil, errors.Wrapf(err, "config=%s.%s", t.PkgPath(), t.Name())
+		}
+
+		fieldUnit, err := getFieldUnit(field, fieldType)
+		if err != nil {
+			return nil, errors.Wrapf(err, "config=%s.%s", t.PkgPath(), t.Name())
+		}
+
 		if fieldFlag == nil {
+			fieldDefault := labelsDefault
+			if isOptionalScalar(field.Type) {
+				fieldDefault = getOptionalScalarDefault(field, fieldValue)
+			} else if fieldType.Kind == TypeScalar && fieldType.Name == "time" {
+				fieldDefault = getTimeDefault(field, (*flagext.Time)(fieldValue.Addr().Interface().(*time.Time)).String())
+			} else if !isLabels {
+				if fieldDefault, err = getMapDefault(field, fieldValue); err != nil {
+					return nil, errors.Wrapf(err, "config=%s.%s", t.PkgPath(), t.Name())
+				}
+			}
+			if err := checkDefaultInBounds(field, fieldDefault, fieldMin, fieldMax); err != nil {
+				return nil, errors.Wrapf(err, "config=%s.%s", t.PkgPath(), t.Name())
+			}
+			fieldDefault, fieldRawDefault := formatDefault(fieldDefault, fieldUnit)
+
 			block.Add(&ConfigEntry{
 				Kind:          kind,
 				Name:          fieldName,
 				Required:      isFieldRequired(field),
 				FieldDesc:     getFieldDescription(field, ""),
-				FieldType:     fieldType,
-				FieldExample:  getFieldExample(fieldName, field.Type),
+				FieldType:     fieldType.String(),
+				FieldTypeSpec: fieldType,
+				FieldDefault:  fieldDefault,
+				FieldExample:  fieldExample,
 				FieldCategory: getFieldCategory(field, ""),
 				Element:       element,
+				RefBlock:      getRefBlock(field.Type, rootBlocks),
+
+				FieldRawDefault:  fieldRawDefault,
+				FieldSentinels:   fieldSentinels,
+				FieldZeroMeaning: fieldZeroMeaning,
+				FieldFeature:     getFieldFeature(field),
+				FieldWarnings:    getFieldWarnings(field),
+				FieldBits:        fieldBits,
+				FieldMin:         fieldMin,
+				FieldMax:         fieldMax,
+				FieldUnit:        fieldUnit,
+				OmitEmpty:        parseYAMLTag(field).omitEmpty,
+				NoCLI:            isAbsentInCLI(field),
+				Alias:            isFieldAlias(field),
+				GoType:           field.Type.String(),
+
+				DeprecatedInFavorOf: getFieldDeprecatedInFavorOf(field),
+				FieldConflictsWith:  getFieldConflictsWith(field),
+				FieldReloadable:     isFieldReloadable(field),
+				StartupOnly:         isFieldStartupOnly(field),
+				FieldDefaultChanges: fieldDefaultChanges,
 			})
 			continue
 		}
 
+		fieldDefault := getFieldDefault(field, fieldFlag.DefValue)
+		if fieldType.Kind == TypeScalar && fieldType.Name == "time" {
+			fieldDefault = getTimeDefault(field, fieldFlag.DefValue)
+		}
+		if isLabels {
+			fieldDefault = labelsDefault
+		}
+		if fieldBits != nil {
+			if fieldDefault, err = getBitsDefault(field, fieldValue, fieldBits); err != nil {
+				return nil, errors.Wrapf(err, "config=%s.%s", t.PkgPath(), t.Name())
+			}
+		}
+		if err := checkDefaultInBounds(field, fieldDefault, fieldMin, fieldMax); err != nil {
+			return nil, errors.Wrapf(err, "config=%s.%s", t.PkgPath(), t.Name())
+		}
+		fieldDefault, fieldRawDefault := formatDefault(fieldDefault, fieldUnit)
+
 		block.Add(&ConfigEntry{
 			Kind:          kind,
 			Name:          fieldName,
 			Required:      isFieldRequired(field),
 			FieldFlag:     fieldFlag.Name,
 			FieldDesc:     getFieldDescription(field, fieldFlag.Usage),
-			FieldType:     fieldType,
-			FieldDefault:  getFieldDefault(field, fieldFlag.DefValue),
-			FieldExample:  getFieldExample(fieldName, field.Type),
+			FieldType:     fieldType.String(),
+			FieldTypeSpec: fieldType,
+			FieldDefault:  fieldDefault,
+			FieldExample:  fieldExample,
 			FieldCategory: getFieldCategory(field, fieldFlag.Name),
 			Element:       element,
+			RefBlock:      getRefBlock(field.Type, rootBlocks),
+
+			FieldRawDefault:     fieldRawDefault,
+			FieldFlagAlternates: getFieldFlagAlternates(field, flagValue, flags),
+			FieldSentinels:      fieldSentinels,
+			FieldZeroMeaning:    fieldZeroMeaning,
+			FieldFeature:        getFieldFeature(field),
+			FieldWarnings:       getFieldWarnings(field),
+			FieldBits:           fieldBits,
+			FieldMin:            fieldMin,
+			FieldMax:            fieldMax,
+			FieldUnit:           fieldUnit,
+			DeprecatedInFavorOf: getFieldDeprecatedInFavorOf(field),
+			FieldConflictsWith:  getFieldConflictsWith(field),
+			FieldReloadable:     isFieldReloadable(field),
+			StartupOnly:         isFieldStartupOnly(field),
+			FieldDefaultChanges: fieldDefaultChanges,
+			OmitEmpty:           parseYAMLTag(field).omitEmpty,
+			Alias:               isFieldAlias(field),
+			GoType:              field.Type.String(),
 		})
 	}
 
+	// Entries added through inline structs have already been annotated with
+	// the package of the struct declaring them.
+	for _, entry := range block.Entries[firstEntry:] {
+		if entry.PkgPath == "" {
+			entry.PkgPath = t.PkgPath()
+		}
+	}
+
+	for i, f := range fields {
+		if len(f.embeddedFrom) == 0 {
+			continue
+		}
+
+		last := len(block.Entries)
+		if i+1 < len(fields) {
+			last = firstEntries[i+1]
+		}
+		for _, entry := range block.Entries[firstEntries[i]:last] {
+			entry.InlinedFrom = append(append([]string(nil), f.embeddedFrom...), entry.InlinedFrom...)
+		}
+	}
+
+	// Fields without a category of their own, neither tagged nor overridden by
+	// flag name, inherit the category of the block. The ones with the same category
+	// as the block are considered inheriting it too, so that it's described once.
+	if block.Category != "" {
+		for _, entry := range block.Entries {
+			if entry.Kind != KindBlock && (entry.FieldCategory == "" || entry.FieldCategory == block.Category) {
+				entry.FieldCategory = block.Category
+				entry.CategoryInherited = true
+			}
+		}
+	}
+
+	setBlockFlagsPrefix(block)
+
 	return blocks, nil
 }
 
-func getFieldName(field reflect.StructField) string {
-	name := field.Name
-	tag := field.Tag.Get("yaml")
+// validateUniqueEntryNames returns an error if multiple entries of the block, or of its nested
+// blocks, have the same name, like a field promoted from an inline struct and a field of the
+// parent struct. Root blocks are validated on their own.
+func validateUniqueEntryNames(block *ConfigBlock, path string) error {
+	seen := make(map[string][]*ConfigEntry, len(block.Entries))
+	for _, entry := range block.Entries {
+		for _, other := range seen[entry.Name] {
+			// The fields of different implementations of an interface field are never set together.
+			if other.FieldSelector != "" && entry.FieldSelector != "" && other.FieldSelector != entry.FieldSelector {
+				continue
+			}
 
-	// If the tag is not specified, then an exported field can be
-	// configured via the field name (lowercase), while an unexported
-	// field can't be configured.
-	if tag == "" {
-		if unicode.IsLower(rune(name[0])) {
-			return ""
+			err := fmt.Errorf("field name %s is used by both %s and %s", entry.Name, entryOrigin(other), entryOrigin(entry))
+			if path != "" {
+				err = errors.Wrapf(err, "block %s", path)
+			}
+			return err
 		}
+		seen[entry.Name] = append(seen[entry.Name], entry)
 
-		return strings.ToLower(name)
+		entryPath := joinPath(path, entry.Name)
+		if entry.Kind == KindBlock && !entry.Root {
+			if err := validateUniqueEntryNames(entry.Block, entryPath); err != nil {
+				return err
+			}
+		}
+		if entry.Element != nil {
+			if err := validateUniqueEntryNames(entry.Element, entryPath+"[]"); err != nil {
+				return err
+			}
+		}
 	}
+	return nil
+}
 
-	// Parse the field name
-	fieldName := yamlFieldNameParser.FindString(tag)
-	if fieldName == "-" {
-		return ""
+// setEntryAliases sets the aliases of the fields of the top-level block, including the ones
+// of the root blocks it references, which are set by the same CLI flag as other fields.
+func setEntryAliases(block *ConfigBlock) {
+	paths := map[*ConfigEntry]string{}
+	byFlag := map[string][]*ConfigEntry{}
+	collectFlagEntries(block, "", paths, byFlag)
+
+	aliases := map[*ConfigEntry]map[string]struct{}{}
+	for _, entries := range byFlag {
+		for _, entry := range entries {
+			for _, other := range entries {
+				if other == entry {
+					continue
+				}
+				if aliases[entry] == nil {
+					aliases[entry] = map[string]struct{}{}
+				}
+				aliases[entry][paths[other]] = struct{}{}
+			}
+		}
 	}
 
-	return fieldName
+	for entry, otherPaths := range aliases {
+		entry.AliasesOf = make([]string, 0, len(otherPaths))
+		for otherPath := range otherPaths {
+			entry.AliasesOf = append(entry.AliasesOf, otherPath)
+		}
+		sort.Strings(entry.AliasesOf)
+	}
 }
 
-func getFieldCustomType(t reflect.Type) (string, bool) {
-	// Handle custom data types used in the config
-	switch t.String() {
-	case reflect.TypeOf(&url.URL{}).String():
-		return "url", true
-	case reflect.TypeOf(time.Duration(0)).String():
-		return "duration", true
-	case reflect.TypeOf(flagext.StringSliceCSV{}).String():
-		return "string", true
-	case reflect.TypeOf(flagext.CIDRSliceCSV{}).String():
-		return "string", true
-	case reflect.TypeOf([]*relabel.Config{}).String():
-		return "relabel_config...", true
-	case reflect.TypeOf(activeseries.CustomTrackersConfig{}).String():
-		return "map of tracker name (string) to matcher (string)", true
-	default:
-		return "", false
+// collectFlagEntries maps the fields of the block to their dotted path, and the names of
+// their CLI flags to the fields, recursing into all the sub-blocks.
+func collectFlagEntries(block *ConfigBlock, path string, paths map[*ConfigEntry]string, byFlag map[string][]*ConfigEntry) {
+	for _, entry := range block.Entries {
+		entryPath := joinPath(path, entry.Name)
+		if entry.Kind == KindBlock {
+			collectFlagEntries(entry.Block, entryPath, paths, byFlag)
+			continue
+		}
+
+		if entry.FieldFlag == "" {
+			continue
+		}
+		paths[entry] = entryPath
+		for _, name := range append([]string{entry.FieldFlag}, entry.FieldFlagAlternates...) {
+			byFlag[name] = append(byFlag[name], entry)
+		}
 	}
 }
 
-func getFieldType(t reflect.Type) (string, error) {
-	if typ, isCustom := getFieldCustomType(t); isCustom {
-		return typ, nil
+func entryOrigin(entry *ConfigEntry) string {
+	if entry.FieldSelector != "" {
+		return "a field of the implementation selected by " + entry.FieldSelector
 	}
+	if len(entry.InlinedFrom) == 0 {
+		return "a field of the struct"
+	}
+	return "a field promoted from " + strings.Join(entry.InlinedFrom, ".")
+}
 
-	// Fallback to auto-detection of built-in data types
-	switch t.Kind() {
-	case reflect.Bool:
-		return "boolean", nil
+// setBlockFlagsPrefix sets the flags prefixes of a block whose fields are registered with
+// multiple prefixes, and picks the flag matching the block prefix as the flag of each field.
+func setBlockFlagsPrefix(block *ConfigBlock) {
+	for _, entry := range block.Entries {
+		if len(entry.FieldFlagAlternates) == 0 {
+			continue
+		}
 
-	case reflect.Int:
+		prefixes := FindFlagsPrefix(entryFlags(entry))
+		if prefixes[0] == "" {
+			// Can't reference a block with an empty prefix.
+			return
+		}
+
+		block.FlagsPrefix = prefixes[0]
+		block.FlagsPrefixes = prefixes
+		break
+	}
+
+	if block.FlagsPrefix == "" {
+		return
+	}
+
+	for _, entry := range block.Entries {
+		if len(entry.FieldFlagAlternates) == 0 {
+			continue
+		}
+
+		names := entryFlags(entry)
+		for i, name := range names {
+			if strings.HasPrefix(name, block.FlagsPrefix+".") {
+				entry.FieldFlag = name
+				entry.FieldFlagAlternates = append(names[:i:i], names[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// entryFlags returns the names of all flags of the entry, sorted.
+func entryFlags(entry *ConfigEntry) []string {
+	names := append([]string{entry.FieldFlag}, entry.FieldFlagAlternates...)
+	sort.Strings(names)
+	return names
+}
+
+func getFieldName(field reflect.StructField) string {
+	tag, ok := field.Tag.Lookup("yaml")
+
+	// If the tag is not specified, then an exported field can be
+	// configured via the field name (lowercase), while an unexported
+	// field can't be configured.
+	if !ok || tag == "" {
+		return getLowercaseFieldName(field)
+	}
+
+	// Like the yaml package, a field is omitted only if the whole tag is "-",
+	// so that "-," can be used to configure a field named "-".
+	if tag == "-" {
+		return ""
+	}
+
+	// Like the yaml package, a tag with options only, like ",omitempty", names the
+	// field after its lowercased name. Inline fields have no name on their own.
+	yamlTag := parseYAMLTag(field)
+	if yamlTag.name == "" && !yamlTag.inline {
+		return getLowercaseFieldName(field)
+	}
+
+	return yamlTag.name
+}
+
+// getLowercaseFieldName returns the lowercased name of the field, or an empty string
+// if the field is unexported, or has no name.
+func getLowercaseFieldName(field reflect.StructField) string {
+	first, _ := utf8.DecodeRuneInString(field.Name)
+	if first == utf8.RuneError || !unicode.IsUpper(first) {
+		return ""
+	}
+
+	return strings.ToLower(field.Name)
+}
+
+// withJSONTagFallback returns the field with a yaml struct tag copied from its json struct tag,
+// if the field has a json struct tag but no yaml one. Like the json package, a tag without
+// a name is named after the field.
+func withJSONTagFallback(field reflect.StructField) reflect.StructField {
+	if _, ok := field.Tag.Lookup("yaml"); ok {
+		return field
+	}
+	tag, ok := field.Tag.Lookup("json")
+	if !ok {
+		return field
+	}
+
+	if tag == "" || strings.HasPrefix(tag, ",") {
+		tag = field.Name + tag
+	}
+	field.Tag = reflect.StructTag(strings.TrimSpace(fmt.Sprintf("%s yaml:%s", field.Tag, strconv.Quote(tag))))
+	return field
+}
+
+// yamlTag is the parsed yaml struct tag of a field.
+type yamlTag struct {
+	name      string
+	omitEmpty bool
+	flow      bool
+	inline    bool
+}
+
+// parseYAMLTag parses the yaml struct tag of the field, made of the field name
+// followed by any comma-separated option in any order.
+func parseYAMLTag(field reflect.StructField) yamlTag {
+	parts := strings.Split(field.Tag.Get("yaml"), ",")
+
+	tag := yamlTag{name: parts[0]}
+	for _, option := range parts[1:] {
+		switch option {
+		case "omitempty":
+			tag.omitEmpty = true
+		case "flow":
+			tag.flow = true
+		case "inline":
+			tag.inline = true
+		}
+	}
+	return tag
+}
+
+func getFieldCustomType(t reflect.Type) (*TypeSpec, bool) {
+	// Handle custom data types used in the config
+	switch t.String() {
+	case reflect.TypeOf(&url.URL{}).String():
+		return scalarType("url"), true
+	case reflect.TypeOf(time.Duration(0)).String(),
+		reflect.TypeOf(model.Duration(0)).String():
+		return scalarType("duration"), true
+	case reflect.TypeOf(time.Time{}).String():
+		return scalarType("time"), true
+	case reflect.TypeOf(flagext.DayValue{}).String():
+		return scalarType("date"), true
+	case reflect.TypeOf(flagext.StringSliceCSV{}).String():
+		return scalarType("string"), true
+	case reflect.TypeOf(flagext.CIDRSliceCSV{}).String():
+		return scalarType("string"), true
+	case reflect.TypeOf([]*relabel.Config{}).String():
+		return scalarType("relabel_config..."), true
+	case reflect.TypeOf(model.LabelSet{}).String():
+		return mapType(scalarType("string"), scalarType("string")), true
+	case reflect.TypeOf(labels.Labels{}).String():
+		return mapType(scalarType("string"), scalarType("string")), true
+	case reflect.TypeOf([]model.LabelName{}).String(),
+		reflect.TypeOf(model.LabelNames{}).String(),
+		reflect.TypeOf([]model.LabelValue{}).String(),
+		reflect.TypeOf(model.LabelValues{}).String():
+		return listType(scalarType("string")), true
+	case reflect.TypeOf([]*labels.Matcher{}).String(),
+		reflect.TypeOf(labels.Selector{}).String():
+		return listType(scalarType("label matcher")), true
+	case reflect.TypeOf(map[string]float64{}).String():
+		return mapType(scalarType("string"), scalarType("float64")), true
+	case reflect.TypeOf(activeseries.CustomTrackersConfig{}).String():
+		return mapType(scalarType("tracker name (string)"), scalarType("matcher (string)")), true
+	default:
+		return nil, false
+	}
+}
+
+func getFieldType(t reflect.Type) (*TypeSpec, error) {
+	if typ, isCustom := getFieldCustomType(t); isCustom {
+		return typ, nil
+	}
+
+	// Fallback to auto-detection of built-in data types
+	switch t.Kind() {
+	case reflect.Bool:
+		return scalarType("boolean"), nil
+
+	case reflect.Int:
 		fallthrough
 	case reflect.Int8:
 		fallthrough
@@ -382,34 +1197,85 @@ func getFieldType(t reflect.Type) (string, error) {
 	case reflect.Uint32:
 		fallthrough
 	case reflect.Uint64:
-		return "int", nil
+		return scalarType("int"), nil
 
 	case reflect.Float32:
 		fallthrough
 	case reflect.Float64:
-		return "float", nil
+		return scalarType("float"), nil
 
 	case reflect.String:
-		return "string", nil
+		return scalarType("string"), nil
 
 	case reflect.Slice:
 		// Get the type of elements
 		elemType, err := getFieldType(t.Elem())
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 
-		return "list of " + elemType, nil
+		return listType(elemType), nil
 	case reflect.Map:
-		return fmt.Sprintf("map of %s to %s", t.Key(), t.Elem().String()), nil
+		// Keys are documented like fields of the same type, so that typed keys like
+		// int32 or enums based on a string are documented as "int" or "string".
+		if !isScalarKind(t.Key().Kind()) {
+			return nil, fmt.Errorf("unsupported map key type %s", t.Key())
+		}
+		keyType, err := getFieldType(t.Key())
+		if err != nil {
+			return nil, err
+		}
+
+		return mapType(keyType, scalarType(t.Elem().String())), nil
 
 	case reflect.Struct:
-		return t.Name(), nil
+		return scalarType(t.Name()), nil
 	case reflect.Ptr:
-		return getFieldType(t.Elem())
+		typ, err := getFieldType(t.Elem())
+		if err == nil && isOptionalScalar(t) {
+			typ.Optional = true
+		}
+		return typ, err
 
 	default:
-		return "", fmt.Errorf("unsupported data type %s", t.Kind())
+		return nil, fmt.Errorf("unsupported data type %s", t.Kind())
+	}
+}
+
+// isOptionalScalar returns whether t is a pointer to a scalar, like *int or *time.Duration,
+// which is unset when nil, usually to fall back to another setting.
+func isOptionalScalar(t reflect.Type) bool {
+	if t.Kind() != reflect.Ptr || !isScalarKind(t.Elem().Kind()) {
+		return false
+	}
+	_, custom := getFieldCustomType(t)
+	return !custom
+}
+
+// unsetDefault is the default of the optional scalar fields which are nil.
+const unsetDefault = "unset"
+
+// getOptionalScalarDefault returns the default of an optional scalar field, which is "unset" if nil.
+func getOptionalScalarDefault(field reflect.StructField, fieldValue reflect.Value) string {
+	if v := getDocTagValue(field, "default"); v != "" {
+		return v
+	}
+	if fieldValue.IsNil() {
+		return unsetDefault
+	}
+	return fmt.Sprint(fieldValue.Elem().Interface())
+}
+
+// isScalarKind returns whether values of the kind are YAML scalars.
+func isScalarKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
 	}
 }
 
@@ -420,12 +1286,28 @@ func getCustomFieldType(t reflect.Type) (string, bool) {
 		return "url", true
 	case reflect.TypeOf(time.Duration(0)).String():
 		return "duration", true
+	case reflect.TypeOf(time.Time{}).String():
+		return "time", true
+	case reflect.TypeOf(flagext.DayValue{}).String():
+		return "date", true
 	case reflect.TypeOf(flagext.StringSliceCSV{}).String():
 		return "string", true
 	case reflect.TypeOf(flagext.CIDRSliceCSV{}).String():
 		return "string", true
 	case reflect.TypeOf([]*relabel.Config{}).String():
 		return "relabel_config...", true
+	case reflect.TypeOf(model.LabelSet{}).String():
+		return "map of string to string", true
+	case reflect.TypeOf(labels.Labels{}).String():
+		return "map of string to string", true
+	case reflect.TypeOf([]model.LabelName{}).String(),
+		reflect.TypeOf(model.LabelNames{}).String(),
+		reflect.TypeOf([]model.LabelValue{}).String(),
+		reflect.TypeOf(model.LabelValues{}).String():
+		return "list of string", true
+	case reflect.TypeOf([]*labels.Matcher{}).String(),
+		reflect.TypeOf(labels.Selector{}).String():
+		return "list of label matcher", true
 	case reflect.TypeOf(activeseries.CustomTrackersConfig{}).String():
 		return "map of tracker name (string) to matcher (string)", true
 	default:
@@ -433,8 +1315,17 @@ func getCustomFieldType(t reflect.Type) (string, bool) {
 	}
 }
 
-func ReflectType(typ string) reflect.Type {
-	switch typ {
+// ReflectType returns the Go type of the values of a field of the given type, like
+// the ones of a config descriptor.
+func ReflectType(typ *TypeSpec) reflect.Type {
+	// Optional scalars are pointers to the type of their value.
+	if typ.Optional {
+		value := *typ
+		value.Optional = false
+		return reflect.PtrTo(ReflectType(&value))
+	}
+
+	switch typ.String() {
 	case "string":
 		return reflect.TypeOf("")
 	case "url":
@@ -443,6 +1334,8 @@ func ReflectType(typ string) reflect.Type {
 		return reflect.TypeOf(time.Duration(0))
 	case "time":
 		return reflect.TypeOf(&flagext.Time{})
+	case "date":
+		return reflect.TypeOf(flagext.DayValue{})
 	case "boolean":
 		return reflect.TypeOf(false)
 	case "int":
@@ -457,58 +1350,212 @@ func ReflectType(typ string) reflect.Type {
 		return reflect.TypeOf(map[string]string{})
 	case "relabel_config...":
 		return reflect.TypeOf([]*relabel.Config{})
+	case "list of label matcher":
+		return reflect.TypeOf([]*labels.Matcher{})
 	case "map of string to float64":
 		return reflect.TypeOf(map[string]float64{})
+	case "map of string to int":
+		return reflect.TypeOf(map[string]int{})
+	case "map of int to string":
+		return reflect.TypeOf(map[int]string{})
+	case "map of int to int":
+		return reflect.TypeOf(map[int]int{})
+	case "map of int to float64":
+		return reflect.TypeOf(map[int]float64{})
 	case "list of duration":
 		return reflect.TypeOf(tsdb.DurationList{})
 	case "map of string to validation.ForwardingRule":
 		return reflect.TypeOf(map[string]validation.ForwardingRule{})
 	default:
-		panic("unknown field type " + typ)
+		panic("unknown field type " + typ.String())
 	}
 }
 
-func getFieldFlag(field reflect.StructField, fieldValue reflect.Value, flags map[uintptr]*flag.Flag) (*flag.Flag, error) {
+func getFieldFlag(field reflect.StructField, fieldValue reflect.Value, flags map[uintptr][]*flag.Flag) (*flag.Flag, error) {
 	if isAbsentInCLI(field) {
 		return nil, nil
 	}
 	fieldPtr := fieldValue.Addr().Pointer()
-	fieldFlag, ok := flags[fieldPtr]
-	if !ok {
+	fieldFlags, ok := flags[fieldPtr]
+	if !ok || len(fieldFlags) == 0 {
 		return nil, nil
 	}
 
-	return fieldFlag, nil
+	return fieldFlags[0], nil
 }
 
-func getFieldExample(fieldKey string, fieldType reflect.Type) *FieldExample {
-	ex, ok := reflect.New(fieldType).Interface().(ExamplerConfig)
-	if !ok {
+// getFieldFlagAlternates returns the names of the flags registered for the field
+// besides the one returned by getFieldFlag.
+func getFieldFlagAlternates(field reflect.StructField, fieldValue reflect.Value, flags map[uintptr][]*flag.Flag) []string {
+	if isAbsentInCLI(field) {
 		return nil
 	}
-	comment, yml := ex.ExampleDoc()
+
+	fieldFlags := flags[fieldValue.Addr().Pointer()]
+	if len(fieldFlags) < 2 {
+		return nil
+	}
+
+	names := make([]string, 0, len(fieldFlags)-1)
+	for _, f := range fieldFlags[1:] {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+// maxExampleDepth is the maximum nesting depth of the value returned by ExampleDoc,
+// to guard against cyclic values which would make the YAML marshaling never end.
+const maxExampleDepth = 32
+
+// getFieldExample returns the example of the field, if its type implements ExamplerConfig
+// or has a built-in example, nested under the key of the field. The keys of the blocks the
+// field is nested in are added by setExamplePaths. The example value must not contain values
+// which provide an example on their own.
+func getFieldExample(fieldKey string, fieldType reflect.Type) (*FieldExample, error) {
+	comment, yml, ok := getBuiltinExample(fieldType)
+	if !ok {
+		ex, ok := reflect.New(fieldType).Interface().(ExamplerConfig)
+		if !ok {
+			return nil, nil
+		}
+		comment, yml = ex.ExampleDoc()
+	}
+
+	var elementComments []string
+	if entries, ok := yml.([]ExampleEntry); ok {
+		values := make([]interface{}, 0, len(entries))
+		for _, entry := range entries {
+			values = append(values, entry.Yaml)
+			elementComments = append(elementComments, entry.Comment)
+		}
+		yml = values
+	}
+
+	if err := validateExampleValue(reflect.ValueOf(yml), 0); err != nil {
+		return nil, errors.Wrapf(err, "field %s: invalid example", fieldKey)
+	}
+
 	return &FieldExample{
-		Comment: comment,
-		Yaml:    map[string]interface{}{fieldKey: yml},
+		Comment:         comment,
+		Yaml:            map[string]interface{}{fieldKey: yml},
+		ElementComments: elementComments,
+	}, nil
+}
+
+// getBuiltinExample returns the example of the types of vendored libs, which can't implement ExamplerConfig.
+func getBuiltinExample(t reflect.Type) (comment string, yaml interface{}, ok bool) {
+	switch t.String() {
+	case reflect.TypeOf([]*relabel.Config{}).String():
+		return "The following configuration drops the series of the debug_ metrics, and copies the namespace label to the tenant_namespace label.",
+			[]ExampleEntry{
+				{
+					Comment: "Drop the series of the debug_ metrics.",
+					Yaml: map[string]interface{}{
+						"source_labels": []string{"__name__"},
+						"regex":         "debug_.*",
+						"action":        "drop",
+					},
+				},
+				{
+					Comment: "Copy the namespace label to the tenant_namespace label.",
+					Yaml: map[string]interface{}{
+						"source_labels": []string{"namespace"},
+						"regex":         "(.+)",
+						"target_label":  "tenant_namespace",
+						"action":        "replace",
+					},
+				},
+			}, true
+	default:
+		return "", nil, false
+	}
+}
+
+// setExamplePaths nests the examples of the fields of block under the keys of the non-root
+// blocks they're nested in, from path, so that they can be copied in the block documented on
+// its own. The fields of list or map elements are nested from the element.
+func setExamplePaths(block *ConfigBlock, path []string, seen map[*ConfigBlock]bool) {
+	if seen[block] {
+		return
+	}
+	seen[block] = true
+
+	for _, entry := range block.Entries {
+		if entry.FieldExample != nil {
+			for i := len(path) - 1; i >= 0; i-- {
+				entry.FieldExample.Yaml = map[string]interface{}{path[i]: entry.FieldExample.Yaml}
+			}
+		}
+		if entry.Kind == KindBlock && !entry.Root {
+			setExamplePaths(entry.Block, appendPath(path, entry.Name), seen)
+		}
+		if entry.Element != nil {
+			setExamplePaths(entry.Element, nil, seen)
+		}
 	}
 }
 
-func getCustomFieldEntry(field reflect.StructField, fieldValue reflect.Value, flags map[uintptr]*flag.Flag) (*ConfigEntry, error) {
+func validateExampleValue(v reflect.Value, depth int) error {
+	if depth > maxExampleDepth {
+		return fmt.Errorf("exceeds the maximum depth of %d", maxExampleDepth)
+	}
+	if !v.IsValid() {
+		return nil
+	}
+
+	if v.CanInterface() && depth > 0 {
+		if ex, ok := v.Interface().(ExamplerConfig); ok {
+			return fmt.Errorf("contains a value of type %T, which provides its own example", ex)
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return validateExampleValue(v.Elem(), depth+1)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateExampleValue(v.Index(i), depth+1); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := validateExampleValue(iter.Value(), depth+1); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if err := validateExampleValue(v.Field(i), depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func getCustomFieldEntry(field reflect.StructField, fieldValue reflect.Value, flags map[uintptr][]*flag.Flag) (*ConfigEntry, error) {
 	if field.Type == reflect.TypeOf(logging.Level{}) || field.Type == reflect.TypeOf(logging.Format{}) {
 		fieldFlag, err := getFieldFlag(field, fieldValue, flags)
 		if err != nil {
 			return nil, err
 		}
+		name, usage, defValue := flagOrValue(fieldFlag, fieldValue)
 
 		return &ConfigEntry{
 			Kind:          KindField,
 			Name:          getFieldName(field),
 			Required:      isFieldRequired(field),
-			FieldFlag:     fieldFlag.Name,
-			FieldDesc:     fieldFlag.Usage,
+			FieldFlag:     name,
+			FieldDesc:     getFieldDescription(field, usage),
 			FieldType:     "string",
-			FieldDefault:  getFieldDefault(field, fieldFlag.DefValue),
-			FieldCategory: getFieldCategory(field, fieldFlag.Name),
+			FieldTypeSpec: scalarType("string"),
+			FieldDefault:  getFieldDefault(field, defValue),
+			FieldCategory: getFieldCategory(field, name),
 		}, nil
 	}
 	if field.Type == reflect.TypeOf(flagext.URLValue{}) {
@@ -516,16 +1563,18 @@ func getCustomFieldEntry(field reflect.StructField, fieldValue reflect.Value, fl
 		if err != nil {
 			return nil, err
 		}
+		name, usage, defValue := flagOrValue(fieldFlag, fieldValue)
 
 		return &ConfigEntry{
 			Kind:          KindField,
 			Name:          getFieldName(field),
 			Required:      isFieldRequired(field),
-			FieldFlag:     fieldFlag.Name,
-			FieldDesc:     fieldFlag.Usage,
+			FieldFlag:     name,
+			FieldDesc:     getFieldDescription(field, usage),
 			FieldType:     "url",
-			FieldDefault:  getFieldDefault(field, fieldFlag.DefValue),
-			FieldCategory: getFieldCategory(field, fieldFlag.Name),
+			FieldTypeSpec: scalarType("url"),
+			FieldDefault:  getFieldDefault(field, defValue),
+			FieldCategory: getFieldCategory(field, name),
 		}, nil
 	}
 	if field.Type == reflect.TypeOf(flagext.Secret{}) {
@@ -533,16 +1582,19 @@ func getCustomFieldEntry(field reflect.StructField, fieldValue reflect.Value, fl
 		if err != nil {
 			return nil, err
 		}
+		name, usage, defValue := flagOrValue(fieldFlag, fieldValue)
 
 		return &ConfigEntry{
 			Kind:          KindField,
 			Name:          getFieldName(field),
 			Required:      isFieldRequired(field),
-			FieldFlag:     fieldFlag.Name,
-			FieldDesc:     fieldFlag.Usage,
+			FieldFlag:     name,
+			FieldDesc:     getFieldDescription(field, usage),
 			FieldType:     "string",
-			FieldDefault:  getFieldDefault(field, fieldFlag.DefValue),
-			FieldCategory: getFieldCategory(field, fieldFlag.Name),
+			FieldTypeSpec: scalarType("string"),
+			FieldDefault:  getFieldDefault(field, defValue),
+			FieldCategory: getFieldCategory(field, name),
+			FieldSecret:   true,
 		}, nil
 	}
 	if field.Type == reflect.TypeOf(model.Duration(0)) {
@@ -550,16 +1602,18 @@ func getCustomFieldEntry(field reflect.StructField, fieldValue reflect.Value, fl
 		if err != nil {
 			return nil, err
 		}
+		name, usage, defValue := flagOrValue(fieldFlag, fieldValue)
 
 		return &ConfigEntry{
 			Kind:          KindField,
 			Name:          getFieldName(field),
 			Required:      isFieldRequired(field),
-			FieldFlag:     fieldFlag.Name,
-			FieldDesc:     fieldFlag.Usage,
+			FieldFlag:     name,
+			FieldDesc:     getFieldDescription(field, usage),
 			FieldType:     "duration",
-			FieldDefault:  getFieldDefault(field, fieldFlag.DefValue),
-			FieldCategory: getFieldCategory(field, fieldFlag.Name),
+			FieldTypeSpec: scalarType("duration"),
+			FieldDefault:  getFieldDefault(field, defValue),
+			FieldCategory: getFieldCategory(field, name),
 		}, nil
 	}
 	if field.Type == reflect.TypeOf(flagext.Time{}) {
@@ -567,6 +1621,53 @@ func getCustomFieldEntry(field reflect.StructField, fieldValue reflect.Value, fl
 		if err != nil {
 			return nil, err
 		}
+		name, usage, defValue := flagOrValue(fieldFlag, fieldValue)
+
+		return &ConfigEntry{
+			Kind:          KindField,
+			Name:          getFieldName(field),
+			Required:      isFieldRequired(field),
+			FieldFlag:     name,
+			FieldDesc:     getFieldDescription(field, usage),
+			FieldType:     "time",
+			FieldTypeSpec: scalarType("time"),
+			FieldDefault:  getTimeDefault(field, defValue),
+			FieldCategory: getFieldCategory(field, name),
+		}, nil
+	}
+	if field.Type == reflect.TypeOf(flagext.DayValue{}) {
+		fieldFlag, err := getFieldFlag(field, fieldValue, flags)
+		if err != nil {
+			return nil, err
+		}
+		name, usage, defValue := flagOrValue(fieldFlag, fieldValue)
+
+		// An unset day has no meaningful default, rather than the Unix epoch.
+		fallback := defValue
+		if !fieldValue.Addr().Interface().(*flagext.DayValue).IsSet() {
+			fallback = ""
+		}
+
+		return &ConfigEntry{
+			Kind:          KindField,
+			Name:          getFieldName(field),
+			Required:      isFieldRequired(field),
+			FieldFlag:     name,
+			FieldDesc:     getFieldDescription(field, usage),
+			FieldType:     "date",
+			FieldTypeSpec: scalarType("date"),
+			FieldDefault:  getDateDefault(field, fallback),
+			FieldCategory: getFieldCategory(field, name),
+		}, nil
+	}
+
+	// Any other struct implementing flag.Value, and registered as a flag, is
+	// documented as a string, like the flag itself.
+	if _, custom := getCustomFieldType(field.Type); !custom && isFlagValueStruct(field.Type) {
+		fieldFlag, err := getFieldFlag(field, fieldValue, flags)
+		if err != nil || fieldFlag == nil {
+			return nil, err
+		}
 
 		return &ConfigEntry{
 			Kind:          KindField,
@@ -574,7 +1675,8 @@ func getCustomFieldEntry(field reflect.StructField, fieldValue reflect.Value, fl
 			Required:      isFieldRequired(field),
 			FieldFlag:     fieldFlag.Name,
 			FieldDesc:     fieldFlag.Usage,
-			FieldType:     "time",
+			FieldType:     "string",
+			FieldTypeSpec: scalarType("string"),
 			FieldDefault:  getFieldDefault(field, fieldFlag.DefValue),
 			FieldCategory: getFieldCategory(field, fieldFlag.Name),
 		}, nil
@@ -583,6 +1685,26 @@ func getCustomFieldEntry(field reflect.StructField, fieldValue reflect.Value, fl
 	return nil, nil
 }
 
+// flagOrValue returns the name, the usage and the default of the CLI flag of the field, if any.
+// Otherwise, like for the fields tagged as nocli, it returns an empty name and usage, and the
+// value held by the config as the default. The field must implement flag.Value by pointer.
+func flagOrValue(fieldFlag *flag.Flag, fieldValue reflect.Value) (name, usage, defValue string) {
+	if fieldFlag == nil {
+		return "", "", fieldValue.Addr().Interface().(flag.Value).String()
+	}
+	return fieldFlag.Name, fieldFlag.Usage, fieldFlag.DefValue
+}
+
+var flagValueType = reflect.TypeOf((*flag.Value)(nil)).Elem()
+
+// isFlagValueStruct returns whether t is a struct implementing flag.Value, either
+// by value or by pointer.
+func isFlagValueStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && (t.Implements(flagValueType) || reflect.PtrTo(t).Implements(flagValueType))
+}
+
+// getFieldCategory returns the category of the field, as overridden for its CLI flag name through
+// the fieldcategory package if any, which takes precedence over the category struct tag.
 func getFieldCategory(field reflect.StructField, name string) string {
 	if category, ok := fieldcategory.GetOverride(name); ok {
 		return category.String()
@@ -590,6 +1712,54 @@ func getFieldCategory(field reflect.StructField, name string) string {
 	return field.Tag.Get("category")
 }
 
+// validateFieldCategory returns an error if the category struct tag of the field, if any, is
+// neither one of the categories of the fieldcategory package nor one of the extra ones. The tag
+// is validated even if the category of the field is overridden, since it's still misleading.
+// Categories returns the values accepted by the category struct tag: the categories of the
+// fieldcategory package, followed by the extra ones.
+func Categories(extra []string) []string {
+	var valid []string
+	for _, c := range fieldcategory.All() {
+		valid = append(valid, c.String())
+	}
+	return append(valid, extra...)
+}
+
+func validateFieldCategory(field reflect.StructField, extra []string) error {
+	category := field.Tag.Get("category")
+	if category == "" {
+		return nil
+	}
+
+	valid := Categories(extra)
+	for _, v := range valid {
+		if category == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("field %s: unknown category %q, valid categories are: %s", field.Name, category, strings.Join(valid, ", "))
+}
+
+func getFieldFeature(field reflect.StructField) string {
+	return getDocTagValue(field, "feature")
+}
+
+// getFieldDeprecatedInFavorOf returns the replacement of the field, if it's tagged as deprecated.
+func getFieldDeprecatedInFavorOf(field reflect.StructField) string {
+	return getDocTagValue(field, "deprecated")
+}
+
+// getFieldConflictsWith returns the fields which can't be set together with the field,
+// one for each "conflicts_with" doc tag.
+func getFieldConflictsWith(field reflect.StructField) []string {
+	return getDocTagValues(field, "conflicts_with")
+}
+
+// getFieldWarnings returns the warnings of the field, one for each "warning" doc tag.
+func getFieldWarnings(field reflect.StructField) []string {
+	return getDocTagValues(field, "warning")
+}
+
 func getFieldDefault(field reflect.StructField, fallback string) string {
 	if v := getDocTagValue(field, "default"); v != "" {
 		return v
@@ -598,6 +1768,438 @@ func getFieldDefault(field reflect.StructField, fallback string) string {
 	return fallback
 }
 
+// formatDefault returns the default of a field formatted for humans according to its unit,
+// like "1GiB" for a size in bytes, along with the raw default if it has been formatted.
+// Defaults which aren't numbers, like the ones documented through the doc tag, are kept.
+func formatDefault(value, unit string) (formatted, raw string) {
+	if unit != "bytes" {
+		return value, ""
+	}
+
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return value, ""
+	}
+	return FormatBytes(n), value
+}
+
+// getTimeDefault returns the default of a time field formatted as RFC3339,
+// or an empty string if the default is the zero time.
+func getTimeDefault(field reflect.StructField, fallback string) string {
+	value := getFieldDefault(field, fallback)
+
+	var t flagext.Time
+	if err := t.Set(value); err != nil || time.Time(t).IsZero() {
+		return ""
+	}
+	return time.Time(t).Format(time.RFC3339)
+}
+
+// getLabelsDefault returns the default of a label set, label names, label values or label
+// matchers field formatted as a YAML flow map or list, like {a: b} or [a, b], and whether
+// the field is such a field.
+// The default is taken from the field value, since these types don't format as YAML.
+func getLabelsDefault(field reflect.StructField, fieldValue reflect.Value) (string, bool, error) {
+	if !fieldValue.CanInterface() {
+		return "", false, nil
+	}
+
+	var value interface{}
+	switch v := fieldValue.Interface().(type) {
+	case model.LabelSet:
+		value = map[model.LabelName]model.LabelValue(v)
+	case labels.Labels:
+		value = v.Map()
+	case []model.LabelName:
+		value = v
+	case model.LabelNames:
+		value = []model.LabelName(v)
+	case []model.LabelValue:
+		value = v
+	case model.LabelValues:
+		value = []model.LabelValue(v)
+	case []*labels.Matcher:
+		value = matcherStrings(v)
+	case labels.Selector:
+		value = matcherStrings(v)
+	default:
+		return "", false, nil
+	}
+
+	if v := getDocTagValue(field, "default"); v != "" {
+		return v, true, nil
+	}
+
+	def, err := getFlowDefault(field, value)
+	return def, true, err
+}
+
+// getMapDefault returns the default of a map field without a CLI flag formatted as a YAML
+// flow map, like {1: a}, or an empty string if the field isn't a map or the map is empty.
+func getMapDefault(field reflect.StructField, fieldValue reflect.Value) (string, error) {
+	if _, isCustom := getFieldCustomType(field.Type); isCustom || field.Type.Kind() != reflect.Map {
+		return "", nil
+	}
+	if v := getDocTagValue(field, "default"); v != "" {
+		return v, nil
+	}
+	if !fieldValue.CanInterface() || fieldValue.Len() == 0 {
+		return "", nil
+	}
+
+	return getFlowDefault(field, fieldValue.Interface())
+}
+
+// getFlowDefault returns the value formatted in the YAML flow style.
+func getFlowDefault(field reflect.StructField, value interface{}) (string, error) {
+	node := &yaml.Node{}
+	if err := node.Encode(value); err != nil {
+		return "", errors.Wrapf(err, "field %s: can't encode default", field.Name)
+	}
+	node.Style = yaml.FlowStyle
+
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return "", errors.Wrapf(err, "field %s: can't marshal default", field.Name)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// matcherStrings returns the matchers formatted like in a PromQL selector, like `job="mimir"`.
+func matcherStrings(matchers []*labels.Matcher) []string {
+	values := make([]string, 0, len(matchers))
+	for _, m := range matchers {
+		values = append(values, m.String())
+	}
+	return values
+}
+
+// getDateDefault returns the default of a date field formatted as YYYY-MM-DD in UTC,
+// or an empty string if there's no default.
+func getDateDefault(field reflect.StructField, fallback string) string {
+	value := getFieldDefault(field, fallback)
+
+	var t flagext.Time
+	if err := t.Set(value); err != nil || time.Time(t).IsZero() {
+		return ""
+	}
+	return time.Time(t).UTC().Format("2006-01-02")
+}
+
+// getFieldSentinels parses the "sentinel" doc tag, in the form "0:disabled,-1:unlimited",
+// into a map of the field's special values to their meaning. Values are formatted the same
+// way as flag defaults, so that they can be compared with the field default.
+func getFieldSentinels(field reflect.StructField) (map[string]string, error) {
+	tag := getDocTagValue(field, "sentinel")
+	if tag == "" {
+		return nil, nil
+	}
+
+	sentinels := map[string]string{}
+	for _, sentinel := range strings.Split(tag, ",") {
+		parts := strings.SplitN(sentinel, ":", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, fmt.Errorf("field %s: invalid sentinel %q, expected value:meaning", field.Name, sentinel)
+		}
+
+		value, err := formatSentinelValue(field.Type, parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("field %s: invalid sentinel value %q: %w", field.Name, parts[0], err)
+		}
+		sentinels[value] = parts[1]
+	}
+
+	return sentinels, nil
+}
+
+// zeroDuration is the zero value of time.Duration and model.Duration fields, formatted like their defaults.
+const zeroDuration = "0s"
+
+// getFieldZeroMeaning returns the "zero" doc tag of a duration field, either a time.Duration
+// or a model.Duration, documenting the meaning of its zero value, like "disabled".
+func getFieldZeroMeaning(field reflect.StructField) (string, error) {
+	meaning := getDocTagValue(field, "zero")
+	if meaning == "" {
+		return "", nil
+	}
+
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t != reflect.TypeOf(time.Duration(0)) && t != reflect.TypeOf(model.Duration(0)) {
+		return "", fmt.Errorf("field %s: zero meaning is not supported for %s fields", field.Name, field.Type)
+	}
+	return meaning, nil
+}
+
+func formatSentinelValue(t reflect.Type, value string) (string, error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(value)
+		return d.String(), err
+	case reflect.TypeOf(model.Duration(0)):
+		d, err := model.ParseDuration(value)
+		return d.String(), err
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(value, 10, t.Bits())
+		return strconv.FormatInt(v, 10), err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(value, 10, t.Bits())
+		return strconv.FormatUint(v, 10), err
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(value, t.Bits())
+		return strconv.FormatFloat(v, 'g', -1, t.Bits()), err
+	case reflect.Bool:
+		v, err := strconv.ParseBool(value)
+		return strconv.FormatBool(v), err
+	case reflect.String:
+		return value, nil
+	default:
+		return "", fmt.Errorf("sentinels are not supported for %s fields", t)
+	}
+}
+
+// DefaultChange is a past change of the default value of a field.
+type DefaultChange struct {
+	// The version the default changed in, like "2.5".
+	Version string
+	// The default value before the change.
+	Old string
+}
+
+// getFieldDefaultChanges parses the "default_changed" doc tags, each one in the form
+// "version:old-value", like "2.5:5m". The old value is validated against the field type,
+// if it's a scalar one.
+func getFieldDefaultChanges(field reflect.StructField) ([]DefaultChange, error) {
+	var changes []DefaultChange
+	for _, tag := range getDocTagValues(field, "default_changed") {
+		parts := strings.SplitN(tag, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("field %s: invalid default change %q, expected version:old-value", field.Name, tag)
+		}
+		if !isValidVersion(parts[0]) {
+			return nil, fmt.Errorf("field %s: invalid default change version %q, expected major.minor[.patch]", field.Name, parts[0])
+		}
+
+		old := parts[1]
+		if t := field.Type; isScalarKind(t.Kind()) || (t.Kind() == reflect.Ptr && isScalarKind(t.Elem().Kind())) {
+			var err error
+			if old, err = formatSentinelValue(t, old); err != nil {
+				return nil, fmt.Errorf("field %s: invalid default change value %q: %w", field.Name, parts[1], err)
+			}
+		}
+
+		changes = append(changes, DefaultChange{Version: parts[0], Old: old})
+	}
+	return changes, nil
+}
+
+// isValidVersion returns whether v is a release version, like "2.5" or "2.5.1".
+func isValidVersion(v string) bool {
+	parts := strings.Split(v, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return false
+	}
+	for _, part := range parts {
+		if _, err := strconv.ParseUint(part, 10, 32); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// BitFlag is a named option of a bit flags field.
+type BitFlag struct {
+	Value uint64
+	Name  string
+}
+
+// getFieldBits parses the "bits" doc tag of an integer field storing bit flags, like
+// "bits=1:foo,2:bar,4:baz". The values must be unique powers of two.
+func getFieldBits(field reflect.StructField) ([]BitFlag, error) {
+	tag := getDocTagValue(field, "bits")
+	if tag == "" {
+		return nil, nil
+	}
+
+	switch field.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return nil, fmt.Errorf("field %s: bits are not supported for %s fields", field.Name, field.Type)
+	}
+
+	var bits []BitFlag
+	values := map[uint64]struct{}{}
+	names := map[string]struct{}{}
+	for _, bit := range strings.Split(tag, ",") {
+		parts := strings.SplitN(bit, ":", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, fmt.Errorf("field %s: invalid bit %q, expected value:name", field.Name, bit)
+		}
+
+		value, err := strconv.ParseUint(parts[0], 10, field.Type.Bits())
+		if err != nil {
+			return nil, fmt.Errorf("field %s: invalid bit value %q: %w", field.Name, parts[0], err)
+		}
+		if value == 0 || value&(value-1) != 0 {
+			return nil, fmt.Errorf("field %s: bit value %d is not a power of two", field.Name, value)
+		}
+		if _, ok := values[value]; ok {
+			return nil, fmt.Errorf("field %s: duplicated bit value %d", field.Name, value)
+		}
+		if _, ok := names[parts[1]]; ok {
+			return nil, fmt.Errorf("field %s: duplicated bit name %q", field.Name, parts[1])
+		}
+		values[value] = struct{}{}
+		names[parts[1]] = struct{}{}
+
+		bits = append(bits, BitFlag{Value: value, Name: parts[1]})
+	}
+
+	sort.Slice(bits, func(i, j int) bool { return bits[i].Value < bits[j].Value })
+	return bits, nil
+}
+
+// getFieldUnit returns the "unit" doc tag of a numeric field, documented as an int or a float.
+func getFieldUnit(field reflect.StructField, fieldType *TypeSpec) (string, error) {
+	unit := getDocTagValue(field, "unit")
+	if unit == "" {
+		return "", nil
+	}
+
+	if fieldType == nil || fieldType.Kind != TypeScalar || (fieldType.Name != "int" && fieldType.Name != "float") {
+		return "", fmt.Errorf("field %s: unit is not supported for %s fields", field.Name, field.Type)
+	}
+	return unit, nil
+}
+
+// getFieldBounds parses the "min" and "max" doc tags of a numeric field, documented as an int
+// or a float, into its inclusive bounds. The bounds must be valid values of the field type.
+func getFieldBounds(field reflect.StructField, fieldType *TypeSpec) (min, max *float64, err error) {
+	minTag, maxTag := getDocTagValue(field, "min"), getDocTagValue(field, "max")
+	if minTag == "" && maxTag == "" {
+		return nil, nil, nil
+	}
+
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if fieldType.Kind != TypeScalar || (fieldType.Name != "int" && fieldType.Name != "float") {
+		return nil, nil, fmt.Errorf("field %s: bounds are not supported for %s fields", field.Name, field.Type)
+	}
+
+	if min, err = parseBound(t, minTag); err != nil {
+		return nil, nil, fmt.Errorf("field %s: invalid min %q: %w", field.Name, minTag, err)
+	}
+	if max, err = parseBound(t, maxTag); err != nil {
+		return nil, nil, fmt.Errorf("field %s: invalid max %q: %w", field.Name, maxTag, err)
+	}
+	if min != nil && max != nil && *min > *max {
+		return nil, nil, fmt.Errorf("field %s: min %s is greater than max %s", field.Name, minTag, maxTag)
+	}
+	return min, max, nil
+}
+
+// parseBound parses a bound of a numeric field of type t, or returns nil if the bound is empty.
+func parseBound(t reflect.Type, value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var bound float64
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(value, 10, t.Bits())
+		if err != nil {
+			return nil, err
+		}
+		bound = float64(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(value, 10, t.Bits())
+		if err != nil {
+			return nil, err
+		}
+		bound = float64(v)
+	default:
+		v, err := strconv.ParseFloat(value, t.Bits())
+		if err != nil {
+			return nil, err
+		}
+		bound = v
+	}
+	return &bound, nil
+}
+
+// checkDefaultInBounds returns an error if the default of a bounded field is outside of its bounds.
+// Defaults which aren't numbers, like the ones overridden by the "default" doc tag, aren't checked.
+func checkDefaultInBounds(field reflect.StructField, fieldDefault string, min, max *float64) error {
+	if min == nil && max == nil {
+		return nil
+	}
+
+	v, err := strconv.ParseFloat(fieldDefault, 64)
+	if err != nil {
+		return nil
+	}
+	if (min != nil && v < *min) || (max != nil && v > *max) {
+		return fmt.Errorf("field %s: default %s is outside of its bounds", field.Name, fieldDefault)
+	}
+	return nil
+}
+
+// getBitsDefault returns the names of the bits set in the value of a bit flags field, as a YAML flow list.
+func getBitsDefault(field reflect.StructField, fieldValue reflect.Value, bits []BitFlag) (string, error) {
+	if v := getDocTagValue(field, "default"); v != "" {
+		return v, nil
+	}
+
+	var value uint64
+	switch fieldValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value = uint64(fieldValue.Int())
+	default:
+		value = fieldValue.Uint()
+	}
+
+	var names []string
+	for _, bit := range bits {
+		if value&bit.Value != 0 {
+			names = append(names, bit.Name)
+			value &^= bit.Value
+		}
+	}
+	if value != 0 {
+		return "", fmt.Errorf("field %s: default has bits %d not matching any name", field.Name, value)
+	}
+
+	return "[" + strings.Join(names, ", ") + "]", nil
+}
+
+// acceptsBitNames returns whether the type of a bit flags field implements encoding.TextUnmarshaler
+// accepting the comma-separated names of the bits, so that it can be configured as a list of names.
+func acceptsBitNames(t reflect.Type, bits []BitFlag) bool {
+	u, ok := reflect.New(t).Interface().(encoding.TextUnmarshaler)
+	if !ok {
+		return false
+	}
+
+	names := make([]string, 0, len(bits))
+	for _, bit := range bits {
+		names = append(names, bit.Name)
+	}
+	return u.UnmarshalText([]byte(strings.Join(names, ","))) == nil
+}
+
 func isFieldHidden(f reflect.StructField) bool {
 	return getDocTagFlag(f, "hidden")
 }
@@ -606,12 +2208,105 @@ func isAbsentInCLI(f reflect.StructField) bool {
 	return getDocTagFlag(f, "nocli")
 }
 
+func isFieldAlias(f reflect.StructField) bool {
+	return getDocTagFlag(f, "alias")
+}
+
+func isFieldReloadable(f reflect.StructField) bool {
+	return getDocTagFlag(f, "reloadable")
+}
+
+func isFieldStartupOnly(f reflect.StructField) bool {
+	return getDocTagFlag(f, "startup-only")
+}
+
 func isFieldRequired(f reflect.StructField) bool {
 	return getDocTagFlag(f, "required")
 }
 
+// isFieldInline returns whether the fields of the struct field are promoted to its parent
+// block, because of the inline yaml tag option. Like for yaml.v2, which loads the config,
+// embedded structs aren't inlined unless tagged so.
 func isFieldInline(f reflect.StructField) bool {
-	return yamlFieldInlineParser.MatchString(f.Tag.Get("yaml"))
+	return parseYAMLTag(f).inline
+}
+
+func isEmbeddedStruct(field reflect.StructField) bool {
+	if !field.Anonymous {
+		return false
+	}
+
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// structField is a field of a config struct, along with its value.
+type structField struct {
+	field reflect.StructField
+	value reflect.Value
+
+	// The labels of the unexported embedded structs the field has been promoted
+	// from, from the outermost to the innermost one.
+	embeddedFrom []string
+}
+
+// structFields returns the fields of the struct type t, whose value is v. The unexported
+// structs embedded in t, which must be tagged as inline, are replaced by their own fields, which are promoted to t: such
+// fields can't be recursed into like inline structs, because taking the address of an
+// unexported field doesn't allow to use it, while its exported fields can be accessed.
+func structFields(t reflect.Type, v reflect.Value, embeddedFrom []string) ([]structField, error) {
+	fields := make([]structField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		if !isUnexportedEmbeddedStruct(field) {
+			fields = append(fields, structField{field: field, value: value, embeddedFrom: embeddedFrom})
+			continue
+		}
+		if isFieldHidden(field) || field.Tag.Get("yaml") == "-" {
+			continue
+		}
+		// Like the exported ones, yaml.v2 only inlines the unexported embedded structs tagged as inline.
+		if !isFieldInline(field) {
+			if _, ok := field.Tag.Lookup("yaml"); !ok {
+				return nil, fmt.Errorf("embedded field %s has no yaml tag, while it's only inlined if tagged as inline", field.Type)
+			}
+			return nil, fmt.Errorf("embedded field %s is an unexported struct not tagged as inline, whose fields can't be documented", field.Type)
+		}
+		if field.Type.Kind() == reflect.Ptr {
+			return nil, fmt.Errorf("embedded field %s is a pointer to an unexported struct, whose fields can't be documented", field.Type)
+		}
+
+		label := getInlineLabel(field)
+		promoted, err := structFields(field.Type, value, append(append([]string(nil), embeddedFrom...), label))
+		if err != nil {
+			return nil, errors.Wrapf(err, "embedded field %s", field.Type)
+		}
+		fields = append(fields, promoted...)
+	}
+	return fields, nil
+}
+
+func isUnexportedEmbeddedStruct(field reflect.StructField) bool {
+	return field.PkgPath != "" && isEmbeddedStruct(field)
+}
+
+// getInlineLabel returns the label of an inline struct field, which defaults
+// to the struct type name unless overridden by the "label" doc tag.
+func getInlineLabel(f reflect.StructField) string {
+	if label := getDocTagValue(f, "label"); label != "" {
+		return label
+	}
+
+	t := f.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
 }
 
 func getFieldDescription(f reflect.StructField, fallback string) string {
@@ -632,6 +2327,17 @@ func isRootBlock(t reflect.Type, rootBlocks []RootBlock) (string, string, bool)
 	return "", "", false
 }
 
+// getRefBlock returns the name of the root block referenced by t, looking through
+// pointers, slices and maps, or an empty string if t doesn't reference a root block.
+func getRefBlock(t reflect.Type, rootBlocks []RootBlock) string {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+
+	name, _, _ := isRootBlock(t, rootBlocks)
+	return name
+}
+
 func getDocTagFlag(f reflect.StructField, name string) bool {
 	cfg := parseDocTag(f)
 	_, ok := cfg[name]
@@ -643,6 +2349,67 @@ func getDocTagValue(f reflect.StructField, name string) string {
 	return cfg[name]
 }
 
+// getDocTagValues returns the values of all the occurrences of the doc tag with the input name.
+func getDocTagValues(f reflect.StructField, name string) []string {
+	var values []string
+	for _, entry := range strings.Split(f.Tag.Get("doc"), "|") {
+		if parts := strings.SplitN(entry, "=", 2); len(parts) == 2 && parts[0] == name {
+			values = append(values, parts[1])
+		}
+	}
+	return values
+}
+
+// docTagKeys are the keys supported by the doc struct tag.
+var docTagKeys = map[string]struct{}{
+	"alias":           {},
+	"bits":            {},
+	"conflicts_with":  {},
+	"default":         {},
+	"default_changed": {},
+	"deprecated":      {},
+	"description":     {},
+	"feature":         {},
+	"hidden":          {},
+	"label":           {},
+	"max":             {},
+	"min":             {},
+	"nocli":           {},
+	"reloadable":      {},
+	"required":        {},
+	"sentinel":        {},
+	"startup-only":    {},
+	"unit":            {},
+	"warning":         {},
+	"zero":            {},
+}
+
+// DocTagKeys returns the sorted keys supported by the doc struct tag.
+func DocTagKeys() []string {
+	keys := make([]string, 0, len(docTagKeys))
+	for key := range docTagKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validateDocTag returns an error if the doc tag of the field has a key not listed in docTagKeys.
+func validateDocTag(f reflect.StructField) error {
+	tag := f.Tag.Get("doc")
+	if tag == "" {
+		return nil
+	}
+
+	for _, entry := range strings.Split(tag, "|") {
+		key := strings.SplitN(entry, "=", 2)[0]
+		if _, ok := docTagKeys[key]; !ok {
+			return fmt.Errorf("field %s: unknown doc tag key %q", f.Name, key)
+		}
+	}
+	return nil
+}
+
 func parseDocTag(f reflect.StructField) map[string]string {
 	cfg := map[string]string{}
 	tag := f.Tag.Get("doc")
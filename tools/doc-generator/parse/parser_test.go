@@ -0,0 +1,1913 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/logging"
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/mimir/pkg/ingester/activeseries"
+)
+
+func TestConfig_TimeAndDateFields(t *testing.T) {
+	type timeConfig struct {
+		Zero     time.Time        `yaml:"zero"`
+		NonUTC   time.Time        `yaml:"non_utc"`
+		Day      flagext.DayValue `yaml:"day"`
+		UnsetDay flagext.DayValue `yaml:"unset_day"`
+
+		// Fields without a CLI flag are documented with the value held by the config.
+		NoCLIDay   flagext.DayValue `yaml:"nocli_day" doc:"nocli|description=The day."`
+		NoCLITime  time.Time        `yaml:"nocli_time" doc:"nocli"`
+		NoCLIUnset flagext.DayValue `yaml:"nocli_unset" doc:"nocli"`
+	}
+
+	cfg := &timeConfig{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.Var((*flagext.Time)(&cfg.Zero), "zero", "")
+	cfg.NonUTC = time.Date(2022, 6, 15, 23, 30, 0, 0, time.FixedZone("", -5*60*60))
+	fs.Var((*flagext.Time)(&cfg.NonUTC), "non-utc", "")
+	cfg.Day = flagext.NewDayValue(model.TimeFromUnix(time.Date(2022, 6, 15, 12, 0, 0, 0, time.UTC).Unix()))
+	fs.Var(&cfg.Day, "day", "")
+	fs.Var(&cfg.UnsetDay, "unset-day", "")
+	cfg.NoCLIDay = cfg.Day
+	cfg.NoCLITime = cfg.NonUTC
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+
+	entries := map[string]*ConfigEntry{}
+	for _, entry := range blocks[0].Entries {
+		entries[entry.Name] = entry
+	}
+
+	assert.Equal(t, "time", entries["zero"].FieldType)
+	assert.Equal(t, "", entries["zero"].FieldDefault)
+
+	assert.Equal(t, "time", entries["non_utc"].FieldType)
+	assert.Equal(t, "2022-06-15T23:30:00-05:00", entries["non_utc"].FieldDefault)
+
+	assert.Equal(t, "date", entries["day"].FieldType)
+	assert.Equal(t, "2022-06-15", entries["day"].FieldDefault)
+
+	assert.Equal(t, "date", entries["unset_day"].FieldType)
+	assert.Equal(t, "", entries["unset_day"].FieldDefault)
+
+	assert.Equal(t, "date", entries["nocli_day"].FieldType)
+	assert.Equal(t, "", entries["nocli_day"].FieldFlag)
+	assert.Equal(t, "The day.", entries["nocli_day"].FieldDesc)
+	assert.Equal(t, "2022-06-15", entries["nocli_day"].FieldDefault)
+
+	assert.Equal(t, "time", entries["nocli_time"].FieldType)
+	assert.Equal(t, "", entries["nocli_time"].FieldFlag)
+	assert.Equal(t, "2022-06-15T23:30:00-05:00", entries["nocli_time"].FieldDefault)
+
+	assert.Equal(t, "", entries["nocli_unset"].FieldDefault)
+}
+
+func TestGetTimeDefault(t *testing.T) {
+	field := reflectField(struct {
+		Time time.Time `doc:"default=2022-01-02T03:04:05+02:00"`
+	}{}, "Time")
+
+	assert.Equal(t, "2022-01-02T03:04:05+02:00", getTimeDefault(field, ""))
+	assert.Equal(t, "", getTimeDefault(reflectField(struct{ Time time.Time }{}, "Time"), "0"))
+}
+
+func TestGetDateDefault_NonUTC(t *testing.T) {
+	field := reflectField(struct {
+		Day flagext.DayValue `doc:"default=2022-06-15T23:30:00-05:00"`
+	}{}, "Day")
+
+	// Days are always in UTC.
+	assert.Equal(t, "2022-06-16", getDateDefault(field, ""))
+}
+
+func TestReflectType_TimeAndDate(t *testing.T) {
+	assert.Equal(t, reflect.TypeOf(&flagext.Time{}), ReflectType(scalarType("time")))
+	assert.Equal(t, reflect.TypeOf(flagext.DayValue{}), ReflectType(scalarType("date")))
+}
+
+func TestGetFieldType_MapOfStringToFloat64(t *testing.T) {
+	typ := reflect.TypeOf(map[string]float64{})
+
+	fieldType, err := getFieldType(typ)
+	require.NoError(t, err)
+	assert.Equal(t, "map of string to float64", fieldType.String())
+
+	// The type must round-trip through ReflectType.
+	assert.Equal(t, typ, ReflectType(fieldType))
+}
+
+func TestGetFieldType_MapKeys(t *testing.T) {
+	type tier string
+
+	tests := map[string]struct {
+		typ      reflect.Type
+		expected string
+	}{
+		"int32 keys":  {typ: reflect.TypeOf(map[int32]string{}), expected: "map of int to string"},
+		"uint64 keys": {typ: reflect.TypeOf(map[uint64]int{}), expected: "map of int to int"},
+		"typed keys":  {typ: reflect.TypeOf(map[tier]int{}), expected: "map of string to int"},
+		"float keys":  {typ: reflect.TypeOf(map[int]float64{}), expected: "map of int to float64"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fieldType, err := getFieldType(test.typ)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, fieldType.String())
+
+			// The type must resolve through ReflectType.
+			assert.Equal(t, test.typ.Key().Kind() == reflect.String, ReflectType(fieldType).Key().Kind() == reflect.String)
+			assert.Equal(t, test.typ.Elem().Kind(), ReflectType(fieldType).Elem().Kind())
+		})
+	}
+}
+
+func TestConfig_MapWithIntegerKeys(t *testing.T) {
+	type config struct {
+		Shards     map[int32]string `yaml:"shards"`
+		Empty      map[int32]string `yaml:"empty"`
+		Overridden map[int]int      `yaml:"overridden" doc:"default={0: 1}"`
+	}
+
+	cfg := &config{
+		Shards: map[int32]string{10: "b", 2: "a"},
+	}
+
+	blocks, err := Config(cfg, nil, nil)
+	require.NoError(t, err)
+	entries := blocks[0].Entries
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, "map of int to string", entries[0].FieldType)
+	assert.Equal(t, "{2: a, 10: b}", entries[0].FieldDefault)
+	assert.Equal(t, "", entries[1].FieldDefault)
+	assert.Equal(t, "map of int to int", entries[2].FieldType)
+	assert.Equal(t, "{0: 1}", entries[2].FieldDefault)
+	assert.Equal(t, reflect.TypeOf(map[int]string{}), ReflectType(entries[0].FieldTypeSpec))
+}
+
+func TestConfig_MapWithStructKeys(t *testing.T) {
+	type key struct {
+		ID int
+	}
+
+	cfg := &struct {
+		Shards map[key]string `yaml:"shards"`
+	}{}
+
+	_, err := Config(cfg, nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field=Shards: unsupported map key type parse.key")
+}
+
+func TestConfig_LabelTypes(t *testing.T) {
+	type config struct {
+		ExternalLabels model.LabelSet     `yaml:"external_labels"`
+		Labels         labels.Labels      `yaml:"labels"`
+		LabelNames     []model.LabelName  `yaml:"label_names"`
+		Empty          model.LabelSet     `yaml:"empty"`
+		Overridden     []model.LabelName  `yaml:"overridden" doc:"default=[cluster]"`
+		Names          model.LabelNames   `yaml:"names"`
+		Values         model.LabelValues  `yaml:"values"`
+		ValuesList     []model.LabelValue `yaml:"values_list"`
+		Matchers       []*labels.Matcher  `yaml:"matchers"`
+		Selector       labels.Selector    `yaml:"selector"`
+	}
+
+	cfg := &config{
+		ExternalLabels: model.LabelSet{"region": "eu-west", "cluster": "prod: 1"},
+		Labels:         labels.FromStrings("job", "mimir", "env", "dev"),
+		LabelNames:     []model.LabelName{"cluster", "namespace"},
+		Overridden:     []model.LabelName{"job"},
+		Names:          model.LabelNames{"job"},
+		Values:         model.LabelValues{"a", "b"},
+		Matchers: []*labels.Matcher{
+			labels.MustNewMatcher(labels.MatchEqual, "job", "mimir"),
+			labels.MustNewMatcher(labels.MatchRegexp, "env", "dev|prod"),
+		},
+	}
+
+	blocks, err := Config(cfg, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+	entries := blocks[0].Entries
+
+	expected := []struct {
+		fieldType    string
+		fieldDefault string
+	}{
+		{"map of string to string", `{cluster: 'prod: 1', region: eu-west}`},
+		{"map of string to string", "{env: dev, job: mimir}"},
+		{"list of string", "[cluster, namespace]"},
+		{"map of string to string", "{}"},
+		{"list of string", "[cluster]"},
+		{"list of string", "[job]"},
+		{"list of string", "[a, b]"},
+		{"list of string", "[]"},
+		{"list of label matcher", `[job="mimir", env=~"dev|prod"]`},
+		{"list of label matcher", "[]"},
+	}
+	require.Len(t, entries, len(expected))
+	for i, e := range expected {
+		assert.Equal(t, KindField, entries[i].Kind, entries[i].Name)
+		assert.Equal(t, e.fieldType, entries[i].FieldType, entries[i].Name)
+		assert.Equal(t, e.fieldDefault, entries[i].FieldDefault, entries[i].Name)
+	}
+
+	// The field types must resolve through ReflectType.
+	assert.Equal(t, reflect.TypeOf(map[string]string{}), ReflectType(entries[0].FieldTypeSpec))
+	assert.Equal(t, reflect.TypeOf(flagext.StringSliceCSV{}), ReflectType(entries[2].FieldTypeSpec))
+	assert.Equal(t, reflect.TypeOf([]*labels.Matcher{}), ReflectType(entries[8].FieldTypeSpec))
+}
+
+type exampleTrackers map[string]string
+
+func (exampleTrackers) ExampleDoc() (comment string, yaml interface{}) {
+	return "Two trackers.", map[string]string{"dev": `{namespace="dev"}`, "prod": `{namespace="prod"}`}
+}
+
+type InlineExampleConfig struct {
+	Trackers exampleTrackers `yaml:"trackers"`
+}
+
+type selfNestedExample map[string]string
+
+func (selfNestedExample) ExampleDoc() (comment string, yaml interface{}) {
+	return "", map[string]interface{}{"nested": map[string]string{"a": "b"}}
+}
+
+type recursiveExample map[string]string
+
+func (recursiveExample) ExampleDoc() (comment string, yaml interface{}) {
+	return "", map[string]interface{}{"trackers": exampleTrackers{}}
+}
+
+type cyclicExample map[string]string
+
+func (cyclicExample) ExampleDoc() (comment string, yaml interface{}) {
+	cycle := map[string]interface{}{}
+	cycle["next"] = cycle
+	return "", cycle
+}
+
+func TestConfig_ExampleInInlineStruct(t *testing.T) {
+	cfg := &struct {
+		Block struct {
+			InlineExampleConfig `yaml:",inline"`
+		} `yaml:"block"`
+	}{}
+
+	blocks, err := Config(cfg, nil, nil)
+	require.NoError(t, err)
+
+	entry := blocks[0].Entries[0].Block.Entries[0]
+	assert.Equal(t, "trackers", entry.Name)
+	require.NotNil(t, entry.FieldExample)
+	assert.Equal(t, map[string]interface{}{
+		"block": map[string]interface{}{
+			"trackers": map[string]string{"dev": `{namespace="dev"}`, "prod": `{namespace="prod"}`},
+		},
+	}, entry.FieldExample.Yaml)
+}
+
+func TestConfig_ExamplePaths(t *testing.T) {
+	type RootConfig struct {
+		Block struct {
+			Targets annotatedExample `yaml:"targets"`
+		} `yaml:"block"`
+	}
+
+	cfg := &struct {
+		// An example may be a single-entry map whose key is the name of the field.
+		Nested selfNestedExample `yaml:"nested"`
+		Root   RootConfig        `yaml:"root"`
+		List   []struct {
+			Trackers exampleTrackers `yaml:"trackers"`
+		} `yaml:"list"`
+	}{}
+
+	rootBlocks := []RootBlock{{Name: "root_config", StructType: reflect.TypeOf(RootConfig{})}}
+	blocks, err := Config(cfg, nil, rootBlocks)
+	require.NoError(t, err)
+	require.Len(t, blocks, 3)
+
+	assert.Equal(t, map[string]interface{}{
+		"nested": map[string]interface{}{"nested": map[string]string{"a": "b"}},
+	}, blocks[0].Entries[0].FieldExample.Yaml)
+
+	// The fields of root blocks are nested from the root block, which is documented on its own.
+	example := blocks[1].Entries[0].Block.Entries[0].FieldExample
+	data, err := example.YAML()
+	require.NoError(t, err)
+	assert.Equal(t, "block:\n"+
+		"    targets:\n"+
+		"        # The primary target.\n"+
+		"        - address: a:80\n"+
+		"        - address: b:80\n"+
+		"        # The fallback target,\n"+
+		"        # only used when the others are down.\n"+
+		"        - address: c:80\n", string(data))
+
+	// The fields of list elements are nested from the element.
+	assert.Equal(t, map[string]interface{}{
+		"trackers": map[string]string{"dev": `{namespace="dev"}`, "prod": `{namespace="prod"}`},
+	}, blocks[0].Entries[2].Element.Entries[0].FieldExample.Yaml)
+}
+
+func TestConfig_RelabelConfigsExample(t *testing.T) {
+	cfg := &struct {
+		MetricRelabelConfigs []*relabel.Config `yaml:"metric_relabel_configs"`
+	}{}
+
+	blocks, err := Config(cfg, nil, nil)
+	require.NoError(t, err)
+
+	entry := blocks[0].Entries[0]
+	assert.Equal(t, "relabel_config...", entry.FieldType)
+	require.NotNil(t, entry.FieldExample)
+	assert.NotEmpty(t, entry.FieldExample.Comment)
+	assert.Len(t, entry.FieldExample.ElementComments, 2)
+
+	// The example must be a valid config.
+	data, err := yaml.Marshal(entry.FieldExample.Yaml)
+	require.NoError(t, err)
+	require.NoError(t, yaml.UnmarshalStrict(data, cfg))
+	require.Len(t, cfg.MetricRelabelConfigs, 2)
+	assert.Equal(t, model.LabelNames{"__name__"}, cfg.MetricRelabelConfigs[0].SourceLabels)
+	assert.Equal(t, relabel.Drop, cfg.MetricRelabelConfigs[0].Action)
+	assert.Equal(t, "tenant_namespace", cfg.MetricRelabelConfigs[1].TargetLabel)
+	assert.Equal(t, relabel.Replace, cfg.MetricRelabelConfigs[1].Action)
+}
+
+type annotatedExample []string
+
+func (annotatedExample) ExampleDoc() (comment string, yaml interface{}) {
+	return "Three targets.", []ExampleEntry{
+		{Comment: "The primary target.", Yaml: map[string]string{"address": "a:80"}},
+		{Yaml: map[string]string{"address": "b:80"}},
+		{Comment: "The fallback target,\nonly used when the others are down.", Yaml: map[string]string{"address": "c:80"}},
+	}
+}
+
+func TestConfig_ExampleWithElementComments(t *testing.T) {
+	cfg := &struct {
+		Targets annotatedExample `yaml:"targets"`
+	}{}
+
+	blocks, err := Config(cfg, nil, nil)
+	require.NoError(t, err)
+
+	example := blocks[0].Entries[0].FieldExample
+	require.NotNil(t, example)
+	assert.Equal(t, "Three targets.", example.Comment)
+	assert.Equal(t, map[string]interface{}{"targets": []interface{}{
+		map[string]string{"address": "a:80"},
+		map[string]string{"address": "b:80"},
+		map[string]string{"address": "c:80"},
+	}}, example.Yaml)
+	assert.Equal(t, []string{"The primary target.", "", "The fallback target,\nonly used when the others are down."}, example.ElementComments)
+
+	data, err := example.YAML()
+	require.NoError(t, err)
+	assert.Equal(t, "targets:\n"+
+		"    # The primary target.\n"+
+		"    - address: a:80\n"+
+		"    - address: b:80\n"+
+		"    # The fallback target,\n"+
+		"    # only used when the others are down.\n"+
+		"    - address: c:80\n", string(data))
+}
+
+func TestConfig_InvalidExamples(t *testing.T) {
+	tests := map[string]struct {
+		cfg      interface{}
+		expected string
+	}{
+		"example containing another example provider": {
+			cfg: &struct {
+				Recursive recursiveExample `yaml:"recursive"`
+			}{},
+			expected: "field recursive: invalid example: contains a value of type parse.exampleTrackers, which provides its own example",
+		},
+		"cyclic example": {
+			cfg: &struct {
+				Cyclic cyclicExample `yaml:"cyclic"`
+			}{},
+			expected: "field cyclic: invalid example: exceeds the maximum depth of 32",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Config(test.cfg, nil, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.expected)
+		})
+	}
+}
+
+func TestParseYAMLTag(t *testing.T) {
+	tests := map[string]struct {
+		tag            reflect.StructTag
+		expected       yamlTag
+		expectedName   string
+		expectedInline bool
+	}{
+		"no tag": {
+			tag:          ``,
+			expectedName: "field",
+		},
+		"empty tag": {
+			tag:          `yaml:""`,
+			expectedName: "field",
+		},
+		"name only": {
+			tag:          `yaml:"ring"`,
+			expected:     yamlTag{name: "ring"},
+			expectedName: "ring",
+		},
+		"name with omitempty": {
+			tag:          `yaml:"ring,omitempty"`,
+			expected:     yamlTag{name: "ring", omitEmpty: true},
+			expectedName: "ring",
+		},
+		"name with flow": {
+			tag:          `yaml:"ring,flow"`,
+			expected:     yamlTag{name: "ring", flow: true},
+			expectedName: "ring",
+		},
+		"name with omitempty and flow": {
+			tag:          `yaml:"ring,omitempty,flow"`,
+			expected:     yamlTag{name: "ring", omitEmpty: true, flow: true},
+			expectedName: "ring",
+		},
+		"name with flow and omitempty": {
+			tag:          `yaml:"ring,flow,omitempty"`,
+			expected:     yamlTag{name: "ring", omitEmpty: true, flow: true},
+			expectedName: "ring",
+		},
+		"empty name with omitempty": {
+			tag:          `yaml:",omitempty"`,
+			expected:     yamlTag{omitEmpty: true},
+			expectedName: "field",
+		},
+		"empty name inline": {
+			tag:            `yaml:",inline"`,
+			expected:       yamlTag{inline: true},
+			expectedInline: true,
+		},
+		"inline with omitempty": {
+			tag:            `yaml:",inline,omitempty"`,
+			expected:       yamlTag{inline: true, omitEmpty: true},
+			expectedInline: true,
+		},
+		"omitempty with inline": {
+			tag:            `yaml:",omitempty,inline"`,
+			expected:       yamlTag{inline: true, omitEmpty: true},
+			expectedInline: true,
+		},
+		"explicit dash": {
+			tag:      `yaml:"-"`,
+			expected: yamlTag{name: "-"},
+		},
+		"dash as name": {
+			tag:          `yaml:"-,"`,
+			expected:     yamlTag{name: "-"},
+			expectedName: "-",
+		},
+		"unknown option": {
+			tag:          `yaml:"ring,unknown"`,
+			expected:     yamlTag{name: "ring"},
+			expectedName: "ring",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			field := reflect.StructField{Name: "Field", Tag: test.tag}
+
+			assert.Equal(t, test.expected, parseYAMLTag(field))
+			assert.Equal(t, test.expectedName, getFieldName(field))
+			assert.Equal(t, test.expectedInline, isFieldInline(field))
+		})
+	}
+}
+
+func TestGetFieldName_UntaggedNames(t *testing.T) {
+	tests := map[string]struct {
+		name     string
+		expected string
+	}{
+		"ascii exported":          {name: "Field", expected: "field"},
+		"ascii unexported":        {name: "field", expected: ""},
+		"non-ascii exported":      {name: "Ëntry", expected: "ëntry"},
+		"non-ascii unexported":    {name: "ëntry", expected: ""},
+		"non-letter first rune":   {name: "_Field", expected: ""},
+		"invalid utf8 first byte": {name: "\xffField", expected: ""},
+		"empty name":              {name: "", expected: ""},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, getFieldName(reflect.StructField{Name: test.name}))
+			assert.Equal(t, test.expected, getFieldName(reflect.StructField{Name: test.name, Tag: `yaml:",omitempty"`}))
+		})
+	}
+}
+
+func TestConfig_UnexportedFieldWithYAMLTag(t *testing.T) {
+	type config struct {
+		Exported   int `yaml:"exported"`
+		unexported int `yaml:"unexported"`
+		inner      struct {
+			Enabled bool `yaml:"enabled"`
+		} `yaml:"inner"`
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.IntVar(&cfg.Exported, "exported", 1, "The exported option.")
+	fs.IntVar(&cfg.unexported, "unexported", 2, "The unexported option.")
+
+	var blocks []*ConfigBlock
+	require.NotPanics(t, func() {
+		var err error
+		blocks, err = Config(cfg, testFlags(fs), nil)
+		require.NoError(t, err)
+	})
+
+	require.Len(t, blocks[0].Entries, 1)
+	assert.Equal(t, "exported", blocks[0].Entries[0].Name)
+}
+
+func TestConfig_FieldWithOptionsOnlyYAMLTag(t *testing.T) {
+	cfg := &struct {
+		Timeout int `yaml:",omitempty"`
+		Ëntry   int `yaml:",flow"`
+	}{}
+
+	blocks, err := Config(cfg, nil, nil)
+	require.NoError(t, err)
+
+	// Like the yaml package, fields are named after their lowercased name, rather than skipped.
+	require.Len(t, blocks[0].Entries, 2)
+	assert.Equal(t, "timeout", blocks[0].Entries[0].Name)
+	assert.True(t, blocks[0].Entries[0].OmitEmpty)
+	assert.Equal(t, "ëntry", blocks[0].Entries[1].Name)
+}
+
+// hostPortValue is a custom flag.Value, not known by the parser.
+type hostPortValue struct {
+	Host string
+	Port int
+}
+
+func (v *hostPortValue) String() string {
+	return fmt.Sprintf("%s:%d", v.Host, v.Port)
+}
+
+func (v *hostPortValue) Set(s string) error {
+	_, err := fmt.Sscanf(strings.Replace(s, ":", " ", 1), "%s %d", &v.Host, &v.Port)
+	return err
+}
+
+func TestConfig_CustomFlagValue(t *testing.T) {
+	type config struct {
+		Address    hostPortValue `yaml:"address"`
+		Unflagged  hostPortValue `yaml:"unflagged"`
+		Documented hostPortValue `yaml:"documented" doc:"default=<hostname>:80"`
+	}
+
+	cfg := &config{Address: hostPortValue{Host: "localhost", Port: 9095}}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.Var(&cfg.Address, "server.address", "The address to listen on.")
+	fs.Var(&cfg.Documented, "server.documented-address", "The documented address.")
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+	require.Len(t, blocks[0].Entries, 3)
+
+	address := blocks[0].Entries[0]
+	assert.Equal(t, KindField, address.Kind)
+	assert.Equal(t, "server.address", address.FieldFlag)
+	assert.Equal(t, "The address to listen on.", address.FieldDesc)
+	assert.Equal(t, "string", address.FieldType)
+	assert.Equal(t, "localhost:9095", address.FieldDefault)
+
+	// Without a registered flag, the struct is documented field by field.
+	assert.Equal(t, KindBlock, blocks[0].Entries[1].Kind)
+
+	assert.Equal(t, "string", blocks[0].Entries[2].FieldType)
+	assert.Equal(t, "<hostname>:80", blocks[0].Entries[2].FieldDefault)
+}
+
+func TestConfig_OmitEmpty(t *testing.T) {
+	type InlineConfig struct {
+		Inlined string `yaml:"inlined"`
+	}
+
+	cfg := &struct {
+		InlineConfig `yaml:",inline,omitempty"`
+		Ring         struct {
+			Address string `yaml:"address,omitempty"`
+		} `yaml:"ring,omitempty,flow"`
+		Name string `yaml:"name"`
+	}{}
+
+	blocks, err := Config(cfg, nil, nil)
+	require.NoError(t, err)
+	entries := blocks[0].Entries
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, "inlined", entries[0].Name)
+	assert.False(t, entries[0].OmitEmpty)
+	assert.Equal(t, "ring", entries[1].Name)
+	assert.True(t, entries[1].OmitEmpty)
+	assert.True(t, entries[1].Block.Entries[0].OmitEmpty)
+	assert.Equal(t, "name", entries[2].Name)
+	assert.False(t, entries[2].OmitEmpty)
+}
+
+func reflectField(v interface{}, name string) reflect.StructField {
+	field, _ := reflect.TypeOf(v).FieldByName(name)
+	return field
+}
+
+func TestConfig_RefBlock(t *testing.T) {
+	type rootConfig struct {
+		Address string `yaml:"address"`
+	}
+
+	type config struct {
+		Root      rootConfig            `yaml:"root"`
+		RootList  []rootConfig          `yaml:"root_list"`
+		RootByKey map[string]rootConfig `yaml:"root_by_key"`
+		Other     string                `yaml:"other"`
+	}
+
+	rootBlocks := []RootBlock{{
+		Name:       "root_config",
+		Desc:       "The root_config block.",
+		StructType: reflect.TypeOf(rootConfig{}),
+	}}
+
+	blocks, err := Config(&config{}, nil, rootBlocks)
+	require.NoError(t, err)
+	require.Len(t, blocks, 3)
+	assert.Equal(t, "root_config", blocks[1].Name)
+	assert.Equal(t, "root_config", blocks[2].Name)
+
+	entries := map[string]*ConfigEntry{}
+	for _, entry := range blocks[0].Entries {
+		entries[entry.Name] = entry
+	}
+
+	assert.Equal(t, KindBlock, entries["root"].Kind)
+	assert.True(t, entries["root"].Root)
+	assert.Equal(t, "root_config", entries["root"].RefBlock)
+
+	assert.Equal(t, KindSlice, entries["root_list"].Kind)
+	assert.Equal(t, "root_config", entries["root_list"].RefBlock)
+
+	assert.Equal(t, KindField, entries["root_by_key"].Kind)
+	assert.Equal(t, "root_config", entries["root_by_key"].RefBlock)
+
+	assert.Equal(t, "", entries["other"].RefBlock)
+}
+
+func TestConfig_InlinedFrom(t *testing.T) {
+	type InnerConfig struct {
+		Inner string `yaml:"inner"`
+	}
+
+	type MiddleConfig struct {
+		InnerConfig `yaml:",inline" doc:"label=inner_label|description=The inner options."`
+		Middle      string `yaml:"middle"`
+		Nested      struct {
+			Field string `yaml:"field"`
+		} `yaml:"nested"`
+	}
+
+	type config struct {
+		MiddleConfig `yaml:",inline" doc:"description=The middle options."`
+		Outer        string `yaml:"outer"`
+	}
+
+	blocks, err := Config(&config{}, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+
+	inlinedFrom := map[string][]string{}
+	for _, entry := range blocks[0].Entries {
+		inlinedFrom[entry.Name] = entry.InlinedFrom
+	}
+
+	assert.Equal(t, map[string][]string{
+		"inner":  {"MiddleConfig", "inner_label"},
+		"middle": {"MiddleConfig"},
+		"nested": {"MiddleConfig"},
+		"outer":  nil,
+	}, inlinedFrom)
+
+	assert.Equal(t, map[string]string{
+		"MiddleConfig": "The middle options.",
+		"inner_label":  "The inner options.",
+	}, blocks[0].InlinedDescs)
+
+	// Entries of a nested block aren't inlined into the parent block.
+	assert.Nil(t, blocks[0].Entries[2].Block.Entries[0].InlinedFrom)
+}
+
+type commonConfig struct {
+	Address    string `yaml:"address"`
+	baseConfig `yaml:",inline"`
+}
+
+type baseConfig struct {
+	Timeout time.Duration `yaml:"timeout"`
+	hidden  string
+}
+
+func TestConfig_UnexportedEmbeddedStructs(t *testing.T) {
+	type config struct {
+		commonConfig `yaml:",inline"`
+		Name         string `yaml:"name"`
+		common       commonConfig
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.StringVar(&cfg.Address, "address", "localhost", "The address.")
+	fs.DurationVar(&cfg.Timeout, "timeout", time.Second, "The timeout.")
+	fs.StringVar(&cfg.Name, "name", "", "The name.")
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+	entries := blocks[0].Entries
+
+	// The promoted fields are documented in the parent block, while the named unexported field is skipped.
+	require.Len(t, entries, 3)
+	assert.Equal(t, "address", entries[0].Name)
+	assert.Equal(t, "address", entries[0].FieldFlag)
+	assert.Equal(t, []string{"commonConfig"}, entries[0].InlinedFrom)
+	assert.Equal(t, "timeout", entries[1].Name)
+	assert.Equal(t, "1s", entries[1].FieldDefault)
+	assert.Equal(t, []string{"commonConfig", "baseConfig"}, entries[1].InlinedFrom)
+	assert.Equal(t, "name", entries[2].Name)
+	assert.Nil(t, entries[2].InlinedFrom)
+}
+
+func TestConfig_UnexportedEmbeddedStructPointer(t *testing.T) {
+	cfg := &struct {
+		*baseConfig `yaml:",inline"`
+	}{}
+
+	_, err := Config(cfg, nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "embedded field *parse.baseConfig is a pointer to an unexported struct, whose fields can't be documented")
+}
+
+func TestConfig_UnexportedEmbeddedStructsNotInline(t *testing.T) {
+	// yaml.v2 doesn't inline them, so their fields aren't config keys of the parent.
+	_, err := Config(&struct {
+		baseConfig
+	}{}, nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "embedded field parse.baseConfig has no yaml tag, while it's only inlined if tagged as inline")
+
+	_, err = Config(&struct {
+		baseConfig `yaml:"base"`
+	}{}, nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "embedded field parse.baseConfig is an unexported struct not tagged as inline, whose fields can't be documented")
+}
+
+type EmbeddedCommonConfig struct {
+	Address string        `yaml:"address"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+func TestConfig_EmbeddedStructs(t *testing.T) {
+	type config struct {
+		EmbeddedCommonConfig `yaml:",inline"`
+		Name                 string `yaml:"name"`
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.StringVar(&cfg.Address, "address", "localhost", "The address.")
+	fs.DurationVar(&cfg.Timeout, "timeout", time.Second, "The timeout.")
+	fs.StringVar(&cfg.Name, "name", "", "The name.")
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+	entries := blocks[0].Entries
+
+	// The embedded struct tagged as inline is inlined.
+	require.Len(t, entries, 3)
+	assert.Equal(t, "address", entries[0].Name)
+	assert.Equal(t, "address", entries[0].FieldFlag)
+	assert.Equal(t, []string{"EmbeddedCommonConfig"}, entries[0].InlinedFrom)
+	assert.Equal(t, "timeout", entries[1].Name)
+	assert.Equal(t, "1s", entries[1].FieldDefault)
+	assert.Equal(t, "name", entries[2].Name)
+	assert.Nil(t, entries[2].InlinedFrom)
+
+	// An embedded struct with a yaml tag is a nested block, like any other field.
+	blocks, err = Config(&struct {
+		EmbeddedCommonConfig `yaml:"common"`
+	}{}, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, blocks[0].Entries, 1)
+	assert.Equal(t, KindBlock, blocks[0].Entries[0].Kind)
+	assert.Equal(t, "common", blocks[0].Entries[0].Name)
+
+	// The same goes for pointers to embedded structs.
+	blocks, err = Config(&struct {
+		*EmbeddedCommonConfig `yaml:",inline"`
+	}{}, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, blocks[0].Entries, 2)
+	assert.Equal(t, "address", blocks[0].Entries[0].Name)
+
+	// yaml.v2 doesn't inline an embedded struct without a yaml tag, but nests it under its
+	// lowercased type name, so it's rejected rather than documented with the wrong shape.
+	for _, cfg := range []interface{}{
+		&struct{ EmbeddedCommonConfig }{},
+		&struct{ *EmbeddedCommonConfig }{},
+	} {
+		_, err = Config(cfg, nil, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "EmbeddedCommonConfig has no yaml tag, while it's only inlined if tagged as inline")
+	}
+}
+
+func TestConfig_EmbeddedStructsNameCollisions(t *testing.T) {
+	tests := map[string]struct {
+		cfg      interface{}
+		expected string
+	}{
+		"embedded struct": {
+			cfg: &struct {
+				EmbeddedCommonConfig `yaml:",inline"`
+				Address              string `yaml:"address"`
+			}{},
+			expected: "field name address is used by both a field promoted from EmbeddedCommonConfig and a field of the struct",
+		},
+		"unexported embedded struct": {
+			cfg: &struct {
+				Timeout    int `yaml:"timeout"`
+				baseConfig `yaml:",inline"`
+			}{},
+			expected: "field name timeout is used by both a field of the struct and a field promoted from baseConfig",
+		},
+		"inline struct": {
+			cfg: &struct {
+				Address string               `yaml:"address"`
+				Common  EmbeddedCommonConfig `yaml:",inline"`
+			}{},
+			expected: "field name address is used by both a field of the struct and a field promoted from EmbeddedCommonConfig",
+		},
+		"nested block": {
+			cfg: &struct {
+				Nested struct {
+					EmbeddedCommonConfig `yaml:",inline"`
+					Timeout              int `yaml:"timeout"`
+				} `yaml:"nested"`
+			}{},
+			expected: "block nested: field name timeout is used by both a field promoted from EmbeddedCommonConfig and a field of the struct",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Config(test.cfg, nil, nil)
+			require.Error(t, err)
+			assert.EqualError(t, err, test.expected)
+		})
+	}
+}
+
+func TestConfig_BlockCategory(t *testing.T) {
+	type config struct {
+		Stable struct {
+			Enabled      bool `yaml:"enabled"`
+			Experimental struct {
+				Enabled    bool   `yaml:"enabled"`
+				Advanced   int    `yaml:"advanced" category:"advanced"`
+				PathPrefix string `yaml:"path_prefix"`
+				Nested     struct {
+					Enabled bool `yaml:"enabled"`
+				} `yaml:"nested"`
+				Tagged       bool `yaml:"tagged" category:"experimental"`
+				TaggedNested struct {
+					Enabled bool `yaml:"enabled"`
+				} `yaml:"tagged_nested" category:"experimental" doc:"description=The tagged nested block."`
+			} `yaml:"experimental" category:"experimental" doc:"description=The experimental block."`
+		} `yaml:"stable"`
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.BoolVar(&cfg.Stable.Enabled, "stable.enabled", false, "Whether it's enabled.")
+	fs.BoolVar(&cfg.Stable.Experimental.Enabled, "experimental.enabled", false, "Whether it's enabled.")
+	fs.IntVar(&cfg.Stable.Experimental.Advanced, "experimental.advanced", 0, "An advanced option.")
+	// The category of this flag is overridden to advanced through the fieldcategory package.
+	fs.StringVar(&cfg.Stable.Experimental.PathPrefix, "server.path-prefix", "", "The path prefix.")
+	fs.BoolVar(&cfg.Stable.Experimental.Nested.Enabled, "experimental.nested.enabled", false, "Whether it's enabled.")
+	fs.BoolVar(&cfg.Stable.Experimental.Tagged, "experimental.tagged", false, "Whether it's tagged.")
+	fs.BoolVar(&cfg.Stable.Experimental.TaggedNested.Enabled, "experimental.tagged-nested.enabled", false, "Whether it's enabled.")
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+
+	stable := blocks[0].Entries[0]
+	assert.Equal(t, "", stable.Block.Category)
+	assert.Equal(t, "", stable.Block.Entries[0].FieldCategory)
+
+	experimental := stable.Block.Entries[1]
+	assert.Equal(t, "experimental", experimental.Block.Category)
+	assert.False(t, experimental.CategoryInherited)
+	assert.Equal(t, "(experimental) The experimental block.", experimental.BlockDescription())
+
+	// Fields without a category of their own inherit the one of the block, which
+	// is described once by the block rather than by each field.
+	entries := experimental.Block.Entries
+	assert.Equal(t, "experimental", entries[0].FieldCategory)
+	assert.True(t, entries[0].CategoryInherited)
+	assert.Equal(t, "Whether it's enabled.", entries[0].Description())
+
+	// Both the category tag and the overrides win over the block category.
+	assert.Equal(t, "advanced", entries[1].FieldCategory)
+	assert.False(t, entries[1].CategoryInherited)
+	assert.Equal(t, "(advanced) An advanced option.", entries[1].Description())
+	assert.Equal(t, "advanced", entries[2].FieldCategory)
+	assert.False(t, entries[2].CategoryInherited)
+
+	// Nested blocks inherit the category too.
+	nested := entries[3]
+	assert.Equal(t, "experimental", nested.Block.Category)
+	assert.True(t, nested.CategoryInherited)
+	assert.Equal(t, "", nested.BlockDescription())
+	assert.Equal(t, "experimental", nested.Block.Entries[0].FieldCategory)
+	assert.True(t, nested.Block.Entries[0].CategoryInherited)
+
+	// Fields and blocks tagged with the same category as the block are described as inheriting it,
+	// rather than repeating the category.
+	assert.Equal(t, "experimental", entries[4].FieldCategory)
+	assert.True(t, entries[4].CategoryInherited)
+	assert.Equal(t, "Whether it's tagged.", entries[4].Description())
+	taggedNested := entries[5]
+	assert.Equal(t, "experimental", taggedNested.Block.Category)
+	assert.True(t, taggedNested.CategoryInherited)
+	assert.Equal(t, "The tagged nested block.", taggedNested.BlockDescription())
+}
+
+func TestConfig_Aliases(t *testing.T) {
+	type config struct {
+		Server struct {
+			Timeout int `yaml:"timeout"`
+		} `yaml:"server"`
+		Client struct {
+			Timeout int `yaml:"timeout" doc:"alias"`
+		} `yaml:"client"`
+		Other int `yaml:"other"`
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.IntVar(&cfg.Server.Timeout, "timeout", 10, "The timeout.")
+	fs.IntVar(&cfg.Other, "other", 0, "Another option.")
+
+	// Both timeout fields are registered against the same variable.
+	flags := testFlags(fs)
+	flags[reflect.ValueOf(&cfg.Client.Timeout).Pointer()] = flags[reflect.ValueOf(&cfg.Server.Timeout).Pointer()]
+
+	blocks, err := Config(cfg, flags, nil)
+	require.NoError(t, err)
+
+	server := blocks[0].Entries[0].Block.Entries[0]
+	client := blocks[0].Entries[1].Block.Entries[0]
+	other := blocks[0].Entries[2]
+
+	assert.Equal(t, "timeout", client.FieldFlag)
+	assert.Equal(t, []string{"client.timeout"}, server.AliasesOf)
+	assert.False(t, server.Alias)
+	assert.Equal(t, []string{"server.timeout"}, client.AliasesOf)
+	assert.True(t, client.Alias)
+	assert.Nil(t, other.AliasesOf)
+
+	// Only the alias which isn't tagged is reported.
+	errs := ValidateAliases(blocks)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "field server.timeout shares the CLI flag -timeout with client.timeout but isn't tagged as alias")
+}
+
+func TestConfig_DeprecatedInFavorOf(t *testing.T) {
+	type config struct {
+		Old string `yaml:"old" doc:"deprecated=new"`
+		New string `yaml:"new"`
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.StringVar(&cfg.Old, "old", "", "The old option.")
+	fs.StringVar(&cfg.New, "new", "", "The new option.")
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+
+	// Deprecated fields are still documented, unlike removed ones.
+	require.Len(t, blocks[0].Entries, 2)
+	assert.Equal(t, "old", blocks[0].Entries[0].Name)
+	assert.Equal(t, "old", blocks[0].Entries[0].FieldFlag)
+	assert.Equal(t, "new", blocks[0].Entries[0].DeprecatedInFavorOf)
+	assert.Empty(t, blocks[0].Entries[1].DeprecatedInFavorOf)
+}
+
+func TestConfig_GoType(t *testing.T) {
+	type config struct {
+		Scalar   int                               `yaml:"scalar"`
+		Slice    []string                          `yaml:"slice"`
+		Map      map[string]int                    `yaml:"map"`
+		Duration time.Duration                     `yaml:"duration"`
+		Trackers activeseries.CustomTrackersConfig `yaml:"trackers"`
+		Level    logging.Level                     `yaml:"level"`
+		Block    struct {
+			Enabled bool `yaml:"enabled"`
+		} `yaml:"block"`
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	cfg.Level.RegisterFlags(fs)
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+
+	goTypes := map[string]string{}
+	for _, entry := range blocks[0].Entries {
+		goTypes[entry.Name] = entry.GoType
+	}
+	assert.Equal(t, map[string]string{
+		"scalar":   "int",
+		"slice":    "[]string",
+		"map":      "map[string]int",
+		"duration": "time.Duration",
+		"trackers": "activeseries.CustomTrackersConfig",
+		"level":    "logging.Level",
+		"block":    "struct { Enabled bool \"yaml:\\\"enabled\\\"\" }",
+	}, goTypes)
+}
+
+func TestConfig_SliceOfStructs(t *testing.T) {
+	type SubConfig struct {
+		Address string `yaml:"address"`
+	}
+
+	type config struct {
+		Subs        []SubConfig  `yaml:"subs" doc:"description=The subs."`
+		SubPointers []*SubConfig `yaml:"sub_pointers"`
+		Names       []string     `yaml:"names"`
+	}
+
+	blocks, err := Config(&config{}, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, blocks, 3)
+
+	subs := blocks[0].Entries[0]
+	assert.Equal(t, KindSlice, subs.Kind)
+	assert.Equal(t, "list of SubConfig", subs.FieldType)
+	require.NotNil(t, subs.Element)
+	assert.Same(t, subs.Element, blocks[1])
+	assert.Equal(t, "subs", blocks[1].Name)
+	assert.Equal(t, "The subs.", blocks[1].Desc)
+	require.Len(t, blocks[1].Entries, 1)
+	assert.Equal(t, "address", blocks[1].Entries[0].Name)
+
+	subPointers := blocks[0].Entries[1]
+	assert.Equal(t, KindSlice, subPointers.Kind)
+	assert.Same(t, subPointers.Element, blocks[2])
+	require.Len(t, blocks[2].Entries, 1)
+
+	names := blocks[0].Entries[2]
+	assert.Equal(t, KindField, names.Kind)
+	assert.Equal(t, "list of string", names.FieldType)
+	assert.Nil(t, names.Element)
+}
+
+func TestConfig_Sentinels(t *testing.T) {
+	type config struct {
+		Limit   int           `yaml:"limit" doc:"sentinel=0:disabled,-1:unlimited"`
+		Timeout time.Duration `yaml:"timeout" doc:"sentinel=0:disabled"`
+		Ratio   float64       `yaml:"ratio" doc:"sentinel=0:disabled"`
+		Other   int           `yaml:"other" doc:"sentinel=0:disabled"`
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.IntVar(&cfg.Limit, "limit", -1, "The limit.")
+	fs.DurationVar(&cfg.Timeout, "timeout", 0, "The timeout.")
+	fs.Float64Var(&cfg.Ratio, "ratio", 0.5, "The ratio.")
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+	entries := blocks[0].Entries
+
+	assert.Equal(t, map[string]string{"0": "disabled", "-1": "unlimited"}, entries[0].FieldSentinels)
+	assert.Equal(t, "The limit. (-1 = unlimited)", entries[0].Description())
+
+	assert.Equal(t, map[string]string{"0s": "disabled"}, entries[1].FieldSentinels)
+	assert.Equal(t, "The timeout. (0s = disabled)", entries[1].Description())
+
+	// The default isn't a sentinel value.
+	assert.Equal(t, "The ratio.", entries[2].Description())
+
+	// Fields without a flag have no default.
+	assert.Equal(t, "", entries[3].Description())
+
+	entries[0].FieldCategory = "advanced"
+	assert.Equal(t, "(advanced) The limit. (-1 = unlimited)", entries[0].Description())
+}
+
+func TestConfig_DefaultChanges(t *testing.T) {
+	type config struct {
+		Timeout time.Duration     `yaml:"timeout" doc:"default_changed=2.5:5m|default_changed=2.3.1:1m0s"`
+		Address string            `yaml:"address" doc:"default_changed=2.4:localhost:9090"`
+		Labels  map[string]string `yaml:"labels" doc:"default_changed=2.4:{a: b}"`
+		Other   int               `yaml:"other"`
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.DurationVar(&cfg.Timeout, "timeout", 10*time.Minute, "The timeout.")
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+	entries := blocks[0].Entries
+
+	// Old values are formatted like the flag defaults.
+	assert.Equal(t, []DefaultChange{{Version: "2.5", Old: "5m0s"}, {Version: "2.3.1", Old: "1m0s"}}, entries[0].FieldDefaultChanges)
+	assert.Equal(t, []DefaultChange{{Version: "2.4", Old: "localhost:9090"}}, entries[1].FieldDefaultChanges)
+	assert.Equal(t, []DefaultChange{{Version: "2.4", Old: "{a: b}"}}, entries[2].FieldDefaultChanges)
+	assert.Nil(t, entries[3].FieldDefaultChanges)
+}
+
+func TestConfig_InvalidDefaultChanges(t *testing.T) {
+	tests := map[string]struct {
+		cfg      interface{}
+		expected string
+	}{
+		"missing old value": {
+			cfg: &struct {
+				Limit int `yaml:"limit" doc:"default_changed=2.5"`
+			}{},
+			expected: `field Limit: invalid default change "2.5", expected version:old-value`,
+		},
+		"invalid version": {
+			cfg: &struct {
+				Limit int `yaml:"limit" doc:"default_changed=v2:10"`
+			}{},
+			expected: `field Limit: invalid default change version "v2", expected major.minor[.patch]`,
+		},
+		"old value not matching the field type": {
+			cfg: &struct {
+				Timeout time.Duration `yaml:"timeout" doc:"default_changed=2.5:ten"`
+			}{},
+			expected: `field Timeout: invalid default change value "ten"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Config(test.cfg, nil, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.expected)
+		})
+	}
+}
+
+func TestConfig_Feature(t *testing.T) {
+	type config struct {
+		WithFlag    int          `yaml:"with_flag" doc:"feature=netgo|description=Gated field."`
+		WithoutFlag string       `yaml:"without_flag" doc:"feature=query-sharding"`
+		Custom      flagext.Time `yaml:"custom" doc:"feature=netgo"`
+		Ungated     int          `yaml:"ungated"`
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.IntVar(&cfg.WithFlag, "with-flag", 0, "")
+	fs.Var(&cfg.Custom, "custom", "")
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+	entries := blocks[0].Entries
+
+	assert.Equal(t, map[string]string{"feature": "netgo", "description": "Gated field."}, parseDocTag(reflectField(config{}, "WithFlag")))
+	assert.Equal(t, "netgo", entries[0].FieldFeature)
+	assert.Equal(t, "Gated field.", entries[0].FieldDesc)
+	assert.Equal(t, "query-sharding", entries[1].FieldFeature)
+	assert.Equal(t, "netgo", entries[2].FieldFeature)
+	assert.Equal(t, "", entries[3].FieldFeature)
+}
+
+func TestConfig_Warnings(t *testing.T) {
+	cfg := &struct {
+		Single   string `yaml:"single" doc:"warning=Requires filesystem write access."`
+		Multiple string `yaml:"multiple" doc:"description=The multiple field.|warning=Exposes an unauthenticated endpoint.|warning=Affects billing metrics."`
+		None     string `yaml:"none"`
+	}{}
+
+	blocks, err := Config(cfg, nil, nil)
+	require.NoError(t, err)
+	entries := blocks[0].Entries
+
+	assert.Equal(t, []string{"Requires filesystem write access."}, entries[0].FieldWarnings)
+	assert.Equal(t, []string{"Exposes an unauthenticated endpoint.", "Affects billing metrics."}, entries[1].FieldWarnings)
+	assert.Equal(t, "The multiple field.", entries[1].FieldDesc)
+	assert.Nil(t, entries[2].FieldWarnings)
+}
+
+// featureMask is a bit flags type configurable as a comma-separated list of names.
+type featureMask uint64
+
+func (m *featureMask) UnmarshalText(text []byte) error {
+	*m = 0
+	for _, name := range strings.Split(string(text), ",") {
+		switch name {
+		case "foo":
+			*m |= 1
+		case "bar":
+			*m |= 2
+		case "baz":
+			*m |= 4
+		default:
+			return fmt.Errorf("unknown feature %q", name)
+		}
+	}
+	return nil
+}
+
+func TestConfig_Bits(t *testing.T) {
+	type config struct {
+		Mask     uint64      `yaml:"mask" doc:"bits=4:baz,1:foo,2:bar"`
+		Features featureMask `yaml:"features" doc:"bits=1:foo,2:bar,4:baz"`
+		None     int         `yaml:"none" doc:"bits=1:foo,2:bar"`
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.Uint64Var(&cfg.Mask, "mask", 5, "The mask.")
+	fs.Uint64Var((*uint64)(&cfg.Features), "features", 2, "The features.")
+	fs.IntVar(&cfg.None, "none", 0, "Nothing.")
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+	entries := blocks[0].Entries
+
+	assert.Equal(t, []BitFlag{{Value: 1, Name: "foo"}, {Value: 2, Name: "bar"}, {Value: 4, Name: "baz"}}, entries[0].FieldBits)
+	assert.Equal(t, "int", entries[0].FieldType)
+	assert.Equal(t, "[foo, baz]", entries[0].FieldDefault)
+	assert.Equal(t, "The mask. Supported options: foo, bar, baz.", entries[0].Description())
+
+	// The type accepts the names, so it's documented as a list of names.
+	assert.Equal(t, "list of string", entries[1].FieldType)
+	assert.Equal(t, "[bar]", entries[1].FieldDefault)
+
+	assert.Equal(t, "[]", entries[2].FieldDefault)
+}
+
+func TestConfig_InvalidBits(t *testing.T) {
+	tests := map[string]struct {
+		cfg      interface{}
+		expected string
+	}{
+		"not a power of two": {
+			cfg: &struct {
+				Mask uint64 `yaml:"mask" doc:"bits=1:foo,3:bar"`
+			}{},
+			expected: "field Mask: bit value 3 is not a power of two",
+		},
+		"zero": {
+			cfg: &struct {
+				Mask uint64 `yaml:"mask" doc:"bits=0:foo"`
+			}{},
+			expected: "field Mask: bit value 0 is not a power of two",
+		},
+		"duplicated value": {
+			cfg: &struct {
+				Mask uint64 `yaml:"mask" doc:"bits=1:foo,1:bar"`
+			}{},
+			expected: "field Mask: duplicated bit value 1",
+		},
+		"duplicated name": {
+			cfg: &struct {
+				Mask uint64 `yaml:"mask" doc:"bits=1:foo,2:foo"`
+			}{},
+			expected: `field Mask: duplicated bit name "foo"`,
+		},
+		"missing name": {
+			cfg: &struct {
+				Mask uint64 `yaml:"mask" doc:"bits=1"`
+			}{},
+			expected: `field Mask: invalid bit "1", expected value:name`,
+		},
+		"not an integer field": {
+			cfg: &struct {
+				Mask string `yaml:"mask" doc:"bits=1:foo"`
+			}{},
+			expected: "field Mask: bits are not supported for string fields",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Config(test.cfg, nil, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.expected)
+		})
+	}
+
+	t.Run("default with unknown bits", func(t *testing.T) {
+		cfg := &struct {
+			Mask uint64 `yaml:"mask" doc:"bits=1:foo"`
+		}{}
+		fs := flag.NewFlagSet("", flag.PanicOnError)
+		fs.Uint64Var(&cfg.Mask, "mask", 3, "")
+
+		_, err := Config(cfg, testFlags(fs), nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "field Mask: default has bits 2 not matching any name")
+	})
+}
+
+func TestConfig_InvalidSentinels(t *testing.T) {
+	tests := map[string]struct {
+		cfg      interface{}
+		expected string
+	}{
+		"value not matching the field type": {
+			cfg: &struct {
+				Limit int `yaml:"limit" doc:"sentinel=none:disabled"`
+			}{},
+			expected: `field Limit: invalid sentinel value "none": strconv.ParseInt: parsing "none": invalid syntax`,
+		},
+		"missing meaning": {
+			cfg: &struct {
+				Limit int `yaml:"limit" doc:"sentinel=0"`
+			}{},
+			expected: `field Limit: invalid sentinel "0", expected value:meaning`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Config(test.cfg, nil, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.expected)
+		})
+	}
+}
+
+func TestConfig_Bounds(t *testing.T) {
+	cfg := &struct {
+		Shards  int     `yaml:"shards" doc:"min=1|max=64"`
+		Ratio   float64 `yaml:"ratio" doc:"min=0.1"`
+		Retries uint    `yaml:"retries" doc:"max=10"`
+		Workers int     `yaml:"workers" doc:"min=1|default=<number of CPUs>"`
+		Limit   int     `yaml:"limit"`
+	}{}
+
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.IntVar(&cfg.Shards, "shards", 64, "The number of shards.")
+	fs.Float64Var(&cfg.Ratio, "ratio", 0.5, "The ratio.")
+	fs.UintVar(&cfg.Retries, "retries", 3, "The number of retries.")
+	fs.IntVar(&cfg.Workers, "workers", 0, "The number of workers.")
+	fs.IntVar(&cfg.Limit, "limit", 0, "The limit.")
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+
+	entries := blocks[0].Entries
+	require.Len(t, entries, 5)
+
+	// Bounds are inclusive.
+	assert.Equal(t, 1.0, *entries[0].FieldMin)
+	assert.Equal(t, 64.0, *entries[0].FieldMax)
+	assert.Equal(t, "The number of shards. (must be between 1 and 64)", entries[0].Description())
+
+	assert.Equal(t, 0.1, *entries[1].FieldMin)
+	assert.Nil(t, entries[1].FieldMax)
+	assert.Equal(t, "The ratio. (must be at least 0.1)", entries[1].Description())
+
+	assert.Nil(t, entries[2].FieldMin)
+	assert.Equal(t, 10.0, *entries[2].FieldMax)
+	assert.Equal(t, "The number of retries. (must be at most 10)", entries[2].Description())
+
+	// Defaults which aren't numbers aren't checked.
+	assert.Equal(t, "<number of CPUs>", entries[3].FieldDefault)
+	assert.Equal(t, 1.0, *entries[3].FieldMin)
+
+	assert.Nil(t, entries[4].FieldMin)
+	assert.Nil(t, entries[4].FieldMax)
+	assert.Equal(t, "The limit.", entries[4].Description())
+}
+
+func TestConfig_InvalidBounds(t *testing.T) {
+	tests := map[string]struct {
+		cfg      interface{}
+		expected string
+	}{
+		"value not matching the field type": {
+			cfg: &struct {
+				Shards int `yaml:"shards" doc:"min=0.5"`
+			}{},
+			expected: `field Shards: invalid min "0.5": strconv.ParseInt: parsing "0.5": invalid syntax`,
+		},
+		"value overflowing the field type": {
+			cfg: &struct {
+				Shards uint8 `yaml:"shards" doc:"max=256"`
+			}{},
+			expected: `field Shards: invalid max "256": strconv.ParseUint: parsing "256": value out of range`,
+		},
+		"min greater than max": {
+			cfg: &struct {
+				Shards int `yaml:"shards" doc:"min=10|max=1"`
+			}{},
+			expected: "field Shards: min 10 is greater than max 1",
+		},
+		"not a numeric field": {
+			cfg: &struct {
+				Timeout time.Duration `yaml:"timeout" doc:"min=1"`
+			}{},
+			expected: "field Timeout: bounds are not supported for time.Duration fields",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Config(test.cfg, nil, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.expected)
+		})
+	}
+
+	t.Run("default outside of the bounds", func(t *testing.T) {
+		cfg := &struct {
+			Shards int `yaml:"shards" doc:"min=1|max=64"`
+		}{}
+		fs := flag.NewFlagSet("", flag.PanicOnError)
+		fs.IntVar(&cfg.Shards, "shards", 128, "")
+
+		_, err := Config(cfg, testFlags(fs), nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "field Shards: default 128 is outside of its bounds")
+	})
+}
+
+func TestConfig_ZeroMeaning(t *testing.T) {
+	cfg := &struct {
+		Timeout   time.Duration  `yaml:"timeout" doc:"zero=no timeout"`
+		Retention model.Duration `yaml:"retention" doc:"zero=disabled"`
+		Interval  time.Duration  `yaml:"interval" doc:"zero=disabled|sentinel=0s:never"`
+	}{}
+
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.DurationVar(&cfg.Timeout, "timeout", time.Minute, "The timeout.")
+	fs.Var(&cfg.Retention, "retention", "The retention.")
+	fs.DurationVar(&cfg.Interval, "interval", 0, "The interval.")
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+
+	// The meaning of 0 is documented whatever the default, for both std and model durations.
+	entries := blocks[0].Entries
+	require.Len(t, entries, 3)
+	assert.Equal(t, "no timeout", entries[0].FieldZeroMeaning)
+	assert.Equal(t, "The timeout. (0 = no timeout)", entries[0].Description())
+	assert.Equal(t, "disabled", entries[1].FieldZeroMeaning)
+	assert.Equal(t, "The retention. (0 = disabled)", entries[1].Description())
+
+	// A sentinel for 0 takes precedence.
+	assert.Equal(t, "The interval. (0s = never)", entries[2].Description())
+}
+
+func TestConfig_InvalidZeroMeaning(t *testing.T) {
+	_, err := Config(&struct {
+		Limit int `yaml:"limit" doc:"zero=unlimited"`
+	}{}, nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field Limit: zero meaning is not supported for int fields")
+}
+
+func TestConfig_OptionalScalars(t *testing.T) {
+	limit, enabled, timeout := 5, true, time.Minute
+	cfg := &struct {
+		Limit        *int           `yaml:"limit"`
+		Enabled      *bool          `yaml:"enabled"`
+		Timeout      *time.Duration `yaml:"timeout"`
+		UnsetLimit   *int           `yaml:"unset_limit"`
+		UnsetEnabled *bool          `yaml:"unset_enabled"`
+		UnsetTimeout *time.Duration `yaml:"unset_timeout" doc:"description=The timeout, which falls back to the global one if unset."`
+		Shards       *int           `yaml:"shards" doc:"default=<ingesters count>"`
+	}{Limit: &limit, Enabled: &enabled, Timeout: &timeout}
+
+	// The flags of optional scalars are registered for the values they point to.
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.IntVar(cfg.Limit, "limit", 5, "The limit.")
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+
+	entries := blocks[0].Entries
+	require.Len(t, entries, 7)
+
+	assert.Equal(t, "limit", entries[0].FieldFlag)
+	assert.Equal(t, "5", entries[0].FieldDefault)
+	assert.Equal(t, "The limit.", entries[0].Description())
+
+	for i, expected := range []struct{ typ, def string }{
+		{"int", "5"},
+		{"boolean", "true"},
+		{"duration", "1m0s"},
+		{"int", "unset"},
+		{"boolean", "unset"},
+		{"duration", "unset"},
+		{"int", "<ingesters count>"},
+	} {
+		assert.Equal(t, expected.typ, entries[i].FieldType, entries[i].Name)
+		assert.Equal(t, expected.def, entries[i].FieldDefault, entries[i].Name)
+		assert.True(t, entries[i].FieldTypeSpec.Optional, entries[i].Name)
+	}
+
+	// Only nil fields without a documented default are unset.
+	assert.False(t, entries[0].IsUnset())
+	assert.True(t, entries[3].IsUnset())
+	assert.True(t, entries[5].IsUnset())
+	assert.Equal(t, "The timeout, which falls back to the global one if unset.", entries[5].Description())
+	assert.False(t, entries[6].IsUnset())
+}
+
+func TestConfig_Units(t *testing.T) {
+	cfg := &struct {
+		MaxBytes int64         `yaml:"max_bytes" doc:"unit=bytes"`
+		Ratio    float64       `yaml:"ratio" doc:"unit=percent"`
+		Shards   int           `yaml:"shards"`
+		Timeout  time.Duration `yaml:"timeout"`
+	}{}
+
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.Int64Var(&cfg.MaxBytes, "max-bytes", 1024, "The maximum size.")
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+
+	entries := blocks[0].Entries
+	require.Len(t, entries, 4)
+	assert.Equal(t, "bytes", entries[0].FieldUnit)
+	assert.Equal(t, "int", entries[0].FieldType)
+	assert.Equal(t, "percent", entries[1].FieldUnit)
+	assert.Equal(t, "", entries[2].FieldUnit)
+	assert.Equal(t, "", entries[3].FieldUnit)
+}
+
+func TestConfig_BytesDefaults(t *testing.T) {
+	cfg := &struct {
+		MaxBytes     uint64 `yaml:"max_bytes" doc:"unit=bytes|min=1024"`
+		MaxItemSize  int    `yaml:"max_item_size" doc:"unit=bytes"`
+		ChunkSize    int    `yaml:"chunk_size" doc:"unit=bytes"`
+		MaxCacheSize int    `yaml:"max_cache_size" doc:"unit=bytes|default=<10% of the memory>"`
+		MaxSeries    int    `yaml:"max_series"`
+	}{}
+
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.Uint64Var(&cfg.MaxBytes, "max-bytes", 1073741824, "The maximum size.")
+	fs.IntVar(&cfg.MaxItemSize, "max-item-size", 1000, "The maximum item size.")
+	fs.IntVar(&cfg.ChunkSize, "chunk-size", 0, "The chunk size.")
+	fs.IntVar(&cfg.MaxCacheSize, "max-cache-size", 0, "The maximum cache size.")
+	fs.IntVar(&cfg.MaxSeries, "max-series", 1048576, "The maximum number of series.")
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+
+	entries := blocks[0].Entries
+	require.Len(t, entries, 5)
+
+	// Sizes in bytes are formatted for humans, while the raw default is kept for tools.
+	assert.Equal(t, "1GiB", entries[0].FieldDefault)
+	assert.Equal(t, "1073741824", entries[0].FieldRawDefault)
+	assert.Equal(t, "1073741824", entries[0].RawDefault())
+	assert.Equal(t, "1000B", entries[1].FieldDefault)
+	assert.Equal(t, "1000", entries[1].RawDefault())
+	assert.Equal(t, "0B", entries[2].FieldDefault)
+
+	// Documented defaults, and fields of other units, are kept as is.
+	assert.Equal(t, "<10% of the memory>", entries[3].FieldDefault)
+	assert.Equal(t, "", entries[3].FieldRawDefault)
+	assert.Equal(t, "1048576", entries[4].FieldDefault)
+	assert.Equal(t, "1048576", entries[4].RawDefault())
+
+	// Sentinels are matched against the raw default.
+	entries[2].FieldSentinels = map[string]string{"0": "unlimited"}
+	assert.Equal(t, "The chunk size. (0 = unlimited)", entries[2].Description())
+}
+
+func TestConfig_InvalidUnit(t *testing.T) {
+	_, err := Config(&struct {
+		Name string `yaml:"name" doc:"unit=bytes"`
+	}{}, nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field Name: unit is not supported for string fields")
+}
+
+func TestConfig_Categories(t *testing.T) {
+	t.Run("valid category", func(t *testing.T) {
+		blocks, err := Config(&struct {
+			Limit int `yaml:"limit" category:"advanced"`
+		}{}, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "advanced", blocks[0].Entries[0].FieldCategory)
+	})
+
+	t.Run("unknown category", func(t *testing.T) {
+		_, err := Config(&struct {
+			Limit int `yaml:"limit" category:"advancd"`
+		}{}, nil, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `field Limit: unknown category "advancd", valid categories are: basic, advanced, experimental`)
+	})
+
+	t.Run("extra category", func(t *testing.T) {
+		cfg := &struct {
+			Limit int `yaml:"limit" category:"enterprise"`
+		}{}
+		blocks, err := ConfigWithOptions(cfg, nil, nil, Options{ExtraCategories: []string{"enterprise"}})
+		require.NoError(t, err)
+		assert.Equal(t, "enterprise", blocks[0].Entries[0].FieldCategory)
+	})
+
+	t.Run("override conflicting with the struct tag", func(t *testing.T) {
+		cfg := &struct {
+			PathPrefix string `yaml:"path_prefix" category:"experimental"`
+		}{}
+		fs := flag.NewFlagSet("", flag.PanicOnError)
+		// The category of this flag is overridden to advanced through the fieldcategory package.
+		fs.StringVar(&cfg.PathPrefix, "server.path-prefix", "", "The path prefix.")
+
+		// The override takes precedence over the struct tag.
+		blocks, err := Config(cfg, testFlags(fs), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "advanced", blocks[0].Entries[0].FieldCategory)
+	})
+
+	t.Run("override of a field with an unknown category", func(t *testing.T) {
+		cfg := &struct {
+			PathPrefix string `yaml:"path_prefix" category:"experimntal"`
+		}{}
+		fs := flag.NewFlagSet("", flag.PanicOnError)
+		fs.StringVar(&cfg.PathPrefix, "server.path-prefix", "", "The path prefix.")
+
+		// The struct tag is validated even if it's overridden.
+		_, err := Config(cfg, testFlags(fs), nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `field PathPrefix: unknown category "experimntal"`)
+	})
+}
+
+func TestDocTagKeys(t *testing.T) {
+	assert.Equal(t, []string{"alias", "bits", "conflicts_with", "default", "default_changed", "deprecated", "description", "feature", "hidden", "label", "max", "min", "nocli", "reloadable", "required", "sentinel", "startup-only", "unit", "warning", "zero"}, DocTagKeys())
+}
+
+func TestConfigWithOptions_StrictDocTags(t *testing.T) {
+	tests := map[string]struct {
+		cfg      interface{}
+		expected string
+	}{
+		"known keys": {
+			cfg: &struct {
+				Address string `yaml:"address" doc:"required|nocli|description=The address.|default=localhost"`
+				Secret  string `yaml:"secret" doc:"hidden"`
+				Nested  struct {
+					Enabled bool `yaml:"enabled" doc:"feature=nested|warning=Beware."`
+				} `yaml:"nested"`
+			}{},
+		},
+		"typo'd flag key": {
+			cfg: &struct {
+				Secret string `yaml:"secret" doc:"hiden"`
+			}{},
+			expected: `field Secret: unknown doc tag key "hiden"`,
+		},
+		"typo'd value key": {
+			cfg: &struct {
+				Address string `yaml:"address" doc:"required|descripton=The address."`
+			}{},
+			expected: `field Address: unknown doc tag key "descripton"`,
+		},
+		"typo'd key in a nested block": {
+			cfg: &struct {
+				Nested struct {
+					Enabled bool `yaml:"enabled" doc:"no-cli"`
+				} `yaml:"nested"`
+			}{},
+			expected: `field Enabled: unknown doc tag key "no-cli"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Unknown keys are ignored unless parsing strictly.
+			_, err := Config(test.cfg, nil, nil)
+			require.NoError(t, err)
+
+			_, err = ConfigWithOptions(test.cfg, nil, nil, Options{StrictDocTags: true})
+			if test.expected == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.expected)
+		})
+	}
+}
+
+func TestConfigWithOptions_FallbackToJSONTags(t *testing.T) {
+	cfg := &struct {
+		Both       string `yaml:"yaml_name" json:"jsonName"`
+		JSONOnly   string `json:"jsonOnly"`
+		OmitEmpty  string `json:"omitEmpty,omitempty"`
+		NoName     string `json:",omitempty"`
+		Skipped    string `json:"-"`
+		Dash       string `json:"-,"`
+		NoTags     string
+		JSONStruct struct {
+			Nested int `json:"nestedField"`
+		} `json:"jsonStruct"`
+	}{}
+
+	entryNames := func(block *ConfigBlock) []string {
+		var names []string
+		for _, entry := range block.Entries {
+			names = append(names, entry.Name)
+		}
+		return names
+	}
+
+	// The default behavior ignores json tags.
+	blocks, err := Config(cfg, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"yaml_name", "jsononly", "omitempty", "noname", "skipped", "dash", "notags", "jsonstruct"}, entryNames(blocks[0]))
+	assert.Equal(t, []string{"nested"}, entryNames(blocks[0].Entries[7].Block))
+
+	blocks, err = ConfigWithOptions(cfg, nil, nil, Options{FallbackToJSONTags: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"yaml_name", "jsonOnly", "omitEmpty", "NoName", "-", "notags", "jsonStruct"}, entryNames(blocks[0]))
+	assert.Equal(t, []string{"nestedField"}, entryNames(blocks[0].Entries[6].Block))
+
+	assert.False(t, blocks[0].Entries[1].OmitEmpty)
+	assert.True(t, blocks[0].Entries[2].OmitEmpty)
+	assert.True(t, blocks[0].Entries[3].OmitEmpty)
+}
+
+type defaultedConfig struct {
+	Address string            `yaml:"address"`
+	Labels  map[string]string `yaml:"labels"`
+	Tenants []string          `yaml:"tenants"`
+}
+
+func (cfg *defaultedConfig) RegisterFlags(fs *flag.FlagSet, _ log.Logger) {
+	fs.StringVar(&cfg.Address, "address", "localhost", "The address.")
+
+	// Defaults set without a CLI flag.
+	cfg.Labels = map[string]string{"cluster": "default"}
+}
+
+func TestDefaultedConfig(t *testing.T) {
+	// The values of the input config, like the ones set by a previous
+	// call to RegisterFlags, are never documented nor modified.
+	cfg := &defaultedConfig{Address: "remote", Tenants: []string{"team-a"}}
+
+	defaulted, flags := DefaultedConfig(cfg, log.NewNopLogger())
+	assert.Equal(t, &defaultedConfig{Address: "remote", Tenants: []string{"team-a"}}, cfg)
+	assert.Equal(t, &defaultedConfig{Address: "localhost", Labels: map[string]string{"cluster": "default"}}, defaulted)
+
+	blocks, err := Config(defaulted, flags, nil)
+	require.NoError(t, err)
+	require.Len(t, blocks[0].Entries, 3)
+
+	entries := blocks[0].Entries
+	assert.Equal(t, "address", entries[0].FieldFlag)
+	assert.Equal(t, "localhost", entries[0].FieldDefault)
+	assert.Equal(t, "", entries[2].FieldDefault)
+
+	// The flags match the fields of the returned config, not the ones of the input config.
+	blocks, err = Config(cfg, flags, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "", blocks[0].Entries[0].FieldFlag)
+}
+
+func TestDefaultedConfig_NotAPointerToStruct(t *testing.T) {
+	assert.PanicsWithValue(t, "*parse.defaultedMap is not a pointer to struct", func() {
+		DefaultedConfig(&defaultedMap{}, log.NewNopLogger())
+	})
+}
+
+type defaultedMap map[string]string
+
+func (defaultedMap) RegisterFlags(*flag.FlagSet, log.Logger) {}
+
+type prefixedClientConfig struct {
+	Endpoint string `yaml:"endpoint"`
+	Timeout  int    `yaml:"timeout"`
+}
+
+func (cfg *prefixedClientConfig) RegisterFlagsWithPrefix(prefix string, fs *flag.FlagSet) {
+	fs.StringVar(&cfg.Endpoint, prefix+"client.endpoint", "", "The endpoint.")
+	fs.IntVar(&cfg.Timeout, prefix+"client.timeout", 10, "The timeout.")
+}
+
+func TestConfig_FlagsRegisteredWithMultiplePrefixes(t *testing.T) {
+	type config struct {
+		Single prefixedClientConfig `yaml:"single"`
+		Shared prefixedClientConfig `yaml:"shared"`
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	cfg.Single.RegisterFlagsWithPrefix("single.", fs)
+	cfg.Shared.RegisterFlagsWithPrefix("ruler.", fs)
+	cfg.Shared.RegisterFlagsWithPrefix("alertmanager.", fs)
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+
+	single := blocks[0].Entries[0].Block
+	assert.Equal(t, "", single.FlagsPrefix)
+	assert.Empty(t, single.FlagsPrefixes)
+	assert.Equal(t, "single.client.endpoint", single.Entries[0].FieldFlag)
+	assert.Empty(t, single.Entries[0].FieldFlagAlternates)
+
+	shared := blocks[0].Entries[1].Block
+	assert.Equal(t, "alertmanager", shared.FlagsPrefix)
+	assert.Equal(t, []string{"alertmanager", "ruler"}, shared.FlagsPrefixes)
+	assert.Equal(t, "alertmanager.client.endpoint", shared.Entries[0].FieldFlag)
+	assert.Equal(t, []string{"ruler.client.endpoint"}, shared.Entries[0].FieldFlagAlternates)
+	assert.Equal(t, "alertmanager.client.timeout", shared.Entries[1].FieldFlag)
+	assert.Equal(t, []string{"ruler.client.timeout"}, shared.Entries[1].FieldFlagAlternates)
+	assert.Equal(t, "10", shared.Entries[1].FieldDefault)
+}
+
+func TestFlagsFromSet(t *testing.T) {
+	type config struct {
+		Client prefixedClientConfig `yaml:"client"`
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	cfg.Client.RegisterFlagsWithPrefix("ruler.", fs)
+	cfg.Client.RegisterFlagsWithPrefix("alertmanager.", fs)
+	flagext.DeprecatedFlag(fs, "ruler.client.legacy", "Deprecated: no longer used.", log.NewNopLogger())
+
+	// The flags are the same as the ones returned by Flags for a config registering them.
+	flags := FlagsFromSet(fs)
+	require.Len(t, flags, 2)
+
+	endpoint := flags[reflect.ValueOf(&cfg.Client.Endpoint).Pointer()]
+	require.Len(t, endpoint, 2)
+	assert.Equal(t, "alertmanager.client.endpoint", endpoint[0].Name)
+	assert.Equal(t, "ruler.client.endpoint", endpoint[1].Name)
+
+	timeout := flags[reflect.ValueOf(&cfg.Client.Timeout).Pointer()]
+	require.Len(t, timeout, 2)
+	assert.Equal(t, "10", timeout[0].DefValue)
+
+	// The flags can be used to parse the config, and the flag set isn't modified.
+	blocks, err := Config(cfg, flags, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "alertmanager.client.endpoint", blocks[0].Entries[0].Block.Entries[0].FieldFlag)
+	assert.NotNil(t, fs.Lookup("ruler.client.legacy"))
+}
@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/regexp"
+)
+
+var (
+	// flagReferenceRegexp matches tokens looking like a CLI flag, like "-store.engine" or "(-querier.timeout)".
+	flagReferenceRegexp = regexp.MustCompile("(?:^|[\\s(\"'`])-([a-z0-9][a-z0-9-]*(?:\\.[a-z0-9][a-z0-9_-]*)+)")
+
+	// fieldReferenceRegexp matches backticked tokens looking like a YAML key, like `tenant_federation.enabled`.
+	// Keys without an underscore or a dot are ignored, because they can't be told apart from values like `true`.
+	fieldReferenceRegexp = regexp.MustCompile("`([a-z][a-z0-9_]*(?:\\.[a-z][a-z0-9_]*)*)`")
+)
+
+// ValidateDescriptionReferences returns an error for each reference to a CLI flag or to a YAML
+// field, found in the description of a field, which doesn't resolve to any of the input flags
+// or to any field of the blocks. References listed in allowlist, like flags of external tools,
+// are never reported. A YAML field reference resolves if it matches the trailing part of the
+// path of any field or block, like `enabled` or `tenant_federation.enabled`.
+func ValidateDescriptionReferences(blocks []*ConfigBlock, flags map[uintptr][]*flag.Flag, allowlist []string) []error {
+	allowed := make(map[string]struct{}, len(allowlist))
+	for _, ref := range allowlist {
+		allowed[ref] = struct{}{}
+	}
+
+	flagNames := map[string]struct{}{}
+	for _, fieldFlags := range flags {
+		for _, f := range fieldFlags {
+			flagNames[f.Name] = struct{}{}
+		}
+	}
+
+	fieldPaths := map[string]struct{}{}
+	for _, block := range blocks {
+		collectFieldPaths(block, block.Name, fieldPaths)
+	}
+
+	var errs []error
+	for _, block := range blocks {
+		errs = append(errs, validateDescriptionReferences(block, block.Name, flagNames, fieldPaths, allowed)...)
+	}
+	return errs
+}
+
+func validateDescriptionReferences(block *ConfigBlock, path string, flagNames, fieldPaths, allowed map[string]struct{}) []error {
+	var errs []error
+	for _, entry := range block.Entries {
+		entryPath := joinPath(path, entry.Name)
+
+		if entry.Kind == KindBlock {
+			// Root blocks are validated on their own.
+			if !entry.Root {
+				errs = append(errs, validateDescriptionReferences(entry.Block, entryPath, flagNames, fieldPaths, allowed)...)
+			}
+			continue
+		}
+
+		for _, match := range flagReferenceRegexp.FindAllStringSubmatch(entry.FieldDesc, -1) {
+			ref := "-" + match[1]
+			if _, ok := allowed[ref]; ok {
+				continue
+			}
+			if _, ok := flagNames[match[1]]; !ok {
+				errs = append(errs, fmt.Errorf("field %s references the nonexistent flag %s", entryPath, ref))
+			}
+		}
+
+		for _, match := range fieldReferenceRegexp.FindAllStringSubmatch(entry.FieldDesc, -1) {
+			ref := match[1]
+			if !strings.ContainsAny(ref, "_.") {
+				continue
+			}
+			if _, ok := allowed[ref]; ok {
+				continue
+			}
+			if _, ok := fieldPaths[ref]; !ok {
+				errs = append(errs, fmt.Errorf("field %s references the nonexistent field %s", entryPath, ref))
+			}
+		}
+	}
+	return errs
+}
+
+// collectFieldPaths adds to paths every trailing part of the path of each entry
+// of the block, so that "a.b.c" is indexed as "a.b.c", "b.c" and "c".
+func collectFieldPaths(block *ConfigBlock, path string, paths map[string]struct{}) {
+	for _, entry := range block.Entries {
+		entryPath := joinPath(path, entry.Name)
+
+		parts := strings.Split(entryPath, ".")
+		for i := range parts {
+			paths[strings.Join(parts[i:], ".")] = struct{}{}
+		}
+
+		if entry.Kind == KindBlock && !entry.Root {
+			collectFieldPaths(entry.Block, entryPath, paths)
+		}
+		if entry.Element != nil {
+			collectFieldPaths(entry.Element, entryPath, paths)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type referencingConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Timeout time.Duration `yaml:"timeout"`
+	Other   string        `yaml:"other_field"`
+	Desc    string        `yaml:"desc"`
+}
+
+func (cfg *referencingConfig) RegisterFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&cfg.Enabled, "client.enabled", false, "")
+	fs.DurationVar(&cfg.Timeout, "client.timeout", 0, "")
+	fs.StringVar(&cfg.Other, "client.other-field", "", "")
+	fs.StringVar(&cfg.Desc, "client.desc", "", "")
+}
+
+func TestValidateDescriptionReferences(t *testing.T) {
+	tests := map[string]struct {
+		desc      string
+		allowlist []string
+		expected  []string
+	}{
+		"no references": {
+			desc: "Set to `true` to enable it.",
+		},
+		"existing flag and fields": {
+			desc: "Only used when -client.enabled is true (see `other_field` and `client.timeout`), unlike -client.timeout.",
+		},
+		"nonexistent flag": {
+			desc:     "Overrides -client.removed-flag.",
+			expected: []string{"field client.desc references the nonexistent flag -client.removed-flag"},
+		},
+		"nonexistent field": {
+			desc:     "Only used when `removed_field` is set.",
+			expected: []string{"field client.desc references the nonexistent field removed_field"},
+		},
+		"nonexistent field path": {
+			desc:     "Ignored unless `server.other_field` is set.",
+			expected: []string{"field client.desc references the nonexistent field server.other_field"},
+		},
+		"allowlisted references": {
+			desc:      "Same as -tool.external-flag of the external tool, see `tool_option`.",
+			allowlist: []string{"-tool.external-flag", "tool_option"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := &struct {
+				Client referencingConfig `yaml:"client"`
+			}{}
+
+			fs := flag.NewFlagSet("", flag.PanicOnError)
+			cfg.Client.RegisterFlags(fs)
+			flags := testFlags(fs)
+
+			blocks, err := Config(cfg, flags, nil)
+			require.NoError(t, err)
+			for _, entry := range blocks[0].Entries[0].Block.Entries {
+				if entry.Name == "desc" {
+					entry.FieldDesc = test.desc
+				}
+			}
+
+			var actual []string
+			for _, err := range ValidateDescriptionReferences(blocks, flags, test.allowlist) {
+				actual = append(actual, err.Error())
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"sort"
+)
+
+var (
+	// YAML paths of the fields registered as reloadable, which can't be tagged because they're vendored.
+	reloadablePaths = map[string]struct{}{}
+
+	// YAML paths of the registered reloadable fields which have been matched by Config.
+	matchedReloadablePaths = map[string]struct{}{}
+)
+
+// MarkReloadable registers the field at yamlPath as changeable at runtime, through the runtime
+// config, without restarting. The path is the dot-separated path of the field from its root
+// block (like "limits.ingestion_rate"), or from the top-level block if it isn't nested in any
+// root block. It's meant for fields which can't be tagged as reloadable, like vendored ones.
+func MarkReloadable(yamlPath string) {
+	reloadablePaths[yamlPath] = struct{}{}
+}
+
+// UnmatchedReloadablePaths returns the sorted YAML paths registered through MarkReloadable
+// which haven't matched any field in the configs parsed so far.
+func UnmatchedReloadablePaths() []string {
+	var unmatched []string
+	for path := range reloadablePaths {
+		if _, ok := matchedReloadablePaths[path]; !ok {
+			unmatched = append(unmatched, path)
+		}
+	}
+	sort.Strings(unmatched)
+	return unmatched
+}
+
+// setRegisteredReloadable marks the fields registered through MarkReloadable as reloadable,
+// walking the blocks from the top-level block and the root blocks.
+func setRegisteredReloadable(blocks []*ConfigBlock, rootBlocks []RootBlock) {
+	roots := make(map[string]struct{}, len(rootBlocks))
+	for _, rootBlock := range rootBlocks {
+		roots[rootBlock.Name] = struct{}{}
+	}
+
+	for _, block := range blocks {
+		if _, ok := roots[block.Name]; ok || block.Name == "" {
+			setRegisteredEntriesReloadable(block, block.Name)
+		}
+	}
+}
+
+func setRegisteredEntriesReloadable(block *ConfigBlock, path string) {
+	for _, entry := range block.Entries {
+		entryPath := joinPath(path, entry.Name)
+
+		// Root blocks are walked on their own.
+		if entry.Kind == KindBlock {
+			if !entry.Root {
+				setRegisteredEntriesReloadable(entry.Block, entryPath)
+			}
+			continue
+		}
+
+		if _, ok := reloadablePaths[entryPath]; ok {
+			matchedReloadablePaths[entryPath] = struct{}{}
+			entry.FieldReloadable = true
+		}
+	}
+}
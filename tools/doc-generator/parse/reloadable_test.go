@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func markTestReloadable(t *testing.T, yamlPath string) {
+	MarkReloadable(yamlPath)
+	t.Cleanup(func() {
+		delete(reloadablePaths, yamlPath)
+		delete(matchedReloadablePaths, yamlPath)
+	})
+}
+
+func TestMarkReloadable(t *testing.T) {
+	type RootConfig struct {
+		Tagged     int `yaml:"tagged" doc:"reloadable"`
+		Registered int `yaml:"registered"`
+		Other      int `yaml:"other"`
+	}
+
+	cfg := &struct {
+		Root   RootConfig `yaml:"root"`
+		Nested struct {
+			Vendored string `yaml:"vendored"`
+		} `yaml:"nested"`
+	}{}
+	rootBlocks := []RootBlock{{Name: "root_config", Desc: "The root_config block.", StructType: reflect.TypeOf(RootConfig{})}}
+
+	markTestReloadable(t, "root_config.registered")
+	markTestReloadable(t, "nested.vendored")
+	markTestReloadable(t, "root_config.removed")
+
+	blocks, err := Config(cfg, nil, rootBlocks)
+	require.NoError(t, err)
+
+	root := blocks[0].Entries[0].Block
+	require.Equal(t, "root_config", root.Name)
+	assert.True(t, root.Entries[0].FieldReloadable)
+	assert.True(t, root.Entries[1].FieldReloadable)
+	assert.False(t, root.Entries[2].FieldReloadable)
+	assert.True(t, blocks[0].Entries[1].Block.Entries[0].FieldReloadable)
+
+	assert.Equal(t, []string{"root_config.removed"}, UnmatchedReloadablePaths())
+}
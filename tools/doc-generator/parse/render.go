@@ -0,0 +1,348 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"bytes"
+	_ "embed" // need this for defaultTemplate
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/grafana/regexp"
+	"github.com/mitchellh/go-wordwrap"
+)
+
+const (
+	renderMaxLineWidth = 80
+	renderTabWidth     = 2
+)
+
+//go:embed render.tmpl
+var defaultTemplate string
+
+// RenderData is the data model of the template executed by Render.
+type RenderData struct {
+	// Blocks to render, deduplicated by name: the top-level block first, followed
+	// by the root blocks in the RootBlocks order and then by any other block.
+	Blocks []*ConfigBlock
+
+	// Required fields and blocks, as returned by RequiredEntries.
+	Required []FlatEntry
+}
+
+// RenderOptions configures what Render writes on top of the template.
+type RenderOptions struct {
+	// GoTypes makes the goTypes function return true, so that the default template
+	// writes the Go type of the fields.
+	GoTypes bool
+}
+
+// RenderEntry is the data model of the "entry" template, executed for each entry
+// through the renderEntries function.
+type RenderEntry struct {
+	Entry *ConfigEntry
+
+	// Indentation of the entry, in spaces.
+	Indent int
+}
+
+// TemplateFuncs returns the functions available to the templates executed by Render:
+//
+//   - renderBlock BLOCK: executes the "block" template with the block.
+//   - renderEntries ENTRIES INDENT: executes the "entry" template with a RenderEntry for each entry,
+//     separated by an empty line.
+//   - anchor BLOCK: the markdown anchor of the block section, like "#blocks_storage".
+//   - slugify STRING: the string lowercased, with any non-alphanumeric character replaced by "-".
+//   - yamlPath ENTRY: the dot-separated YAML path of the entry, from its root block.
+//   - goTypes: whether the Go types of the fields are requested, through RenderOptions.
+//   - isRoot ENTRY: whether the entry references a root block.
+//   - byCategory CATEGORY ENTRIES: the field entries of the category. Fields without category are "basic".
+//   - comment TEXT INDENT: the text wrapped as a YAML comment.
+//   - example EXAMPLE INDENT: the field example as a YAML comment.
+//   - cliFlag NAME INDENT: the CLI flag as a YAML comment.
+//   - spec ENTRY INDENT: the YAML specification line of a field entry.
+//   - blockDesc BLOCK: the markdown description of the block, including its CLI flags prefixes.
+//   - pad N, add A B, trimSpace STRING.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		// Placeholders, replaced by Render with functions bound to the template being executed.
+		"renderBlock":   func(*ConfigBlock) (string, error) { return "", nil },
+		"renderEntries": func([]*ConfigEntry, int) (string, error) { return "", nil },
+		"yamlPath":      func(*ConfigEntry) string { return "" },
+		"goTypes":       func() bool { return false },
+
+		"anchor":     func(b *ConfigBlock) string { return "#" + slugify(b.Name) },
+		"slugify":    slugify,
+		"isRoot":     func(e *ConfigEntry) bool { return e.Kind == KindBlock && e.Root },
+		"byCategory": byCategory,
+		"comment":    renderComment,
+		"example":    renderExample,
+		"cliFlag":    renderFlag,
+		"spec":       renderSpec,
+		"blockDesc":  renderBlockDesc,
+		"pad":        func(n int) string { return strings.Repeat(" ", n) },
+		"add":        func(a, b int) int { return a + b },
+		"trimSpace":  strings.TrimSpace,
+		"join":       strings.Join,
+	}
+}
+
+// DefaultTemplate returns the template rendering the markdown reference configuration.
+func DefaultTemplate() *template.Template {
+	return template.Must(template.New("reference").Funcs(TemplateFuncs()).Parse(defaultTemplate))
+}
+
+// Render executes tmpl with the RenderData of the blocks, as returned by Config, writing
+// the output to w. tmpl must be parsed with TemplateFuncs and, in order to use renderBlock
+// and renderEntries, must define the "block" and "entry" templates.
+func Render(blocks []*ConfigBlock, tmpl *template.Template, w io.Writer) error {
+	return RenderWithOptions(blocks, tmpl, w, RenderOptions{})
+}
+
+// RenderWithOptions is like Render, but renders the blocks according to the options.
+func RenderWithOptions(blocks []*ConfigBlock, tmpl *template.Template, w io.Writer, opts RenderOptions) error {
+	tmpl, err := bindTemplate(blocks, tmpl, opts)
+	if err != nil {
+		return err
+	}
+
+	return tmpl.Execute(w, RenderData{Blocks: orderBlocks(blocks), Required: RequiredEntries(blocks)})
+}
+
+// RenderBlock executes the "block" template of tmpl with the block alone, writing the
+// output to w, like for embedding the reference of a single block in another document.
+func RenderBlock(block *ConfigBlock, tmpl *template.Template, w io.Writer, opts RenderOptions) error {
+	tmpl, err := bindTemplate([]*ConfigBlock{block}, tmpl, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := tmpl.ExecuteTemplate(w, "block", block); err != nil {
+		return fmt.Errorf("block %q: %w", block.Name, err)
+	}
+	return nil
+}
+
+// bindTemplate returns a clone of tmpl whose placeholder functions are bound to the blocks.
+func bindTemplate(blocks []*ConfigBlock, tmpl *template.Template, opts RenderOptions) (*template.Template, error) {
+	tmpl, err := tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := map[*ConfigEntry]string{}
+	for _, block := range blocks {
+		collectPaths(block, "", paths)
+	}
+
+	var renderEntries func([]*ConfigEntry, int) (string, error)
+	renderEntries = func(entries []*ConfigEntry, indent int) (string, error) {
+		out := &bytes.Buffer{}
+		for i, entry := range entries {
+			if i > 0 {
+				out.WriteString("\n")
+			}
+			if err := tmpl.ExecuteTemplate(out, "entry", RenderEntry{Entry: entry, Indent: indent}); err != nil {
+				return "", fmt.Errorf("entry %s: %w", paths[entry], err)
+			}
+		}
+		return out.String(), nil
+	}
+
+	tmpl.Funcs(template.FuncMap{
+		"renderBlock": func(block *ConfigBlock) (string, error) {
+			out := &bytes.Buffer{}
+			if err := tmpl.ExecuteTemplate(out, "block", block); err != nil {
+				return "", fmt.Errorf("block %q: %w", block.Name, err)
+			}
+			return out.String(), nil
+		},
+		"renderEntries": renderEntries,
+		"yamlPath":      func(e *ConfigEntry) string { return paths[e] },
+		"goTypes":       func() bool { return opts.GoTypes },
+	})
+
+	return tmpl, nil
+}
+
+// orderBlocks deduplicates the blocks by name, keeping the last one, and sorts them
+// with the top-level block first, followed by the root blocks in the RootBlocks order
+// and then by any other block in the input order.
+func orderBlocks(blocks []*ConfigBlock) []*ConfigBlock {
+	unique := map[string]*ConfigBlock{}
+	for _, block := range blocks {
+		unique[block.Name] = block
+	}
+
+	var ordered []*ConfigBlock
+	if top, ok := unique[""]; ok {
+		ordered = append(ordered, top)
+		delete(unique, "")
+	}
+	for _, rootBlock := range RootBlocks {
+		if block, ok := unique[rootBlock.Name]; ok {
+			ordered = append(ordered, block)
+			delete(unique, rootBlock.Name)
+		}
+	}
+	for _, block := range blocks {
+		if b, ok := unique[block.Name]; ok {
+			ordered = append(ordered, b)
+			delete(unique, block.Name)
+		}
+	}
+	return ordered
+}
+
+func collectPaths(block *ConfigBlock, parent string, paths map[*ConfigEntry]string) {
+	for _, entry := range block.Entries {
+		path := joinPath(parent, entry.Name)
+		paths[entry] = path
+		if entry.Kind == KindBlock && !entry.Root {
+			collectPaths(entry.Block, path, paths)
+		}
+	}
+}
+
+func slugify(s string) string {
+	return strings.Trim(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return unicode.ToLower(r)
+		}
+		return '-'
+	}, s), "-")
+}
+
+func byCategory(category string, entries []*ConfigEntry) []*ConfigEntry {
+	var filtered []*ConfigEntry
+	for _, entry := range entries {
+		if entry.Kind == KindBlock {
+			continue
+		}
+
+		entryCategory := entry.FieldCategory
+		if entryCategory == "" {
+			entryCategory = "basic"
+		}
+		if entryCategory == category {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+func renderComment(comment string, indent int) string {
+	if comment == "" {
+		return ""
+	}
+
+	wrapped := wordwrap.WrapString(comment, uint(renderMaxLineWidth-indent-2))
+	return renderWrappedString(wrapped, indent, 0)
+}
+
+func renderExample(example *FieldExample, indent int) (string, error) {
+	if example == nil {
+		return "", nil
+	}
+
+	out := renderComment("Example:", indent)
+	if example.Comment != "" {
+		out += renderWrappedString(wordwrap.WrapString(example.Comment, uint(renderMaxLineWidth-indent-4)), indent, 2)
+	}
+
+	data, err := example.YAML()
+	if err != nil {
+		return "", fmt.Errorf("can't render example: %w", err)
+	}
+
+	return out + renderWrappedString(string(data), indent, 2), nil
+}
+
+func renderWrappedString(s string, indent, innerIndent int) string {
+	out := strings.Builder{}
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		out.WriteString(strings.Repeat(" ", indent) + "# " + strings.Repeat(" ", innerIndent) + line + "\n")
+	}
+	return out.String()
+}
+
+func renderFlag(name string, indent int) string {
+	if name == "" {
+		return ""
+	}
+	return strings.Repeat(" ", indent) + "# CLI flag: -" + name + "\n"
+}
+
+func renderSpec(e *ConfigEntry, indent int) string {
+	fieldType := "<" + e.FieldType + ">"
+	if e.FieldUnit != "" {
+		fieldType += " (" + e.FieldUnit + ")"
+	}
+
+	spec := e.Name + ": " + fieldType + " | default = " + e.DisplayDefault()
+	if !e.Required {
+		spec = "[" + spec + "]"
+	}
+	return strings.Repeat(" ", indent) + spec + "\n"
+}
+
+func renderBlockDesc(block *ConfigBlock) string {
+	if block.Desc == "" {
+		return ""
+	}
+	desc := block.Desc
+
+	// Wrap first instance of the config block name with backticks
+	if block.Name != "" {
+		var matches int
+		nameRegexp := regexp.MustCompile(regexp.QuoteMeta(block.Name))
+		desc = nameRegexp.ReplaceAllStringFunc(desc, func(input string) string {
+			if matches == 0 {
+				matches++
+				return "`" + input + "`"
+			}
+			return input
+		})
+	}
+
+	// List of all prefixes used to reference this config block.
+	if len(block.FlagsPrefixes) > 1 {
+		sortedPrefixes := append([]string(nil), block.FlagsPrefixes...)
+		sort.Strings(sortedPrefixes)
+
+		desc += " The supported CLI flags `<prefix>` used to reference this configuration block are:\n\n"
+
+		for _, prefix := range sortedPrefixes {
+			if prefix == "" {
+				desc += "- _no prefix_\n"
+			} else {
+				desc += fmt.Sprintf("- `%s`\n", prefix)
+			}
+		}
+
+		// Workaround the website markdown compiler bug with a list followed by a code block.
+		desc += "\n&nbsp;"
+	}
+
+	return desc
+}
+
+func cleanupDuration(value string) string {
+	// This is the list of suffixes to remove from the duration if they're not
+	// the whole duration value.
+	suffixes := []string{"0s", "0m"}
+
+	for _, suffix := range suffixes {
+		re := regexp.MustCompile("(^.+\\D)" + suffix + "$")
+
+		if groups := re.FindStringSubmatch(value); len(groups) == 2 {
+			value = groups[1]
+		}
+	}
+
+	return value
+}
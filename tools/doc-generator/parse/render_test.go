@@ -0,0 +1,273 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func renderTestBlocks() []*ConfigBlock {
+	client := &ConfigBlock{
+		Name: "grpc_client",
+		Desc: "The grpc_client block configures the gRPC client.",
+		Entries: []*ConfigEntry{
+			{Kind: KindField, Name: "max_send_msg_size", FieldType: "int", FieldDefault: "104857600", FieldFlag: "client.grpc-max-send-msg-size", FieldDesc: "gRPC client max send message size.", FieldUnit: "bytes"},
+			{Kind: KindField, Name: "backoff_on_ratelimits", FieldType: "boolean", FieldDefault: "false", FieldFlag: "client.backoff-on-ratelimits", FieldCategory: "advanced"},
+		},
+	}
+
+	top := &ConfigBlock{
+		Entries: []*ConfigEntry{
+			{Kind: KindField, Name: "target", FieldType: "string", FieldDefault: "all", FieldFlag: "target", FieldDesc: "Comma-separated list of components to include."},
+			{Kind: KindBlock, Name: "server", Block: &ConfigBlock{
+				Entries: []*ConfigEntry{
+					{Kind: KindField, Name: "http_listen_port", FieldType: "int", FieldDefault: "8080", FieldFlag: "server.http-listen-port", FieldCategory: "experimental"},
+				},
+			}},
+			{Kind: KindBlock, Name: "grpc_client", Block: client, Root: true, RefBlock: "grpc_client"},
+		},
+	}
+
+	return []*ConfigBlock{top, client}
+}
+
+func TestRender_AlternativeTemplate(t *testing.T) {
+	tmpl, err := template.New("table.tmpl").Funcs(TemplateFuncs()).ParseFiles("testdata/table.tmpl")
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, Render(renderTestBlocks(), tmpl, out))
+
+	expected, err := os.ReadFile("testdata/table.md")
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), out.String())
+}
+
+func TestRender_ErrorsIncludeTheBlockAndEntry(t *testing.T) {
+	tmpl, err := template.New("broken").Funcs(TemplateFuncs()).Parse(
+		`{{range .Blocks}}{{renderBlock .}}{{end}}` +
+			`{{define "block"}}{{renderEntries .Entries 0}}{{end}}` +
+			`{{define "entry"}}{{renderEntries .Entry.Block.Entries 2}}{{end}}`)
+	require.NoError(t, err)
+
+	err = Render(renderTestBlocks(), tmpl, &bytes.Buffer{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `block ""`)
+	assert.Contains(t, err.Error(), "entry target")
+}
+
+func TestRender_Funcs(t *testing.T) {
+	tests := map[string]struct {
+		template string
+		expected string
+	}{
+		"anchor": {
+			template: `{{range .Blocks}}{{anchor .}} {{end}}`,
+			expected: "# #grpc_client ",
+		},
+		"slugify": {
+			template: `{{slugify "Blocks Storage.TSDB"}}`,
+			expected: "blocks-storage-tsdb",
+		},
+		"yamlPath and isRoot": {
+			template: `{{range (index .Blocks 0).Entries}}{{yamlPath .}}={{isRoot .}} {{end}}`,
+			expected: "target=false server=false grpc_client=true ",
+		},
+		"spec": {
+			template: `{{spec (index (index .Blocks 1).Entries 0) 0}}`,
+			expected: "[max_send_msg_size: <int> (bytes) | default = 104857600]\n",
+		},
+		"byCategory": {
+			template: `{{range byCategory "advanced" (index .Blocks 1).Entries}}{{.Name}}{{end}}`,
+			expected: "backoff_on_ratelimits",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tmpl, err := template.New(name).Funcs(TemplateFuncs()).Parse(test.template)
+			require.NoError(t, err)
+
+			out := &bytes.Buffer{}
+			require.NoError(t, Render(renderTestBlocks(), tmpl, out))
+			assert.Equal(t, test.expected, out.String())
+		})
+	}
+}
+
+// renderEntry returns the entry rendered by the default template, without the code block fences.
+func renderEntry(t *testing.T, entry *ConfigEntry, opts RenderOptions) string {
+	out := &bytes.Buffer{}
+	require.NoError(t, RenderWithOptions([]*ConfigBlock{{Entries: []*ConfigEntry{entry}}}, DefaultTemplate(), out, opts))
+	return strings.TrimSuffix(strings.TrimPrefix(out.String(), "```yaml\n"), "\n```")
+}
+
+func TestRender_DefaultTemplateEntries(t *testing.T) {
+	tests := map[string]struct {
+		entry    *ConfigEntry
+		goTypes  bool
+		expected string
+	}{
+		"zero time": {
+			entry:    &ConfigEntry{Kind: KindField, Name: "start", FieldType: "time"},
+			expected: "[start: <time> | default = (no default)]",
+		},
+		"non-UTC time": {
+			entry:    &ConfigEntry{Kind: KindField, Name: "start", FieldType: "time", FieldDefault: "2022-06-15T23:30:00-05:00"},
+			expected: "[start: <time> | default = 2022-06-15T23:30:00-05:00]",
+		},
+		"unset date": {
+			entry:    &ConfigEntry{Kind: KindField, Name: "from", FieldType: "date"},
+			expected: "[from: <date> | default = (no default)]",
+		},
+		"date": {
+			entry:    &ConfigEntry{Kind: KindField, Name: "from", FieldType: "date", FieldDefault: "2022-06-15"},
+			expected: "[from: <date> | default = 2022-06-15]",
+		},
+		"without go types": {
+			entry:    &ConfigEntry{Kind: KindField, Name: "trackers", FieldType: "map of tracker name (string) to matcher (string)", FieldFlag: "trackers", GoType: "activeseries.CustomTrackersConfig"},
+			expected: "# CLI flag: -trackers\n[trackers: <map of tracker name (string) to matcher (string)> | default = ]",
+		},
+		"go types": {
+			entry:   &ConfigEntry{Kind: KindField, Name: "trackers", FieldType: "map of tracker name (string) to matcher (string)", FieldFlag: "trackers", GoType: "activeseries.CustomTrackersConfig"},
+			goTypes: true,
+			expected: "# CLI flag: -trackers\n" +
+				"# Go type: activeseries.CustomTrackersConfig\n" +
+				"[trackers: <map of tracker name (string) to matcher (string)> | default = ]",
+		},
+		"units": {
+			entry:    &ConfigEntry{Kind: KindField, Name: "max_bytes", FieldType: "int", FieldDefault: "1024", FieldUnit: "bytes"},
+			expected: "[max_bytes: <int> (bytes) | default = 1024]",
+		},
+		"aliases": {
+			entry: &ConfigEntry{Kind: KindField, Name: "timeout", FieldType: "int", FieldFlag: "timeout", FieldDefault: "10", AliasesOf: []string{"client.timeout", "server.timeout"}},
+			expected: "# Alias of client.timeout, server.timeout.\n" +
+				"# CLI flag: -timeout\n" +
+				"[timeout: <int> | default = 10]",
+		},
+		"deprecated in favor of": {
+			entry: &ConfigEntry{Kind: KindField, Name: "old", FieldType: "int", FieldFlag: "old", FieldDefault: "10", FieldDesc: "The old option.", DeprecatedInFavorOf: "new"},
+			expected: "# The old option.\n" +
+				"# Deprecated: use new instead.\n" +
+				"# CLI flag: -old\n" +
+				"[old: <int> | default = 10]",
+		},
+		"conflicts with": {
+			entry: &ConfigEntry{Kind: KindField, Name: "local", FieldType: "boolean", FieldFlag: "local", FieldDefault: "false", FieldConflictsWith: []string{"remote.url", "-remote.enabled"}},
+			expected: "# Cannot be used together with remote.url, -remote.enabled.\n" +
+				"# CLI flag: -local\n" +
+				"[local: <boolean> | default = false]",
+		},
+		"reloadable": {
+			entry: &ConfigEntry{Kind: KindField, Name: "rate", FieldType: "int", FieldFlag: "rate", FieldDefault: "10", FieldReloadable: true},
+			expected: "# Reloadable at runtime without restarting.\n" +
+				"# CLI flag: -rate\n" +
+				"[rate: <int> | default = 10]",
+		},
+		"startup only": {
+			entry: &ConfigEntry{Kind: KindField, Name: "port", FieldType: "int", FieldFlag: "port", FieldDefault: "80", StartupOnly: true},
+			expected: "# Only applied at startup: changing it requires a restart.\n" +
+				"# CLI flag: -port\n" +
+				"[port: <int> | default = 80]",
+		},
+		"default changes": {
+			entry: &ConfigEntry{Kind: KindField, Name: "timeout", FieldType: "duration", FieldFlag: "timeout", FieldDefault: "10m0s",
+				FieldDefaultChanges: []DefaultChange{{Version: "2.5", Old: "5m0s"}, {Version: "2.3", Old: "1m0s"}}},
+			expected: "# Default changed from 5m0s in 2.5.\n" +
+				"# Default changed from 1m0s in 2.3.\n" +
+				"# CLI flag: -timeout\n" +
+				"[timeout: <duration> | default = 10m]",
+		},
+		"warnings": {
+			entry: &ConfigEntry{Kind: KindField, Name: "path", FieldType: "string", FieldDesc: "The path.", FieldFlag: "path",
+				FieldWarnings: []string{"Requires filesystem write access.", "Not shared across replicas."}},
+			expected: "# The path.\n" +
+				"# Warning: Requires filesystem write access.\n" +
+				"# Warning: Not shared across replicas.\n" +
+				"# CLI flag: -path\n" +
+				"[path: <string> | default = \"\"]",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, renderEntry(t, test.entry, RenderOptions{GoTypes: test.goTypes}))
+		})
+	}
+}
+
+func TestRender_DefaultTemplateSliceOfStructs(t *testing.T) {
+	element := &ConfigBlock{
+		Name: "subs",
+		Desc: "The subs block configures each sub.",
+		Entries: []*ConfigEntry{
+			{Kind: KindField, Name: "address", FieldType: "string"},
+		},
+	}
+	top := &ConfigBlock{
+		Entries: []*ConfigEntry{
+			{Kind: KindSlice, Name: "subs", FieldType: "list of SubConfig", Element: element},
+		},
+	}
+
+	out := &bytes.Buffer{}
+	require.NoError(t, Render([]*ConfigBlock{top, element}, DefaultTemplate(), out))
+	assert.Equal(t, "```yaml\n"+
+		"# Each element of the list is configured by the subs block.\n"+
+		"[subs: <list of SubConfig> | default = ]\n"+
+		"```\n"+
+		"\n"+
+		"### subs\n"+
+		"\n"+
+		"The `subs` block configures each sub.\n"+
+		"\n"+
+		"```yaml\n"+
+		"[address: <string> | default = \"\"]\n"+
+		"```", out.String())
+}
+
+func TestRender_DefaultTemplateRequiredEntries(t *testing.T) {
+	top := &ConfigBlock{
+		Entries: []*ConfigEntry{
+			{Kind: KindField, Name: "address", FieldType: "string", Required: true},
+			{Kind: KindBlock, Name: "storage", Required: true, Block: &ConfigBlock{
+				Entries: []*ConfigEntry{
+					{Kind: KindField, Name: "bucket", FieldType: "string"},
+				},
+			}},
+		},
+	}
+
+	out := &bytes.Buffer{}
+	require.NoError(t, Render([]*ConfigBlock{top}, DefaultTemplate(), out))
+	assert.Equal(t, "### Required fields\n\nThe following fields must be set, as they have no usable default.\n\n- `address`\n- `storage`\n\n```yaml\naddress: <string> | default = \"\"\n\nstorage:\n  [bucket: <string> | default = \"\"]\n```", out.String())
+}
+
+func TestRenderBlock(t *testing.T) {
+	blocks := renderTestBlocks()
+
+	// The block is rendered alone, without the required fields.
+	blocks[1].Entries[0].Required = true
+	out := &bytes.Buffer{}
+	require.NoError(t, RenderBlock(blocks[1], DefaultTemplate(), out, RenderOptions{}))
+	assert.Equal(t, "### grpc_client\n"+
+		"\n"+
+		"The `grpc_client` block configures the gRPC client.\n"+
+		"\n"+
+		"```yaml\n"+
+		"# gRPC client max send message size.\n"+
+		"# CLI flag: -client.grpc-max-send-msg-size\n"+
+		"max_send_msg_size: <int> (bytes) | default = 104857600\n"+
+		"\n"+
+		"# (advanced)\n"+
+		"# CLI flag: -client.backoff-on-ratelimits\n"+
+		"[backoff_on_ratelimits: <boolean> | default = false]\n"+
+		"```", out.String())
+}
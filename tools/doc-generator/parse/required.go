@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"sort"
+)
+
+// RequiredEntries returns the entries of the blocks, as returned by Config, which are marked
+// as required, sorted by path. Both fields and blocks are returned, so that a required block
+// is listed even if all its fields are optional. Root blocks referenced by multiple entries,
+// like the ones configured with multiple CLI flags prefixes, are walked through each entry,
+// so their required fields are listed once for each path they can be set at.
+func RequiredEntries(blocks []*ConfigBlock) []FlatEntry {
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	entries := map[string]FlatEntry{}
+	collectRequiredEntries(blocks[0], "", entries)
+
+	result := make([]FlatEntry, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+// collectRequiredEntries adds the required entries of the block, and of its sub-blocks, to
+// entries by path, so that an entry reached multiple times through the same path is listed once.
+func collectRequiredEntries(block *ConfigBlock, path string, entries map[string]FlatEntry) {
+	for _, entry := range block.Entries {
+		entryPath := joinPath(path, entry.Name)
+		if entry.Required {
+			entries[entryPath] = FlatEntry{Path: entryPath, Entry: entry}
+		}
+
+		if entry.Kind == KindBlock {
+			collectRequiredEntries(entry.Block, entryPath, entries)
+		} else if entry.Element != nil {
+			collectRequiredEntries(entry.Element, entryPath+"[]", entries)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type requiredTestClientConfig struct {
+	Endpoint string `yaml:"endpoint" doc:"required"`
+	Timeout  int    `yaml:"timeout"`
+}
+
+func TestRequiredEntries(t *testing.T) {
+	type level3 struct {
+		Token    string `yaml:"token" doc:"required"`
+		Optional string `yaml:"optional"`
+	}
+	type level2 struct {
+		Level3 level3 `yaml:"level3"`
+	}
+	type level1 struct {
+		Level2 level2 `yaml:"level2"`
+	}
+	type InlineConfig struct {
+		Level1 level1 `yaml:"level1"`
+	}
+	type storageConfig struct {
+		Bucket string `yaml:"bucket"`
+	}
+
+	type config struct {
+		InlineConfig `yaml:",inline"`
+		Address      string                   `yaml:"address" doc:"required"`
+		Storage      storageConfig            `yaml:"storage" doc:"required"`
+		Ruler        requiredTestClientConfig `yaml:"ruler"`
+		Alertmanager requiredTestClientConfig `yaml:"alertmanager"`
+		Targets      []struct {
+			URL string `yaml:"url" doc:"required"`
+		} `yaml:"targets"`
+	}
+
+	rootBlocks := []RootBlock{{Name: "client_config", StructType: reflect.TypeOf(requiredTestClientConfig{})}}
+	blocks, err := Config(&config{}, nil, rootBlocks)
+	require.NoError(t, err)
+
+	var paths []string
+	for _, entry := range RequiredEntries(blocks) {
+		paths = append(paths, entry.Path)
+	}
+
+	// The required block is listed even if its fields are optional, and the fields
+	// of the root block are listed once for each entry referencing it.
+	assert.Equal(t, []string{
+		"address",
+		"alertmanager.endpoint",
+		"level1.level2.level3.token",
+		"ruler.endpoint",
+		"storage",
+		"targets[].url",
+	}, paths)
+}
+
+func TestRequiredEntries_NoBlocks(t *testing.T) {
+	assert.Empty(t, RequiredEntries(nil))
+}
@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SnippetFor returns a minimal YAML document setting the field at the dot-separated YAML path
+// from the top-level block, like "blocks_storage.bucket_store.sync_interval", to its default.
+// The field is nested under the keys of its parent blocks, like in the config file, so inline
+// structs don't add a nesting level, and it's preceded by its description as a comment.
+//
+// A root block configured at multiple places, like a client config registered with multiple
+// CLI flags prefixes, must be addressed through the path of one of the fields referencing it,
+// so that the caller picks the instance. A path starting with the name of a root block, like
+// "grpc_client.backoff_on_ratelimits", is only accepted if the block is referenced once.
+func SnippetFor(blocks []*ConfigBlock, dottedPath string) (string, error) {
+	if len(blocks) == 0 {
+		return "", fmt.Errorf("unknown field %s", dottedPath)
+	}
+
+	names := strings.Split(dottedPath, ".")
+	chain, ok := entryChain(blocks[0], names)
+	if !ok {
+		refs := rootBlockReferences(blocks[0], "", names[0])
+		switch {
+		case len(refs) == 0 || len(names) == 1:
+			return "", fmt.Errorf("unknown field %s", dottedPath)
+		case len(refs) > 1:
+			return "", fmt.Errorf("the %s block of the field %s is configured at multiple paths, pick one of: %s", names[0], dottedPath, strings.Join(refs, ", "))
+		}
+
+		names = append(strings.Split(refs[0], "."), names[1:]...)
+		if chain, ok = entryChain(blocks[0], names); !ok {
+			return "", fmt.Errorf("unknown field %s", dottedPath)
+		}
+	}
+
+	field := chain[len(chain)-1]
+	if field.Kind == KindBlock {
+		return "", fmt.Errorf("%s is a block, while a field is expected", dottedPath)
+	}
+
+	out := strings.Builder{}
+	for i, entry := range chain[:len(chain)-1] {
+		out.WriteString(strings.Repeat(" ", i*renderTabWidth) + entry.Name + ":\n")
+	}
+
+	indent := (len(chain) - 1) * renderTabWidth
+	out.WriteString(renderComment(field.Description(), indent))
+	out.WriteString(strings.Repeat(" ", indent) + field.Name + ": " + snippetValue(field) + "\n")
+	return out.String(), nil
+}
+
+// entryChain returns the entries at each level of the path of names, from the block down to the
+// last name, and whether the path exists.
+func entryChain(block *ConfigBlock, names []string) ([]*ConfigEntry, bool) {
+	chain := make([]*ConfigEntry, 0, len(names))
+	for _, name := range names {
+		if block == nil {
+			return nil, false
+		}
+
+		var found *ConfigEntry
+		for _, entry := range block.Entries {
+			if entry.Name == name {
+				found = entry
+				break
+			}
+		}
+		if found == nil {
+			return nil, false
+		}
+
+		chain = append(chain, found)
+		block = nil
+		if found.Kind == KindBlock {
+			block = found.Block
+		}
+	}
+	return chain, true
+}
+
+// rootBlockReferences returns the paths of the entries referencing the root block with the name.
+func rootBlockReferences(block *ConfigBlock, path, name string) []string {
+	var refs []string
+	for _, entry := range block.Entries {
+		if entry.Kind != KindBlock {
+			continue
+		}
+
+		entryPath := joinPath(path, entry.Name)
+		if entry.Root && entry.Block.Name == name {
+			refs = append(refs, entryPath)
+			continue
+		}
+		refs = append(refs, rootBlockReferences(entry.Block, entryPath, name)...)
+	}
+	return refs
+}
+
+// snippetValue returns the default of the field as a YAML value, which is the raw default
+// rather than the one formatted for humans, so that it can be set back.
+func snippetValue(e *ConfigEntry) string {
+	value := e.RawDefault()
+	switch {
+	case e.IsUnset(), e.NoDefault:
+		return "null"
+	case e.FieldType == "string":
+		return strconv.Quote(value)
+	case value == "" && (e.Kind == KindSlice || (e.FieldTypeSpec != nil && e.FieldTypeSpec.Kind == TypeList)):
+		return "[]"
+	case value == "" && (e.Kind == KindMap || (e.FieldTypeSpec != nil && e.FieldTypeSpec.Kind == TypeMap)):
+		return "{}"
+	case value == "":
+		return "null"
+	case e.FieldType == "duration":
+		return cleanupDuration(value)
+	}
+	return value
+}
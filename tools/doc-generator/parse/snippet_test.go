@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"flag"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type snippetClientConfig struct {
+	Endpoint string        `yaml:"endpoint"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+func (cfg *snippetClientConfig) RegisterFlagsWithPrefix(prefix string, fs *flag.FlagSet) {
+	fs.StringVar(&cfg.Endpoint, prefix+"client.endpoint", "", "The endpoint of the client.")
+	fs.DurationVar(&cfg.Timeout, prefix+"client.timeout", 10*time.Second, "The timeout of the client.")
+}
+
+type snippetStoreConfig struct {
+	Address string `yaml:"address"`
+}
+
+type SnippetServerConfig struct {
+	LogLevel string `yaml:"log_level"`
+}
+
+type snippetConfig struct {
+	SnippetServerConfig `yaml:",inline"`
+
+	BlocksStorage struct {
+		BucketStore struct {
+			SyncInterval   time.Duration `yaml:"sync_interval" category:"advanced"`
+			IgnoredTenants []string      `yaml:"ignored_tenants"`
+		} `yaml:"bucket_store"`
+	} `yaml:"blocks_storage"`
+
+	Ruler struct {
+		Client snippetClientConfig `yaml:"client"`
+	} `yaml:"ruler"`
+	Alertmanager struct {
+		Client snippetClientConfig `yaml:"client"`
+	} `yaml:"alertmanager"`
+	Store snippetStoreConfig `yaml:"store"`
+}
+
+func snippetTestBlocks(t *testing.T) []*ConfigBlock {
+	cfg := &snippetConfig{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.StringVar(&cfg.LogLevel, "log.level", "info", "Only log messages with the given severity or above.")
+	fs.DurationVar(&cfg.BlocksStorage.BucketStore.SyncInterval, "blocks-storage.bucket-store.sync-interval", 15*time.Minute, "How frequently to scan the bucket to look for changes (new blocks shipped by ingesters and blocks deleted by retention or compaction).")
+	fs.StringVar(&cfg.Store.Address, "store.address", "localhost:9095", "The address of the store.")
+	cfg.Ruler.Client.RegisterFlagsWithPrefix("ruler.", fs)
+	cfg.Alertmanager.Client.RegisterFlagsWithPrefix("alertmanager.", fs)
+
+	rootBlocks := []RootBlock{
+		{Name: "client_config", StructType: reflect.TypeOf(snippetClientConfig{})},
+		{Name: "store_config", StructType: reflect.TypeOf(snippetStoreConfig{})},
+	}
+	blocks, err := Config(cfg, testFlags(fs), rootBlocks)
+	require.NoError(t, err)
+	return blocks
+}
+
+func TestSnippetFor(t *testing.T) {
+	tests := map[string]string{
+		"blocks_storage.bucket_store.sync_interval":   "testdata/snippets/nested.yaml",
+		"blocks_storage.bucket_store.ignored_tenants": "testdata/snippets/list.yaml",
+		"log_level":                   "testdata/snippets/inline.yaml",
+		"alertmanager.client.timeout": "testdata/snippets/root_block.yaml",
+		// Root blocks referenced once can be addressed by name.
+		"store_config.address": "testdata/snippets/root_block_by_name.yaml",
+	}
+
+	blocks := snippetTestBlocks(t)
+	for path, fixture := range tests {
+		t.Run(path, func(t *testing.T) {
+			snippet, err := SnippetFor(blocks, path)
+			require.NoError(t, err)
+
+			expected, err := os.ReadFile(fixture)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), snippet)
+		})
+	}
+}
+
+func TestSnippetFor_Errors(t *testing.T) {
+	tests := map[string]string{
+		"unknown":                     "unknown field unknown",
+		"blocks_storage.unknown":      "unknown field blocks_storage.unknown",
+		"log_level.unknown":           "unknown field log_level.unknown",
+		"blocks_storage.bucket_store": "blocks_storage.bucket_store is a block, while a field is expected",
+		"client_config.endpoint":      "the client_config block of the field client_config.endpoint is configured at multiple paths, pick one of: ruler.client, alertmanager.client",
+	}
+
+	blocks := snippetTestBlocks(t)
+	for path, expected := range tests {
+		t.Run(path, func(t *testing.T) {
+			_, err := SnippetFor(blocks, path)
+			require.Error(t, err)
+			assert.Equal(t, expected, err.Error())
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+// SplitByCategory returns a deep copy of the blocks, as returned by Config, keeping only
+// the fields whose category is one of the input categories. Fields without a category
+// are considered basic. Blocks left without entries are dropped, as well as the blocks
+// which are no longer referenced by any entry, except the top-level block which is
+// always the first one. The returned blocks don't share any memory with the input ones.
+func SplitByCategory(blocks []*ConfigBlock, categories ...string) []*ConfigBlock {
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	s := categorySplitter{
+		categories: make(map[string]struct{}, len(categories)),
+		copies:     map[*ConfigBlock]*ConfigBlock{},
+	}
+	for _, category := range categories {
+		s.categories[category] = struct{}{}
+	}
+
+	result := []*ConfigBlock{s.copyBlock(blocks[0])}
+	for _, block := range blocks[1:] {
+		if copied, ok := s.copies[block]; ok && len(copied.Entries) > 0 && copied != result[0] {
+			result = append(result, copied)
+		}
+	}
+
+	return result
+}
+
+type categorySplitter struct {
+	categories map[string]struct{}
+
+	// Copies of the blocks visited so far, so that a block referenced
+	// multiple times is copied (and filtered) only once.
+	copies map[*ConfigBlock]*ConfigBlock
+}
+
+func (s categorySplitter) copyBlock(block *ConfigBlock) *ConfigBlock {
+	if copied, ok := s.copies[block]; ok {
+		return copied
+	}
+
+	copied := &ConfigBlock{
+		Name:          block.Name,
+		Desc:          block.Desc,
+		FlagsPrefix:   block.FlagsPrefix,
+		FlagsPrefixes: copyStrings(block.FlagsPrefixes),
+		InlinedDescs:  copyStringMap(block.InlinedDescs),
+		Category:      block.Category,
+	}
+	s.copies[block] = copied
+
+	for _, entry := range block.Entries {
+		if e := s.copyEntry(entry); e != nil {
+			copied.Add(e)
+		}
+	}
+
+	return copied
+}
+
+// copyEntry returns a copy of the entry, or nil if the entry should be filtered out.
+func (s categorySplitter) copyEntry(entry *ConfigEntry) *ConfigEntry {
+	copied := *entry
+	copied.InlinedFrom = copyStrings(entry.InlinedFrom)
+	copied.FieldFlagAlternates = copyStrings(entry.FieldFlagAlternates)
+	copied.FieldSentinels = copyStringMap(entry.FieldSentinels)
+	copied.FieldWarnings = copyStrings(entry.FieldWarnings)
+	copied.AliasesOf = copyStrings(entry.AliasesOf)
+	copied.FieldConflictsWith = copyStrings(entry.FieldConflictsWith)
+	if entry.FieldDefaultChanges != nil {
+		copied.FieldDefaultChanges = append(make([]DefaultChange, 0, len(entry.FieldDefaultChanges)), entry.FieldDefaultChanges...)
+	}
+	if entry.FieldBits != nil {
+		copied.FieldBits = append(make([]BitFlag, 0, len(entry.FieldBits)), entry.FieldBits...)
+	}
+	copied.FieldTypeSpec = copyTypeSpec(entry.FieldTypeSpec)
+	copied.FieldMin = copyFloat64(entry.FieldMin)
+	copied.FieldMax = copyFloat64(entry.FieldMax)
+	if entry.FieldExample != nil {
+		example := *entry.FieldExample
+		example.ElementComments = copyStrings(example.ElementComments)
+		copied.FieldExample = &example
+	}
+
+	if entry.Kind == KindBlock {
+		copied.Block = s.copyBlock(entry.Block)
+		if len(copied.Block.Entries) == 0 {
+			return nil
+		}
+		return &copied
+	}
+
+	category := entry.FieldCategory
+	if category == "" {
+		category = "basic"
+	}
+	if _, ok := s.categories[category]; !ok {
+		return nil
+	}
+
+	if entry.Element != nil {
+		copied.Element = s.copyBlock(entry.Element)
+	}
+	return &copied
+}
+
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append(make([]string, 0, len(s)), s...)
+}
+
+func copyTypeSpec(s *TypeSpec) *TypeSpec {
+	if s == nil {
+		return nil
+	}
+	copied := *s
+	copied.Elem = copyTypeSpec(s.Elem)
+	copied.Key = copyTypeSpec(s.Key)
+	return &copied
+}
+
+func copyFloat64(v *float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	copied := *v
+	return &copied
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+
+	copied := make(map[string]string, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
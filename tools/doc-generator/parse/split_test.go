@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitByCategory(t *testing.T) {
+	type AdvancedRoot struct {
+		Advanced string `yaml:"advanced" category:"advanced"`
+	}
+
+	type MixedRoot struct {
+		Basic    string `yaml:"basic"`
+		Advanced string `yaml:"advanced" category:"advanced"`
+	}
+
+	type InlineConfig struct {
+		InlineBasic    string `yaml:"inline_basic"`
+		InlineAdvanced string `yaml:"inline_advanced" category:"advanced"`
+	}
+
+	type config struct {
+		InlineConfig `yaml:",inline"`
+		Basic        string `yaml:"basic"`
+		Experimental string `yaml:"experimental" category:"experimental"`
+		Nested       struct {
+			Basic    string `yaml:"basic"`
+			Advanced string `yaml:"advanced" category:"advanced"`
+		} `yaml:"nested"`
+		AdvancedRoot AdvancedRoot `yaml:"advanced_root"`
+		MixedRoot    MixedRoot    `yaml:"mixed_root"`
+	}
+
+	rootBlocks := []RootBlock{
+		{Name: "advanced_root", StructType: reflect.TypeOf(AdvancedRoot{})},
+		{Name: "mixed_root", StructType: reflect.TypeOf(MixedRoot{})},
+	}
+	blocks, err := Config(&config{}, nil, rootBlocks)
+	require.NoError(t, err)
+	require.Len(t, blocks, 3)
+
+	t.Run("basic", func(t *testing.T) {
+		actual := SplitByCategory(blocks, "basic")
+		require.Len(t, actual, 2)
+
+		assert.Equal(t, []string{"inline_basic", "basic", "nested", "mixed_root"}, entryNames(actual[0]))
+		assert.Equal(t, []string{"basic"}, entryNames(actual[0].Entries[2].Block))
+
+		// The root block which became empty is dropped, while the other one is referenced by its entry.
+		assert.Equal(t, "mixed_root", actual[1].Name)
+		assert.Equal(t, []string{"basic"}, entryNames(actual[1]))
+		assert.Same(t, actual[1], actual[0].Entries[3].Block)
+	})
+
+	t.Run("basic only", func(t *testing.T) {
+		actual := SplitByCategory(blocks, "basic")
+
+		// Every field left is basic, and every block left has entries.
+		var check func(block *ConfigBlock)
+		check = func(block *ConfigBlock) {
+			assert.NotEmpty(t, block.Entries, block.Name)
+			for _, entry := range block.Entries {
+				if entry.Kind == KindBlock {
+					check(entry.Block)
+					continue
+				}
+				assert.Empty(t, entry.FieldCategory, entry.Name)
+			}
+		}
+		for _, block := range actual {
+			check(block)
+		}
+	})
+
+	t.Run("basic and advanced", func(t *testing.T) {
+		actual := SplitByCategory(blocks, "basic", "advanced")
+		require.Len(t, actual, 3)
+
+		assert.Equal(t, []string{"inline_basic", "inline_advanced", "basic", "nested", "advanced_root", "mixed_root"}, entryNames(actual[0]))
+		assert.Equal(t, []string{"advanced"}, entryNames(actual[1]))
+		assert.Equal(t, []string{"basic", "advanced"}, entryNames(actual[2]))
+	})
+
+	t.Run("no matching category", func(t *testing.T) {
+		actual := SplitByCategory(blocks, "deprecated")
+		require.Len(t, actual, 1)
+		assert.Empty(t, actual[0].Entries)
+	})
+
+	t.Run("the copy doesn't alias the input", func(t *testing.T) {
+		actual := SplitByCategory(blocks, "basic", "advanced", "experimental")
+		require.Len(t, actual, 3)
+
+		actual[0].Entries[0].InlinedFrom[0] = "changed"
+		actual[0].Entries[0].FieldDesc = "changed"
+		actual[2].Entries = actual[2].Entries[:1]
+
+		assert.Equal(t, []string{"InlineConfig"}, blocks[0].Entries[0].InlinedFrom)
+		assert.Equal(t, "", blocks[0].Entries[0].FieldDesc)
+		assert.Len(t, blocks[2].Entries, 2)
+
+		// Nested types are copied too.
+		typed, err := Config(&struct {
+			Limits map[string]int `yaml:"limits"`
+			Ports  []int          `yaml:"ports"`
+		}{}, nil, nil)
+		require.NoError(t, err)
+
+		actual = SplitByCategory(typed, "basic")
+		actual[0].Entries[0].FieldTypeSpec.Optional = true
+		actual[0].Entries[0].FieldTypeSpec.Key.Name = "changed"
+		actual[0].Entries[0].FieldTypeSpec.Elem.Name = "changed"
+		actual[0].Entries[1].FieldTypeSpec.Elem.Name = "changed"
+
+		assert.Equal(t, "map of string to int", typed[0].Entries[0].FieldTypeSpec.String())
+		assert.False(t, typed[0].Entries[0].FieldTypeSpec.Optional)
+		assert.Equal(t, "list of int", typed[0].Entries[1].FieldTypeSpec.String())
+	})
+}
+
+func entryNames(block *ConfigBlock) []string {
+	var names []string
+	for _, entry := range block.Entries {
+		names = append(names, entry.Name)
+	}
+	return names
+}
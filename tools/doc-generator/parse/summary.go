@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Summary of the changes of the config options between two versions of the config.
+type Summary struct {
+	// Dot-separated YAML paths of the added, removed and changed fields, sorted.
+	Added   []string
+	Removed []string
+	Changed []string
+
+	// DefaultsChanged is the number of changed fields whose default value changed.
+	DefaultsChanged int
+}
+
+// Summarize returns the summary of the changes between the previous blocks, serialized
+// by MarshalJSON, and the current ones, as returned by Config. The previous blocks may
+// have been serialized by an older version of the JSON format.
+func Summarize(prev []byte, current []*ConfigBlock) (Summary, error) {
+	prevBlocks, err := unmarshalJSON(prev, true)
+	if err != nil {
+		return Summary{}, errors.Wrap(err, "can't parse the previous config")
+	}
+
+	summary := Summary{Added: DiffConfig(prevBlocks, current).Added}
+
+	for _, change := range BreakingChanges(prevBlocks, current) {
+		switch {
+		case change.Kind == ChangeRemoved:
+			summary.Removed = append(summary.Removed, change.Path)
+		case len(summary.Changed) == 0 || summary.Changed[len(summary.Changed)-1] != change.Path:
+			// Changes are sorted by path, so multiple changes of the same field are consecutive.
+			summary.Changed = append(summary.Changed, change.Path)
+		}
+		if change.Kind == ChangeDefaultChanged {
+			summary.DefaultsChanged++
+		}
+	}
+
+	return summary, nil
+}
+
+// Empty returns whether no config option has been added, removed or changed.
+func (s Summary) Empty() bool {
+	return len(s.Added) == 0 && len(s.Removed) == 0 && len(s.Changed) == 0
+}
+
+// String returns the summary in markdown, suitable for a pull request comment.
+func (s Summary) String() string {
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "This change adds %d config %s, removes %d, changes %d (%d %s).\n",
+		len(s.Added), pluralize(len(s.Added), "option", "options"), len(s.Removed), len(s.Changed),
+		s.DefaultsChanged, pluralize(s.DefaultsChanged, "default", "defaults"))
+
+	writeSection := func(title string, paths []string) {
+		if len(paths) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "\n**%s**\n\n", title)
+		for _, path := range paths {
+			fmt.Fprintf(&sb, "- `%s`\n", path)
+		}
+	}
+	writeSection("Added", s.Added)
+	writeSection("Removed", s.Removed)
+	writeSection("Changed", s.Changed)
+
+	return sb.String()
+}
+
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
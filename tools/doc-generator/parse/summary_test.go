@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(t *testing.T) {
+	prev, err := MarshalJSON(breakingTestBlocks(
+		&ConfigEntry{Kind: KindField, Name: "max", FieldType: "int", FieldDefault: "10"},
+		&ConfigEntry{Kind: KindField, Name: "min", FieldType: "int", FieldDefault: "1"},
+		&ConfigEntry{Kind: KindField, Name: "labels", FieldType: "string"},
+	))
+	require.NoError(t, err)
+
+	current := breakingTestBlocks(
+		&ConfigEntry{Kind: KindField, Name: "max", FieldType: "int", FieldDefault: "20", FieldCategory: "advanced"},
+		&ConfigEntry{Kind: KindField, Name: "labels", FieldType: "string"},
+		&ConfigEntry{Kind: KindBlock, Name: "ring", Block: &ConfigBlock{Entries: []*ConfigEntry{
+			{Kind: KindField, Name: "address", FieldType: "string"},
+		}}},
+	)
+
+	summary, err := Summarize(prev, current)
+	require.NoError(t, err)
+	assert.Equal(t, Summary{
+		Added:           []string{"limits.ring.address"},
+		Removed:         []string{"limits.min"},
+		Changed:         []string{"limits.max"},
+		DefaultsChanged: 1,
+	}, summary)
+	assert.False(t, summary.Empty())
+
+	assert.Equal(t, "This change adds 1 config option, removes 1, changes 1 (1 default).\n"+
+		"\n**Added**\n\n- `limits.ring.address`\n"+
+		"\n**Removed**\n\n- `limits.min`\n"+
+		"\n**Changed**\n\n- `limits.max`\n", summary.String())
+}
+
+func TestSummarize_NoChanges(t *testing.T) {
+	blocks := breakingTestBlocks(&ConfigEntry{Kind: KindField, Name: "max", FieldType: "int", FieldDefault: "10"})
+	prev, err := MarshalJSON(blocks)
+	require.NoError(t, err)
+
+	summary, err := Summarize(prev, blocks)
+	require.NoError(t, err)
+	assert.True(t, summary.Empty())
+	assert.Equal(t, "This change adds 0 config options, removes 0, changes 0 (0 defaults).\n", summary.String())
+}
+
+func TestSummarize_OlderVersion(t *testing.T) {
+	// Documents generated by older versions may have properties since removed.
+	prev := `{"version": 1, "blocks": [{"entries": [{"kind": "block", "name": "limits", "block": {"entries": [
+		{"kind": "field", "name": "max", "fieldType": "int", "fieldDefault": "10", "obsolete": true}
+	]}}]}]}`
+
+	current := breakingTestBlocks(&ConfigEntry{Kind: KindField, Name: "max", FieldType: "int", FieldDefault: "10"})
+
+	summary, err := Summarize([]byte(prev), current)
+	require.NoError(t, err)
+	assert.True(t, summary.Empty())
+
+	_, err = Summarize([]byte(`{"version": 2, "blocks": []}`), current)
+	assert.EqualError(t, err, "can't parse the previous config: unsupported version 2, expected 1")
+
+	_, err = Summarize([]byte(`{}`), current)
+	assert.EqualError(t, err, "can't parse the previous config: unsupported version 0, expected 1")
+}
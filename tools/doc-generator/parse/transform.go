@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+// transformBlocks applies the EntryTransform and BlockTransform options to the blocks, as
+// returned by config, and returns the remaining blocks.
+func transformBlocks(blocks []*ConfigBlock, opts Options) []*ConfigBlock {
+	if opts.EntryTransform == nil && opts.BlockTransform == nil {
+		return blocks
+	}
+
+	// Root blocks are transformed on their own, and then replaced in the entries referencing them.
+	replaced := map[*ConfigBlock]*ConfigBlock{}
+	transformed := make([]*ConfigBlock, 0, len(blocks))
+	for i, block := range blocks {
+		var path []string
+		if i > 0 {
+			path = []string{block.Name}
+
+			if opts.BlockTransform != nil {
+				b, ok := opts.BlockTransform(path, block)
+				if !ok {
+					replaced[block] = nil
+					continue
+				}
+				replaced[block] = b
+				block = b
+			}
+		}
+
+		transformEntries(block, path, opts)
+		transformed = append(transformed, block)
+	}
+
+	// Names of the replaced root blocks, referenced by the fields whose type is a root block.
+	// The references to the dropped ones are cleared.
+	names := map[string]string{}
+	for old, b := range replaced {
+		if b != nil {
+			names[old.Name] = b.Name
+		} else {
+			names[old.Name] = ""
+		}
+	}
+
+	for _, block := range transformed {
+		replaceRootBlockReferences(block, replaced, names)
+	}
+	return transformed
+}
+
+func transformEntries(block *ConfigBlock, path []string, opts Options) {
+	entries := block.Entries[:0]
+	for _, entry := range block.Entries {
+		entryPath := appendPath(path, entry.Name)
+
+		if opts.EntryTransform != nil {
+			e, ok := opts.EntryTransform(entryPath, entry)
+			if !ok {
+				continue
+			}
+			entry = e
+		}
+
+		// Root blocks are transformed on their own.
+		if entry.Kind == KindBlock && !entry.Root {
+			if opts.BlockTransform != nil {
+				b, ok := opts.BlockTransform(entryPath, entry.Block)
+				if !ok {
+					continue
+				}
+				entry.Block = b
+			}
+			transformEntries(entry.Block, entryPath, opts)
+		}
+		if entry.Element != nil {
+			elementPath := appendPath(path, entry.Name+"[]")
+			transformEntries(entry.Element, elementPath, opts)
+		}
+
+		entries = append(entries, entry)
+	}
+	block.Entries = entries
+}
+
+// replaceRootBlockReferences replaces the root blocks referenced by the entries of the block,
+// and of its nested blocks, according to replaced, and their names according to names.
+// Entries referencing a dropped root block, mapped to nil, are dropped too.
+func replaceRootBlockReferences(block *ConfigBlock, replaced map[*ConfigBlock]*ConfigBlock, names map[string]string) {
+	entries := block.Entries[:0]
+	for _, entry := range block.Entries {
+		if entry.Kind == KindBlock && entry.Root {
+			if b, ok := replaced[entry.Block]; ok {
+				if b == nil {
+					continue
+				}
+				entry.Block = b
+			}
+		} else if entry.Kind == KindBlock {
+			replaceRootBlockReferences(entry.Block, replaced, names)
+		}
+		if entry.Element != nil {
+			replaceRootBlockReferences(entry.Element, replaced, names)
+		}
+		if name, ok := names[entry.RefBlock]; ok {
+			entry.RefBlock = name
+		}
+
+		entries = append(entries, entry)
+	}
+	block.Entries = entries
+}
+
+// appendPath returns a copy of path with name appended, so that it isn't shared with the
+// paths of the sibling entries.
+func appendPath(path []string, name string) []string {
+	return append(append(make([]string, 0, len(path)+1), path...), name)
+}
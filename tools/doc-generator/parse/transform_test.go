@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"bytes"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type transformServerConfig struct {
+	Port     int    `yaml:"port"`
+	Internal string `yaml:"internal"`
+}
+
+type transformTestConfig struct {
+	Server transformServerConfig `yaml:"server"`
+	Other  transformServerConfig `yaml:"other_server"`
+	Store  struct {
+		Dir string `yaml:"dir"`
+	} `yaml:"store"`
+}
+
+func transformTestBlocks(t *testing.T, opts Options) []*ConfigBlock {
+	cfg := &transformTestConfig{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.IntVar(&cfg.Server.Port, "server.port", 80, "The port of Mimir.")
+	fs.StringVar(&cfg.Server.Internal, "server.internal", "", "An internal option.")
+	fs.StringVar(&cfg.Store.Dir, "store.dir", "./data", "The directory of Mimir.")
+
+	rootBlocks := []RootBlock{
+		{Name: "server_config", Desc: "The server block.", StructType: reflect.TypeOf(transformServerConfig{})},
+	}
+
+	blocks, err := ConfigWithOptions(cfg, testFlags(fs), rootBlocks, opts)
+	require.NoError(t, err)
+	return blocks
+}
+
+func TestConfigWithOptions_EntryTransform(t *testing.T) {
+	var paths []string
+	blocks := transformTestBlocks(t, Options{
+		EntryTransform: func(path []string, e *ConfigEntry) (*ConfigEntry, bool) {
+			paths = append(paths, strings.Join(path, "."))
+
+			// Drop the internal field.
+			if e.Name == "internal" {
+				return nil, false
+			}
+
+			// Rewrite the descriptions with the product name.
+			copied := *e
+			copied.FieldDesc = strings.ReplaceAll(e.FieldDesc, "Mimir", "Acme")
+			return &copied, true
+		},
+	})
+
+	assert.Equal(t, []string{
+		"server",
+		"other_server",
+		"store",
+		"store.dir",
+		"server_config.port",
+		"server_config.internal",
+		"server_config.port",
+		"server_config.internal",
+	}, paths)
+
+	top := blocks[0]
+	require.Len(t, top.Entries, 3)
+	assert.Equal(t, "The directory of Acme.", top.Entries[2].Block.Entries[0].FieldDesc)
+
+	for _, block := range blocks[1:] {
+		assert.Equal(t, "server_config", block.Name)
+		require.Len(t, block.Entries, 1)
+		assert.Equal(t, "port", block.Entries[0].Name)
+	}
+	assert.Equal(t, "The port of Acme.", blocks[1].Entries[0].FieldDesc)
+
+	// The root block references still point to the transformed blocks.
+	assert.Same(t, blocks[1], top.Entries[0].Block)
+	assert.Same(t, blocks[2], top.Entries[1].Block)
+}
+
+func TestConfigWithOptions_BlockTransform(t *testing.T) {
+	blocks := transformTestBlocks(t, Options{
+		BlockTransform: func(path []string, b *ConfigBlock) (*ConfigBlock, bool) {
+			switch b.Name {
+			case "server_config":
+				// Rename the root block.
+				copied := *b
+				copied.Name = "acme_server_config"
+				return &copied, true
+			case "store":
+				// Drop the nested block.
+				return nil, false
+			}
+			return b, true
+		},
+	})
+
+	top := blocks[0]
+	require.Len(t, top.Entries, 2)
+	for i, entry := range top.Entries {
+		assert.True(t, entry.Root)
+		assert.Equal(t, "acme_server_config", entry.RefBlock)
+		assert.Same(t, blocks[i+1], entry.Block)
+		assert.Equal(t, "acme_server_config", entry.Block.Name)
+	}
+
+	// The rendered reference follows the renamed block.
+	out := &bytes.Buffer{}
+	require.NoError(t, Render(blocks, DefaultTemplate(), out))
+	assert.Contains(t, out.String(), "### acme_server_config")
+	assert.Contains(t, out.String(), "[server: <acme_server_config>]")
+	assert.NotContains(t, out.String(), "store")
+}
+
+func TestConfigWithOptions_BlockTransformDroppingRootBlock(t *testing.T) {
+	blocks := transformTestBlocks(t, Options{
+		BlockTransform: func(path []string, b *ConfigBlock) (*ConfigBlock, bool) {
+			return b, b.Name != "server_config"
+		},
+	})
+
+	// The entries referencing the dropped root block are dropped too.
+	require.Len(t, blocks, 1)
+	require.Len(t, blocks[0].Entries, 1)
+	assert.Equal(t, "store", blocks[0].Entries[0].Name)
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"strings"
+)
+
+type TypeKind string
+
+const (
+	// TypeScalar is a type documented by its name, like "int", "duration" or "url".
+	TypeScalar TypeKind = "scalar"
+	TypeList   TypeKind = "list"
+	TypeMap    TypeKind = "map"
+)
+
+const (
+	listTypePrefix = "list of "
+	mapTypePrefix  = "map of "
+	mapTypeSep     = " to "
+)
+
+// TypeSpec is the structured type of a config field, whose String() is the documented type,
+// like "list of duration" or "map of string to int".
+type TypeSpec struct {
+	Kind TypeKind
+
+	// In case the Kind is TypeList or TypeMap, the type of the values.
+	Elem *TypeSpec
+
+	// In case the Kind is TypeMap, the type of the keys.
+	Key *TypeSpec
+
+	// In case the Kind is TypeScalar, the name of the type. Map values are named after
+	// their Go type, like "float64" or "validation.ForwardingRule".
+	Name string
+
+	// Whether the value is optional, like a pointer to a scalar which is unset when nil.
+	// It isn't part of the documented type.
+	Optional bool
+}
+
+func scalarType(name string) *TypeSpec {
+	return &TypeSpec{Kind: TypeScalar, Name: name}
+}
+
+func listType(elem *TypeSpec) *TypeSpec {
+	return &TypeSpec{Kind: TypeList, Elem: elem}
+}
+
+func mapType(key, elem *TypeSpec) *TypeSpec {
+	return &TypeSpec{Kind: TypeMap, Key: key, Elem: elem}
+}
+
+// String returns the documented type, like "list of duration".
+func (s *TypeSpec) String() string {
+	if s == nil {
+		return ""
+	}
+
+	switch s.Kind {
+	case TypeList:
+		return listTypePrefix + s.Elem.String()
+	case TypeMap:
+		return mapTypePrefix + s.Key.String() + mapTypeSep + s.Elem.String()
+	default:
+		return s.Name
+	}
+}
+
+// ParseTypeSpec returns the TypeSpec of a documented type, as returned by TypeSpec.String.
+// Any type which is neither a list nor a map is a scalar named after the type.
+func ParseTypeSpec(typ string) *TypeSpec {
+	if strings.HasPrefix(typ, listTypePrefix) {
+		return listType(ParseTypeSpec(strings.TrimPrefix(typ, listTypePrefix)))
+	}
+
+	// Keys and values are scalars, so the first separator ends the key type.
+	if strings.HasPrefix(typ, mapTypePrefix) {
+		if key, elem, ok := cutString(strings.TrimPrefix(typ, mapTypePrefix), mapTypeSep); ok {
+			return mapType(scalarType(key), scalarType(elem))
+		}
+	}
+
+	return scalarType(typ)
+}
+
+// cutString is like strings.Cut, which isn't available in Go 1.17.
+func cutString(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
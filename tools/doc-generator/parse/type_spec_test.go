@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/ingester/activeseries"
+	"github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestGetFieldType_TypeSpec(t *testing.T) {
+	type tier string
+	type nested struct{}
+
+	tests := []struct {
+		typ      reflect.Type
+		expected string
+		spec     *TypeSpec
+	}{
+		// Custom types.
+		{typ: reflect.TypeOf(&url.URL{}), expected: "url", spec: scalarType("url")},
+		{typ: reflect.TypeOf(time.Duration(0)), expected: "duration", spec: scalarType("duration")},
+		{typ: reflect.TypeOf(time.Time{}), expected: "time", spec: scalarType("time")},
+		{typ: reflect.TypeOf(flagext.DayValue{}), expected: "date", spec: scalarType("date")},
+		{typ: reflect.TypeOf(flagext.StringSliceCSV{}), expected: "string", spec: scalarType("string")},
+		{typ: reflect.TypeOf(flagext.CIDRSliceCSV{}), expected: "string", spec: scalarType("string")},
+		{typ: reflect.TypeOf([]*relabel.Config{}), expected: "relabel_config...", spec: scalarType("relabel_config...")},
+		{typ: reflect.TypeOf(model.LabelSet{}), expected: "map of string to string", spec: mapType(scalarType("string"), scalarType("string"))},
+		{typ: reflect.TypeOf(labels.Labels{}), expected: "map of string to string", spec: mapType(scalarType("string"), scalarType("string"))},
+		{typ: reflect.TypeOf([]model.LabelName{}), expected: "list of string", spec: listType(scalarType("string"))},
+		{typ: reflect.TypeOf(model.LabelNames{}), expected: "list of string", spec: listType(scalarType("string"))},
+		{typ: reflect.TypeOf([]model.LabelValue{}), expected: "list of string", spec: listType(scalarType("string"))},
+		{typ: reflect.TypeOf(model.LabelValues{}), expected: "list of string", spec: listType(scalarType("string"))},
+		{typ: reflect.TypeOf([]*labels.Matcher{}), expected: "list of label matcher", spec: listType(scalarType("label matcher"))},
+		{typ: reflect.TypeOf(labels.Selector{}), expected: "list of label matcher", spec: listType(scalarType("label matcher"))},
+		{typ: reflect.TypeOf(map[string]float64{}), expected: "map of string to float64", spec: mapType(scalarType("string"), scalarType("float64"))},
+		{typ: reflect.TypeOf(activeseries.CustomTrackersConfig{}), expected: "map of tracker name (string) to matcher (string)", spec: mapType(scalarType("tracker name (string)"), scalarType("matcher (string)"))},
+
+		// Built-in types.
+		{typ: reflect.TypeOf(false), expected: "boolean", spec: scalarType("boolean")},
+		{typ: reflect.TypeOf(int(0)), expected: "int", spec: scalarType("int")},
+		{typ: reflect.TypeOf(int8(0)), expected: "int", spec: scalarType("int")},
+		{typ: reflect.TypeOf(int16(0)), expected: "int", spec: scalarType("int")},
+		{typ: reflect.TypeOf(int32(0)), expected: "int", spec: scalarType("int")},
+		{typ: reflect.TypeOf(int64(0)), expected: "int", spec: scalarType("int")},
+		{typ: reflect.TypeOf(uint(0)), expected: "int", spec: scalarType("int")},
+		{typ: reflect.TypeOf(uint8(0)), expected: "int", spec: scalarType("int")},
+		{typ: reflect.TypeOf(uint16(0)), expected: "int", spec: scalarType("int")},
+		{typ: reflect.TypeOf(uint32(0)), expected: "int", spec: scalarType("int")},
+		{typ: reflect.TypeOf(uint64(0)), expected: "int", spec: scalarType("int")},
+		{typ: reflect.TypeOf(float32(0)), expected: "float", spec: scalarType("float")},
+		{typ: reflect.TypeOf(float64(0)), expected: "float", spec: scalarType("float")},
+		{typ: reflect.TypeOf(""), expected: "string", spec: scalarType("string")},
+		{typ: reflect.TypeOf(tier("")), expected: "string", spec: scalarType("string")},
+		{typ: reflect.TypeOf([]int{}), expected: "list of int", spec: listType(scalarType("int"))},
+		{typ: reflect.TypeOf([]time.Duration{}), expected: "list of duration", spec: listType(scalarType("duration"))},
+		{typ: reflect.TypeOf([][]string{}), expected: "list of list of string", spec: listType(listType(scalarType("string")))},
+		{typ: reflect.TypeOf(map[string]string{}), expected: "map of string to string", spec: mapType(scalarType("string"), scalarType("string"))},
+		{typ: reflect.TypeOf(map[int32]string{}), expected: "map of int to string", spec: mapType(scalarType("int"), scalarType("string"))},
+		{typ: reflect.TypeOf(map[tier]int{}), expected: "map of string to int", spec: mapType(scalarType("string"), scalarType("int"))},
+		{typ: reflect.TypeOf(map[string]validation.ForwardingRule{}), expected: "map of string to validation.ForwardingRule", spec: mapType(scalarType("string"), scalarType("validation.ForwardingRule"))},
+		{typ: reflect.TypeOf(nested{}), expected: "nested", spec: scalarType("nested")},
+		{typ: reflect.TypeOf(&nested{}), expected: "nested", spec: scalarType("nested")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.typ.String(), func(t *testing.T) {
+			spec, err := getFieldType(test.typ)
+			require.NoError(t, err)
+			assert.Equal(t, test.spec, spec)
+
+			// The rendering of the spec is the documented type, which parses back to the same spec.
+			assert.Equal(t, test.expected, spec.String())
+			assert.Equal(t, test.spec, ParseTypeSpec(test.expected))
+		})
+	}
+}
+
+func TestGetFieldType_UnsupportedTypes(t *testing.T) {
+	for _, typ := range []reflect.Type{
+		reflect.TypeOf(func() {}),
+		reflect.TypeOf(make(chan int)),
+		reflect.TypeOf([]func(){}),
+		reflect.TypeOf(map[struct{}]string{}),
+	} {
+		spec, err := getFieldType(typ)
+		assert.Error(t, err, typ.String())
+		assert.Nil(t, spec, typ.String())
+	}
+}
+
+func TestGetFieldType_OptionalScalar(t *testing.T) {
+	spec, err := getFieldType(reflect.TypeOf(new(int)))
+	require.NoError(t, err)
+	assert.Equal(t, &TypeSpec{Kind: TypeScalar, Name: "int", Optional: true}, spec)
+
+	// Optionality isn't part of the documented type.
+	assert.Equal(t, "int", spec.String())
+}
+
+func TestReflectType_TypeSpec(t *testing.T) {
+	assert.Equal(t, reflect.TypeOf(tsdb.DurationList{}), ReflectType(listType(scalarType("duration"))))
+	assert.Equal(t, reflect.TypeOf(map[string]validation.ForwardingRule{}), ReflectType(ParseTypeSpec("map of string to validation.ForwardingRule")))
+	assert.Panics(t, func() { ReflectType(scalarType("unknown")) })
+}
+
+func TestReflectType_OptionalScalars(t *testing.T) {
+	assert.Equal(t, reflect.TypeOf((*int)(nil)), ReflectType(&TypeSpec{Kind: TypeScalar, Name: "int", Optional: true}))
+	assert.Equal(t, reflect.TypeOf((*time.Duration)(nil)), ReflectType(&TypeSpec{Kind: TypeScalar, Name: "duration", Optional: true}))
+}
+
+func TestTypeSpec_String(t *testing.T) {
+	var spec *TypeSpec
+	assert.Equal(t, "", spec.String())
+}
@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// UnusedCandidates returns the field entries of the blocks, as returned by Config, whose CLI flags
+// aren't in used, as candidates for deprecation, sorted by path. used is the list of the CLI flags
+// known to be read by some component, like the ones found by a static analysis. A field registered
+// with multiple prefixes is used if any of its flags is, and fields without a CLI flag can't be
+// checked, so they're never reported. Fields whose path or CLI flag matches any of the exclude
+// patterns, as matched by path.Match (like "memberlist.*"), aren't reported either.
+func UnusedCandidates(blocks []*ConfigBlock, used map[string]struct{}, exclude []string) ([]FlatEntry, error) {
+	for _, pattern := range exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+		}
+	}
+
+	var candidates []FlatEntry
+	if len(blocks) > 0 {
+		candidates = appendUnusedCandidates(candidates, blocks[0], "", used, exclude)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Path < candidates[j].Path
+	})
+	return candidates, nil
+}
+
+func appendUnusedCandidates(candidates []FlatEntry, block *ConfigBlock, blockPath string, used map[string]struct{}, exclude []string) []FlatEntry {
+	for _, entry := range block.Entries {
+		entryPath := joinPath(blockPath, entry.Name)
+		if entry.Kind == KindBlock {
+			candidates = appendUnusedCandidates(candidates, entry.Block, entryPath, used, exclude)
+			continue
+		}
+
+		if entry.FieldFlag == "" {
+			continue
+		}
+
+		flags := entryFlags(entry)
+		if isAnyFlagUsed(flags, used) || isExcluded(entryPath, flags, exclude) {
+			continue
+		}
+		candidates = append(candidates, FlatEntry{Path: entryPath, Entry: entry})
+	}
+	return candidates
+}
+
+func isAnyFlagUsed(flags []string, used map[string]struct{}) bool {
+	for _, name := range flags {
+		if _, ok := used[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isExcluded(entryPath string, flags []string, exclude []string) bool {
+	for _, pattern := range exclude {
+		for _, name := range append([]string{entryPath}, flags...) {
+			// Patterns are validated upfront.
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WriteUnusedCandidates writes the candidates, as returned by UnusedCandidates, as tab-separated
+// values with a header: the YAML path, the comma-separated CLI flags and the category of each field.
+// The format is meant to be consumed by scripts, so columns are only ever appended.
+func WriteUnusedCandidates(w io.Writer, candidates []FlatEntry) error {
+	if _, err := fmt.Fprintln(w, "path\tflags\tcategory"); err != nil {
+		return err
+	}
+	for _, c := range candidates {
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\n", c.Path, strings.Join(entryFlags(c.Entry), ","), entryCategory(c.Entry)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type unusedInlineConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Legacy  bool `yaml:"legacy" category:"advanced"`
+}
+
+func TestUnusedCandidates(t *testing.T) {
+	type config struct {
+		unusedInlineConfig `yaml:",inline"`
+
+		Single prefixedClientConfig `yaml:"single"`
+		Shared prefixedClientConfig `yaml:"shared"`
+		Local  string               `yaml:"local"`
+		NoCLI  string               `yaml:"no_cli"`
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.BoolVar(&cfg.Enabled, "enabled", false, "")
+	fs.BoolVar(&cfg.Legacy, "legacy", false, "")
+	cfg.Single.RegisterFlagsWithPrefix("single.", fs)
+	cfg.Shared.RegisterFlagsWithPrefix("ruler.", fs)
+	cfg.Shared.RegisterFlagsWithPrefix("alertmanager.", fs)
+	fs.StringVar(&cfg.Local, "local", "", "")
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+
+	used := map[string]struct{}{
+		"enabled":                {},
+		"single.client.endpoint": {},
+		// A field registered with multiple prefixes is used if any of its flags is.
+		"ruler.client.endpoint": {},
+	}
+
+	candidates, err := UnusedCandidates(blocks, used, nil)
+	require.NoError(t, err)
+
+	var paths []string
+	for _, c := range candidates {
+		paths = append(paths, c.Path)
+	}
+	assert.Equal(t, []string{"legacy", "local", "shared.timeout", "single.timeout"}, paths)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, WriteUnusedCandidates(out, candidates))
+	assert.Equal(t, "path\tflags\tcategory\n"+
+		"legacy\tlegacy\tadvanced\n"+
+		"local\tlocal\tbasic\n"+
+		"shared.timeout\talertmanager.client.timeout,ruler.client.timeout\tbasic\n"+
+		"single.timeout\tsingle.client.timeout\tbasic\n", out.String())
+
+	// Fields can be excluded either by path or by CLI flag.
+	candidates, err = UnusedCandidates(blocks, used, []string{"single.*", "ruler.client.*", "loc?l"})
+	require.NoError(t, err)
+
+	paths = nil
+	for _, c := range candidates {
+		paths = append(paths, c.Path)
+	}
+	assert.Equal(t, []string{"legacy"}, paths)
+}
+
+func TestUnusedCandidates_InvalidExcludePattern(t *testing.T) {
+	_, err := UnusedCandidates(nil, nil, []string{"single.["})
+	assert.EqualError(t, err, `invalid exclude pattern "single.[": syntax error in pattern`)
+}
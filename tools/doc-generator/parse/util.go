@@ -7,9 +7,35 @@ package parse
 
 import (
 	"math"
+	"strconv"
 	"strings"
 )
 
+// byteUnits are the binary units of FormatBytes, from the largest.
+var byteUnits = []struct {
+	name string
+	size uint64
+}{
+	{"EiB", 1 << 60},
+	{"PiB", 1 << 50},
+	{"TiB", 1 << 40},
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+}
+
+// FormatBytes returns the size n in the largest binary unit it's a multiple of, like "1GiB"
+// for 1073741824 or "1536KiB" for 1572864, or in bytes, like "1000B". The size is never
+// rounded, so that the formatted value is as precise as the raw one.
+func FormatBytes(n uint64) string {
+	for _, unit := range byteUnits {
+		if n != 0 && n%unit.size == 0 {
+			return strconv.FormatUint(n/unit.size, 10) + unit.name
+		}
+	}
+	return strconv.FormatUint(n, 10) + "B"
+}
+
 func FindFlagsPrefix(flags []string) []string {
 	if len(flags) == 0 {
 		return flags
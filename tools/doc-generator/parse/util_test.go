@@ -6,6 +6,7 @@
 package parse
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -50,3 +51,28 @@ func Test_findFlagsPrefix(t *testing.T) {
 		assert.Equal(t, test.expected, FindFlagsPrefix(test.input))
 	}
 }
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[uint64]string{
+		0:                "0B",
+		1:                "1B",
+		1000:             "1000B",
+		1024:             "1KiB",
+		1536:             "1536B",
+		1572864:          "1536KiB",
+		16 * 1024 * 1024: "16MiB",
+		104857600:        "100MiB",
+		1073741824:       "1GiB",
+		2 * 1073741824:   "2GiB",
+		1073741824 + 1:   "1073741825B",
+		1 << 40:          "1TiB",
+		1 << 50:          "1PiB",
+		1 << 60:          "1EiB",
+		math.MaxUint64:   "18446744073709551615B",
+		15 * (1 << 60):   "15EiB",
+	}
+
+	for input, expected := range tests {
+		assert.Equal(t, expected, FormatBytes(input), input)
+	}
+}
@@ -0,0 +1,307 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/regexp"
+)
+
+// snakeCaseRegexp matches the names following the snake_case convention of the YAML keys.
+var snakeCaseRegexp = regexp.MustCompile("^[a-z0-9_]+$")
+
+// maxFieldWarningLength is the maximum length of a field warning, which is
+// meant to be a short caveat rather than a replacement of the description.
+const maxFieldWarningLength = 200
+
+// ValidateRequiredBlocks returns an error for each block marked as required whose
+// entries are all optional and have no default value. Such a block can be omitted
+// from the config without consequences, which usually signals a mis-tagged struct.
+func ValidateRequiredBlocks(blocks []*ConfigBlock) []error {
+	var errs []error
+	for _, block := range blocks {
+		errs = append(errs, validateRequiredBlock(block, block.Name)...)
+	}
+	return errs
+}
+
+func validateRequiredBlock(block *ConfigBlock, path string) []error {
+	var errs []error
+	for _, entry := range block.Entries {
+		// Root blocks are validated on their own.
+		if entry.Kind != KindBlock || entry.Root {
+			continue
+		}
+
+		entryPath := joinPath(path, entry.Name)
+		if entry.Required && !hasRequiredOrSetEntry(entry.Block) {
+			errs = append(errs, fmt.Errorf("block %s is required but all its fields are optional and have no default", entryPath))
+		}
+
+		errs = append(errs, validateRequiredBlock(entry.Block, entryPath)...)
+	}
+	return errs
+}
+
+// hasRequiredOrSetEntry returns whether the block contains at least one entry which
+// is required or has a default value, recursing into non-root sub-blocks.
+func hasRequiredOrSetEntry(block *ConfigBlock) bool {
+	for _, entry := range block.Entries {
+		if entry.Required {
+			return true
+		}
+
+		switch entry.Kind {
+		case KindBlock:
+			if entry.Root || hasRequiredOrSetEntry(entry.Block) {
+				return true
+			}
+		default:
+			if entry.FieldDefault != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ValidateAliases returns an error for each field which is set by the same CLI flag as other
+// fields, but isn't tagged as alias to confirm it's intended. Such errors are meant to be
+// reported as warnings, to audit the aliases.
+func ValidateAliases(blocks []*ConfigBlock) []error {
+	var errs []error
+	for _, block := range blocks {
+		errs = append(errs, validateAliases(block, block.Name)...)
+	}
+	return errs
+}
+
+func validateAliases(block *ConfigBlock, path string) []error {
+	var errs []error
+	for _, entry := range block.Entries {
+		entryPath := joinPath(path, entry.Name)
+		if entry.Kind == KindBlock {
+			// Root blocks are validated on their own.
+			if !entry.Root {
+				errs = append(errs, validateAliases(entry.Block, entryPath)...)
+			}
+			continue
+		}
+
+		if len(entry.AliasesOf) > 0 && !entry.Alias {
+			errs = append(errs, fmt.Errorf("field %s shares the CLI flag -%s with %s but isn't tagged as alias", entryPath, entry.FieldFlag, strings.Join(entry.AliasesOf, ", ")))
+		}
+	}
+	return errs
+}
+
+// ValidateFieldWarnings returns an error for each field warning which is longer
+// than maxFieldWarningLength or doesn't end with a terminal punctuation mark.
+func ValidateFieldWarnings(blocks []*ConfigBlock) []error {
+	var errs []error
+	for _, block := range blocks {
+		errs = append(errs, validateFieldWarnings(block, block.Name)...)
+	}
+	return errs
+}
+
+func validateFieldWarnings(block *ConfigBlock, path string) []error {
+	var errs []error
+	for _, entry := range block.Entries {
+		entryPath := joinPath(path, entry.Name)
+
+		if entry.Kind == KindBlock {
+			// Root blocks are validated on their own.
+			if !entry.Root {
+				errs = append(errs, validateFieldWarnings(entry.Block, entryPath)...)
+			}
+			continue
+		}
+
+		for _, warning := range entry.FieldWarnings {
+			if len(warning) > maxFieldWarningLength {
+				errs = append(errs, fmt.Errorf("field %s has a warning longer than %d characters", entryPath, maxFieldWarningLength))
+			}
+			if !strings.HasSuffix(warning, ".") && !strings.HasSuffix(warning, "!") && !strings.HasSuffix(warning, "?") {
+				errs = append(errs, fmt.Errorf("field %s has a warning not ending with a terminal punctuation mark: %q", entryPath, warning))
+			}
+		}
+	}
+	return errs
+}
+
+// ValidateStartupOnly returns an error for each field which is both reloadable and only
+// applied at startup, either through its doc tags or MarkReloadable.
+func ValidateStartupOnly(blocks []*ConfigBlock) []error {
+	var errs []error
+	for _, block := range blocks {
+		errs = append(errs, validateStartupOnly(block, block.Name)...)
+	}
+	return errs
+}
+
+func validateStartupOnly(block *ConfigBlock, path string) []error {
+	var errs []error
+	for _, entry := range block.Entries {
+		entryPath := joinPath(path, entry.Name)
+		if entry.Kind == KindBlock {
+			// Root blocks are validated on their own.
+			if !entry.Root {
+				errs = append(errs, validateStartupOnly(entry.Block, entryPath)...)
+			}
+			continue
+		}
+
+		if entry.FieldReloadable && entry.StartupOnly {
+			errs = append(errs, fmt.Errorf("field %s is both reloadable and startup-only", entryPath))
+		}
+	}
+	return errs
+}
+
+// ValidateZeroMeanings returns an error for each duration field defaulting to 0 which doesn't
+// document what 0 means, either through the zero doc tag or a sentinel, since it may mean
+// "disabled", "no timeout" or "use the default".
+func ValidateZeroMeanings(blocks []*ConfigBlock) []error {
+	var errs []error
+	for _, block := range blocks {
+		errs = append(errs, validateZeroMeanings(block, block.Name)...)
+	}
+	return errs
+}
+
+func validateZeroMeanings(block *ConfigBlock, path string) []error {
+	var errs []error
+	for _, entry := range block.Entries {
+		entryPath := joinPath(path, entry.Name)
+		if entry.Kind == KindBlock {
+			// Root blocks are validated on their own.
+			if !entry.Root {
+				errs = append(errs, validateZeroMeanings(entry.Block, entryPath)...)
+			}
+			continue
+		}
+
+		if entry.FieldType != "duration" || entry.FieldDefault != zeroDuration || entry.FieldZeroMeaning != "" {
+			continue
+		}
+		if _, ok := entry.FieldSentinels[zeroDuration]; !ok {
+			errs = append(errs, fmt.Errorf("duration field %s defaults to 0 without documenting what 0 means", entryPath))
+		}
+	}
+	return errs
+}
+
+// ValidateCLIFlags returns an error for each advanced or experimental field which can't be set
+// through a CLI flag and isn't tagged as nocli, since such fields are expected to be toggled
+// in tests without a config file. Fields whose path, or the path of one of their parent blocks,
+// is listed in allowlist are never reported.
+func ValidateCLIFlags(blocks []*ConfigBlock, allowlist []string) []error {
+	allowed := make(map[string]struct{}, len(allowlist))
+	for _, path := range allowlist {
+		allowed[path] = struct{}{}
+	}
+
+	var errs []error
+	for _, block := range blocks {
+		errs = append(errs, validateCLIFlags(block, block.Name, allowed)...)
+	}
+	return errs
+}
+
+func validateCLIFlags(block *ConfigBlock, path string, allowed map[string]struct{}) []error {
+	var errs []error
+	for _, entry := range block.Entries {
+		entryPath := joinPath(path, entry.Name)
+		if _, ok := allowed[entryPath]; ok {
+			continue
+		}
+
+		if entry.Kind == KindBlock {
+			// Root blocks are validated on their own.
+			if !entry.Root {
+				errs = append(errs, validateCLIFlags(entry.Block, entryPath, allowed)...)
+			}
+			continue
+		}
+
+		if entry.FieldCategory != "advanced" && entry.FieldCategory != "experimental" {
+			continue
+		}
+		if entry.FieldFlag == "" && !entry.NoCLI {
+			errs = append(errs, fmt.Errorf("%s field %s has no CLI flag and isn't tagged as nocli", entry.FieldCategory, entryPath))
+		}
+	}
+	return errs
+}
+
+// ValidateSnakeCaseNames returns an error for each entry and each root block whose name isn't
+// snake_case, including the entries promoted from inline structs. The names listed in allowlist,
+// either entry paths or root block names, are never reported, so that the existing names, which
+// can't be renamed without breaking the config, can be grandfathered.
+func ValidateSnakeCaseNames(blocks []*ConfigBlock, allowlist []string) []error {
+	v := snakeCaseValidator{
+		allowed:    make(map[string]struct{}, len(allowlist)),
+		rootBlocks: map[string]struct{}{},
+	}
+	for _, path := range allowlist {
+		v.allowed[path] = struct{}{}
+	}
+
+	for _, block := range blocks {
+		v.validateBlock(block, block.Name)
+	}
+	return v.errs
+}
+
+type snakeCaseValidator struct {
+	allowed map[string]struct{}
+
+	// Names of the root blocks validated so far, since they can be referenced multiple times.
+	rootBlocks map[string]struct{}
+
+	errs []error
+}
+
+func (v *snakeCaseValidator) validateBlock(block *ConfigBlock, path string) {
+	for _, entry := range block.Entries {
+		entryPath := joinPath(path, entry.Name)
+
+		if _, ok := v.allowed[entryPath]; !ok && !snakeCaseRegexp.MatchString(entry.Name) {
+			if len(entry.InlinedFrom) > 0 {
+				v.errs = append(v.errs, fmt.Errorf("entry %s, inlined from %s, isn't snake_case", entryPath, strings.Join(entry.InlinedFrom, ".")))
+			} else {
+				v.errs = append(v.errs, fmt.Errorf("entry %s isn't snake_case", entryPath))
+			}
+		}
+
+		if entry.RefBlock != "" {
+			v.validateRootBlockName(entry.RefBlock)
+		}
+
+		// Root blocks are validated on their own.
+		if entry.Kind == KindBlock && !entry.Root {
+			v.validateBlock(entry.Block, entryPath)
+		}
+	}
+}
+
+func (v *snakeCaseValidator) validateRootBlockName(name string) {
+	if _, ok := v.rootBlocks[name]; ok {
+		return
+	}
+	v.rootBlocks[name] = struct{}{}
+
+	if _, ok := v.allowed[name]; !ok && !snakeCaseRegexp.MatchString(name) {
+		v.errs = append(v.errs, fmt.Errorf("block %s isn't snake_case", name))
+	}
+}
+
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
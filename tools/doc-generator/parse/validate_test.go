@@ -0,0 +1,325 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package parse
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateRequiredBlocks(t *testing.T) {
+	type innerRequired struct {
+		Address string `yaml:"address" doc:"required"`
+		Timeout int    `yaml:"timeout"`
+	}
+
+	type innerOptional struct {
+		Address string `yaml:"address"`
+		Timeout int    `yaml:"timeout"`
+	}
+
+	tests := map[string]struct {
+		cfg      interface{}
+		expected []string
+	}{
+		"required block with a required field": {
+			cfg: &struct {
+				Inner innerRequired `yaml:"inner" doc:"required"`
+			}{},
+		},
+		"optional block with only optional fields": {
+			cfg: &struct {
+				Inner innerOptional `yaml:"inner"`
+			}{},
+		},
+		"required block with only optional fields": {
+			cfg: &struct {
+				Inner innerOptional `yaml:"inner" doc:"required"`
+			}{},
+			expected: []string{"block inner is required but all its fields are optional and have no default"},
+		},
+		"nested required block with only optional fields": {
+			cfg: &struct {
+				Outer struct {
+					Inner innerOptional `yaml:"inner" doc:"required"`
+				} `yaml:"outer"`
+			}{},
+			expected: []string{"block outer.inner is required but all its fields are optional and have no default"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			blocks, err := Config(test.cfg, nil, nil)
+			require.NoError(t, err)
+
+			var actual []string
+			for _, err := range ValidateRequiredBlocks(blocks) {
+				actual = append(actual, err.Error())
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestValidateRequiredBlocks_FieldWithDefault(t *testing.T) {
+	cfg := &struct {
+		Inner struct {
+			Address string `yaml:"address"`
+		} `yaml:"inner" doc:"required"`
+	}{}
+
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.StringVar(&cfg.Inner.Address, "inner.address", "localhost", "")
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+	assert.Empty(t, ValidateRequiredBlocks(blocks))
+}
+
+func TestValidateFieldWarnings(t *testing.T) {
+	tests := map[string]struct {
+		warnings []string
+		expected []string
+	}{
+		"valid warnings": {
+			warnings: []string{"Requires filesystem write access.", "Really?", "Beware!"},
+		},
+		"missing terminal punctuation": {
+			warnings: []string{"Requires filesystem write access"},
+			expected: []string{`field outer.path has a warning not ending with a terminal punctuation mark: "Requires filesystem write access"`},
+		},
+		"too long": {
+			warnings: []string{strings.Repeat("a", maxFieldWarningLength) + "."},
+			expected: []string{"field outer.path has a warning longer than 200 characters"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			blocks := []*ConfigBlock{{
+				Entries: []*ConfigEntry{{
+					Kind: KindBlock,
+					Name: "outer",
+					Block: &ConfigBlock{
+						Entries: []*ConfigEntry{{Kind: KindField, Name: "path", FieldType: "string", FieldWarnings: test.warnings}},
+					},
+				}},
+			}}
+
+			var actual []string
+			for _, err := range ValidateFieldWarnings(blocks) {
+				actual = append(actual, err.Error())
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestValidateStartupOnly(t *testing.T) {
+	type config struct {
+		Rate   int `yaml:"rate" doc:"reloadable"`
+		Port   int `yaml:"port" doc:"startup-only"`
+		Both   int `yaml:"both" doc:"reloadable|startup-only"`
+		Nested struct {
+			Registered int `yaml:"registered" doc:"startup-only"`
+		} `yaml:"nested"`
+	}
+
+	markTestReloadable(t, "nested.registered")
+
+	blocks, err := Config(&config{}, nil, nil)
+	require.NoError(t, err)
+
+	entries := blocks[0].Entries
+	assert.False(t, entries[0].StartupOnly)
+	assert.True(t, entries[1].StartupOnly)
+	assert.False(t, entries[1].FieldReloadable)
+
+	var actual []string
+	for _, err := range ValidateStartupOnly(blocks) {
+		actual = append(actual, err.Error())
+	}
+	assert.Equal(t, []string{
+		"field both is both reloadable and startup-only",
+		"field nested.registered is both reloadable and startup-only",
+	}, actual)
+}
+
+func TestValidateZeroMeanings(t *testing.T) {
+	type config struct {
+		Timeout   time.Duration  `yaml:"timeout"`
+		Retention model.Duration `yaml:"retention"`
+		Interval  time.Duration  `yaml:"interval" doc:"zero=disabled"`
+		Period    model.Duration `yaml:"period" doc:"sentinel=0s:never"`
+		Delay     time.Duration  `yaml:"delay"`
+		Nested    struct {
+			Backoff model.Duration `yaml:"backoff"`
+		} `yaml:"nested"`
+	}
+
+	cfg := &config{}
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.DurationVar(&cfg.Timeout, "timeout", 0, "")
+	fs.Var(&cfg.Retention, "retention", "")
+	fs.DurationVar(&cfg.Interval, "interval", 0, "")
+	fs.Var(&cfg.Period, "period", "")
+	fs.DurationVar(&cfg.Delay, "delay", time.Second, "")
+	fs.Var(&cfg.Nested.Backoff, "nested.backoff", "")
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+
+	// Both std and model durations defaulting to 0 are reported, unless documented.
+	var actual []string
+	for _, err := range ValidateZeroMeanings(blocks) {
+		actual = append(actual, err.Error())
+	}
+	assert.Equal(t, []string{
+		"duration field timeout defaults to 0 without documenting what 0 means",
+		"duration field retention defaults to 0 without documenting what 0 means",
+		"duration field nested.backoff defaults to 0 without documenting what 0 means",
+	}, actual)
+}
+
+func TestValidateCLIFlags(t *testing.T) {
+	type inner struct {
+		Flagged  int `yaml:"flagged" category:"experimental"`
+		Unbound  int `yaml:"unbound" category:"experimental"`
+		NoCLI    int `yaml:"nocli" category:"advanced" doc:"nocli"`
+		Advanced int `yaml:"advanced" category:"advanced"`
+		Basic    int `yaml:"basic"`
+	}
+
+	cfg := &struct {
+		First  inner `yaml:"first"`
+		Second inner `yaml:"second"`
+	}{}
+
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.IntVar(&cfg.First.Flagged, "first.flagged", 0, "")
+	fs.IntVar(&cfg.Second.Flagged, "second.flagged", 0, "")
+
+	blocks, err := Config(cfg, testFlags(fs), nil)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		allowlist []string
+		expected  []string
+	}{
+		"no allowlist": {
+			expected: []string{
+				"experimental field first.unbound has no CLI flag and isn't tagged as nocli",
+				"advanced field first.advanced has no CLI flag and isn't tagged as nocli",
+				"experimental field second.unbound has no CLI flag and isn't tagged as nocli",
+				"advanced field second.advanced has no CLI flag and isn't tagged as nocli",
+			},
+		},
+		"allowlisted fields": {
+			allowlist: []string{"first.unbound", "second.advanced"},
+			expected: []string{
+				"advanced field first.advanced has no CLI flag and isn't tagged as nocli",
+				"experimental field second.unbound has no CLI flag and isn't tagged as nocli",
+			},
+		},
+		"allowlisted block": {
+			allowlist: []string{"first"},
+			expected: []string{
+				"experimental field second.unbound has no CLI flag and isn't tagged as nocli",
+				"advanced field second.advanced has no CLI flag and isn't tagged as nocli",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var actual []string
+			for _, err := range ValidateCLIFlags(blocks, test.allowlist) {
+				actual = append(actual, err.Error())
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+type SnakeCaseInlineConfig struct {
+	InlinedName string `yaml:"inlinedName"`
+	Valid       int    `yaml:"valid_2"`
+}
+
+func TestValidateSnakeCaseNames(t *testing.T) {
+	type element struct {
+		ElementName string `yaml:"elementName"`
+	}
+
+	type rootConfig struct {
+		RootField string `yaml:"rootField"`
+	}
+
+	cfg := &struct {
+		SnakeCaseInlineConfig `yaml:",inline"`
+		Valid                 string `yaml:"valid_name"`
+		CamelCase             string `yaml:"camelCase"`
+		Nested                struct {
+			Kebab string `yaml:"kebab-case"`
+		} `yaml:"nestedBlock"`
+		Elements []element  `yaml:"elements"`
+		Root     rootConfig `yaml:"root"`
+	}{}
+
+	rootBlocks := []RootBlock{{Name: "rootConfig", StructType: reflect.TypeOf(rootConfig{})}}
+	blocks, err := Config(cfg, nil, rootBlocks)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		allowlist []string
+		expected  []string
+	}{
+		"no allowlist": {
+			expected: []string{
+				"entry inlinedName, inlined from SnakeCaseInlineConfig, isn't snake_case",
+				"entry camelCase isn't snake_case",
+				"entry nestedBlock isn't snake_case",
+				"entry nestedBlock.kebab-case isn't snake_case",
+				"block rootConfig isn't snake_case",
+				"entry elements.elementName isn't snake_case",
+				"entry rootConfig.rootField isn't snake_case",
+			},
+		},
+		"allowlisted names": {
+			// Allowlisting a block doesn't allowlist its entries.
+			allowlist: []string{"inlinedName", "camelCase", "nestedBlock", "elements.elementName", "rootConfig", "rootConfig.rootField"},
+			expected: []string{
+				"entry nestedBlock.kebab-case isn't snake_case",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var actual []string
+			for _, err := range ValidateSnakeCaseNames(blocks, test.allowlist) {
+				actual = append(actual, err.Error())
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+// testFlags maps the flags registered in fs by the address of their value,
+// the same way Flags does.
+func testFlags(fs *flag.FlagSet) map[uintptr][]*flag.Flag {
+	flags := map[uintptr][]*flag.Flag{}
+	fs.VisitAll(func(f *flag.Flag) {
+		ptr := reflect.ValueOf(f.Value).Pointer()
+		flags[ptr] = append(flags[ptr], f)
+	})
+	return flags
+}